@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// writeChangePlan prints a human readable summary of the given change plan.
+func writeChangePlan(writer io.Writer, plan toggl.ChangePlan) {
+	fmt.Fprintf(writer, "Dry run: no changes were made.\n\n")
+
+	fmt.Fprintf(writer, "Time entries to create: %d\n", plan.TimeRecords)
+
+	if len(plan.MissingWorkspaces) > 0 {
+		fmt.Fprintf(writer, "\nWorkspaces that don't exist (%d time entries cannot be imported):\n", plan.UnresolvableTimeRecords)
+		for _, workspaceName := range plan.MissingWorkspaces {
+			fmt.Fprintf(writer, "  - %q\n", workspaceName)
+		}
+	}
+
+	if len(plan.Clients) > 0 {
+		fmt.Fprintf(writer, "\nClients to create:\n")
+		for _, client := range plan.Clients {
+			fmt.Fprintf(writer, "  - %q (Workspace: %q)\n", client.Name, client.Workspace.Name)
+		}
+	}
+
+	if len(plan.Projects) > 0 {
+		fmt.Fprintf(writer, "\nProjects to create:\n")
+		for _, project := range plan.Projects {
+			fmt.Fprintf(writer, "  - %q (Workspace: %q, Client: %q)\n", project.Name, project.Workspace.Name, project.Client.Name)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func Test_writeChangePlan_AllChangesAreListed(t *testing.T) {
+	// arrange
+	workspace := toggl.Workspace{ID: 1, Name: "My Workspace"}
+	client := toggl.Client{Name: "ACME", Workspace: workspace}
+
+	plan := toggl.ChangePlan{
+		MissingWorkspaces:       []string{"Old Co"},
+		Clients:                 []toggl.Client{client},
+		Projects:                []toggl.Project{toggl.Project{Name: "Website", Client: client, Workspace: workspace}},
+		TimeRecords:             12,
+		UnresolvableTimeRecords: 3,
+	}
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	writeChangePlan(&outputBuffer, plan)
+
+	// assert
+	result := outputBuffer.String()
+	expectedLines := []string{
+		"Time entries to create: 12",
+		"Workspaces that don't exist (3 time entries cannot be imported)",
+		`"Old Co"`,
+		`"ACME" (Workspace: "My Workspace")`,
+		`"Website" (Workspace: "My Workspace", Client: "ACME")`,
+	}
+
+	for _, expected := range expectedLines {
+		if !strings.Contains(result, expected) {
+			t.Fail()
+			t.Logf("writeChangePlan should have printed %q but wrote: %s", expected, result)
+		}
+	}
+}
+
+func Test_writeChangePlan_NoNewEntities_SectionsAreOmitted(t *testing.T) {
+	// arrange
+	plan := toggl.ChangePlan{
+		TimeRecords: 2,
+	}
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	writeChangePlan(&outputBuffer, plan)
+
+	// assert
+	result := outputBuffer.String()
+	if strings.Contains(result, "Projects to create") || strings.Contains(result, "Clients to create") {
+		t.Fail()
+		t.Logf("writeChangePlan should not print empty sections: %s", result)
+	}
+}
@@ -20,8 +20,11 @@ func init() {
 }
 
 type togglCli struct {
-	importerFactory func(apiToken string) CSVImporter
-	exporterFactory func(apiToken string) CSVExporter
+	importerFactory  func(apiToken string, options ImportOptions) CSVImporter
+	exporterFactory  func(apiToken string, options ExportOptions) CSVExporter
+	validatorFactory func(format string, location *time.Location) CSVValidator
+	mergerFactory    func(maxGap time.Duration, location *time.Location) CSVMerger
+	reporterFactory  func(apiToken string, options ReportOptions) Reporter
 }
 
 // Execute parses the given arguments and performs the selected action.
@@ -36,12 +39,61 @@ func (cli *togglCli) Execute(input io.Reader, output, errorOutput io.Writer, arg
 	// export
 	exportCommand := app.Command("export", "Export your Toggl time tracking records as CSV")
 	exportAPIToken := exportCommand.Arg("token", "The Toggl API token of the source account").Required().String()
-	exportStartDate := exportCommand.Arg("startdate", "The start date (e.g. \"2006-01-26\")").Required().String()
-	exportEndDate := exportCommand.Arg("enddate", "The start date (e.g. \"2006-01-26\")").String()
+	exportStartDate := exportCommand.Arg("startdate", "The start date (e.g. \"2006-01-26\"), an ISO week (e.g. \"2016-W32\") or a named range (e.g. \"last-month\" or \"last-7d\")").Required().String()
+	exportEndDate := exportCommand.Arg("enddate", "The end date (e.g. \"2006-01-26\"), an ISO week or a named range (default: today or the end of the named start range)").String()
+	exportFormat := exportCommand.Flag("format", "The output format (csv, json, ndjson, xlsx or ics)").Default(formatCSV).Enum(formats...)
+	exportIncludeRunning := exportCommand.Flag("include-running", "Export the running time record with an empty stop date").Bool()
+	exportTimezone := exportCommand.Flag("timezone", "The time zone (e.g. \"Europe/Berlin\") of the start and end date and of the exported dates").String()
+	exportRounding := exportCommand.Flag("rounding", "A CSV file with rules for rounding the time records of clients and projects").String()
+	exportSplitAt := exportCommand.Flag("split-at", "Split time records at the boundaries of the given period (day, week or month) in the --timezone").Enum(splitPeriods...)
+	exportMerge := exportCommand.Flag("merge", "Merge consecutive time records with identical attributes").Bool()
+	exportMergeGap := exportCommand.Flag("merge-gap", "The largest gap between two time records that are merged (e.g. \"30s\" or \"2m\")").Default(defaultMaxMergeGap).Duration()
+	exportDurationFormat := exportCommand.Flag("duration-format", "Add a Duration column in the given format (clock, hours or minutes) next to Start and Stop").Enum(durationFormats...)
+	exportBillable := exportCommand.Flag("billable", "Add a Billable column (yes or no) to the csv and xlsx output").Bool()
+	exportFilterFlags := addFilterFlags(exportCommand, "export")
 
 	// import
 	importCommand := app.Command("import", "Import CSV-based time tracking records into Toggl from stdin")
 	importAPIToken := importCommand.Arg("token", "The Toggl API token of the target account").Required().String()
+	importFilePatterns := importCommand.Arg("files", "The CSV files or glob patterns to import (default: \"-\" for stdin)").Strings()
+	importFormat := importCommand.Flag("format", "The input format (csv, json, ndjson, xlsx or ics)").Default(formatCSV).Enum(formats...)
+	importSheet := importCommand.Flag("sheet", "The name of the worksheet that is imported from an xlsx input (default: the first worksheet)").String()
+	importCalendarMap := importCommand.Flag("calendar-map", "A CSV file with rules that assign the workspace, project, client and tags of imported calendar events").String()
+	importFrom := importCommand.Flag("from", "Only import calendar events that start on or after the given day (e.g. \"2016-08-01\", \"2016-W32\" or \"last-month\")").String()
+	importUntil := importCommand.Flag("until", "Only import calendar events that end on or before the given day (default: now)").String()
+	importDryRun := importCommand.Flag("dry-run", "Print the clients, projects and time entries that would be created without changing the Toggl account").Bool()
+	importJournal := importCommand.Flag("journal", "Record every created time entry in the given file so that an aborted import can be resumed").String()
+	importResume := importCommand.Flag("resume", "Continue the import recorded in the journal file").Bool()
+	importWorkers := importCommand.Flag("workers", "The number of time entries that are created in parallel").Default("1").Int()
+	importRequestsPerSecond := importCommand.Flag("requests-per-second", "The maximum number of requests per second that are sent to the Toggl API").Default("1").Float64()
+	importStream := importCommand.Flag("stream", "Create the time entries while the CSV input is read instead of validating the whole input first").Bool()
+	importMap := importCommand.Flag("map", "A CSV file with rules for renaming workspaces, clients, projects and tags (YAML map files are not supported)").String()
+	importRounding := importCommand.Flag("rounding", "A CSV file with rules for rounding the time records of clients and projects").String()
+	importSplitAt := importCommand.Flag("split-at", "Split time records at the boundaries of the given period (day, week or month) in the --timezone").Enum(splitPeriods...)
+	importOverlaps := importCommand.Flag("overlaps", "How time records of the same workspace that overlap each other are handled (error, warn, skip-later or trim; default: warn)").Enum(overlapPolicies...)
+	importTimezone := importCommand.Flag("timezone", "The time zone (e.g. \"Europe/Berlin\") of dates without an offset").String()
+	importAtomic := importCommand.Flag("atomic", "Delete all time entries, projects and clients created by the import if any time record fails").Bool()
+
+	// validate
+	validateCommand := app.Command("validate", "Check CSV-based time tracking records from stdin without importing them")
+	validateFormat := validateCommand.Flag("format", "The output format of the problems (table or json)").Default("table").Enum("table", validationFormatJSON)
+	validateTimezone := validateCommand.Flag("timezone", "The time zone (e.g. \"Europe/Berlin\") of dates without an offset").String()
+
+	// merge
+	mergeCommand := app.Command("merge", "Merge consecutive CSV-based time tracking records from stdin with identical attributes and print them as CSV")
+	mergeGap := mergeCommand.Flag("merge-gap", "The largest gap between two time records that are merged (e.g. \"30s\" or \"2m\")").Default(defaultMaxMergeGap).Duration()
+	mergeTimezone := mergeCommand.Flag("timezone", "The time zone (e.g. \"Europe/Berlin\") of dates without an offset").String()
+
+	// report
+	reportCommand := app.Command("report", "Print the total hours of your Toggl time tracking records grouped by workspace, client, project, tag or date")
+	reportAPIToken := reportCommand.Arg("token", "The Toggl API token of the source account").Required().String()
+	reportStartDate := reportCommand.Arg("startdate", "The start date (e.g. \"2006-01-26\"), an ISO week (e.g. \"2016-W32\") or a named range (e.g. \"last-month\" or \"last-7d\")").Required().String()
+	reportEndDate := reportCommand.Arg("enddate", "The end date (e.g. \"2006-01-26\"), an ISO week or a named range (default: today or the end of the named start range)").String()
+	reportGroupBy := reportCommand.Flag("group-by", "Group by workspace, client, project, tag, day, week or month (comma-separated or repeatable)").Default(reportGroupProject).Strings()
+	reportTagMode := reportCommand.Flag("tag-mode", "How time records with several tags are counted when grouping by tag (split, full or combination)").Default(tagModeSplit).Enum(tagModes...)
+	reportFormat := reportCommand.Flag("format", "The output format (table, csv or json)").Default(reportFormatTable).Enum(reportFormats...)
+	reportTimezone := reportCommand.Flag("timezone", "The time zone (e.g. \"Europe/Berlin\") of the start and end date and of the days, weeks and months").String()
+	reportFilterFlags := addFilterFlags(reportCommand, "report")
 
 	command, err := app.Parse(args)
 	if err != nil {
@@ -53,26 +105,47 @@ func (cli *togglCli) Execute(input io.Reader, output, errorOutput io.Writer, arg
 	// export
 	case exportCommand.FullCommand():
 
-		// start date (required)
-		startDate, startDateError := time.Parse(exportDateFormat, *exportStartDate)
-		if startDateError != nil {
-			app.Fatalf("Failed to parse the given start date %q. %s", *exportStartDate, startDateError.Error())
+		location, timezoneError := loadTimezone(*exportTimezone)
+		if timezoneError != nil {
+			app.Fatalf("%s", timezoneError.Error())
+			return false
 		}
 
-		// end date (optional)
-		now := time.Now()
-		endDate := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC) // use current date as the default
+		startDate, endDate, dateRangeError := getDateRange(*exportStartDate, *exportEndDate, location)
+		if dateRangeError != nil {
+			app.Fatalf("%s", dateRangeError.Error())
+			return false
+		}
 
-		if len(*exportEndDate) > 0 {
-			endDateParsed, endDateError := time.Parse(exportDateFormat, *exportEndDate)
-			if endDateError != nil {
-				app.Fatalf("Failed to parse the given end date %q. %s", *exportEndDate, endDateError.Error())
+		var transformers []TimeRecordTransformer
+		if *exportRounding != "" {
+			rounder, roundingError := loadTimeRecordRounder(*exportRounding)
+			if roundingError != nil {
+				app.Fatalf("%s", roundingError.Error())
+				return false
 			}
 
-			endDate = endDateParsed
+			transformers = append(transformers, rounder)
+		}
+
+		filter, filterError := exportFilterFlags.compile()
+		if filterError != nil {
+			app.Fatalf("%s", filterError.Error())
+			return false
 		}
 
-		exporter := cli.exporterFactory(*exportAPIToken)
+		exporter := cli.exporterFactory(*exportAPIToken, ExportOptions{
+			Format:         *exportFormat,
+			IncludeRunning: *exportIncludeRunning,
+			DurationFormat: *exportDurationFormat,
+			Billable:       *exportBillable,
+			Location:       location,
+			Transformers:   transformers,
+			SplitAt:        *exportSplitAt,
+			Merge:          *exportMerge,
+			MaxMergeGap:    *exportMergeGap,
+			Filter:         filter,
+		})
 		if exportError := exporter.Export(startDate, endDate, output); exportError != nil {
 			fmt.Fprintf(errorOutput, "Error: %s\n", exportError.Error())
 			return false
@@ -82,15 +155,349 @@ func (cli *togglCli) Execute(input io.Reader, output, errorOutput io.Writer, arg
 
 	// import
 	case importCommand.FullCommand():
-		importer := cli.importerFactory(*importAPIToken)
-		if importError := importer.Import(input); importError != nil {
+
+		if *importResume && *importJournal == "" {
+			app.Fatalf("The --resume flag requires a --journal file")
+			return false
+		}
+
+		if *importAtomic && *importJournal != "" {
+			app.Fatalf("The --atomic flag cannot be combined with --journal")
+			return false
+		}
+
+		if *importStream && *importDryRun {
+			app.Fatalf("The --stream flag cannot be combined with --dry-run")
+			return false
+		}
+
+		// a streaming import doesn't keep the previous time records that overlaps are detected against
+		if *importStream && *importOverlaps != "" {
+			app.Fatalf("The --stream flag cannot be combined with --overlaps because streaming imports don't detect overlaps")
+			return false
+		}
+
+		overlapPolicy := *importOverlaps
+		if overlapPolicy == "" {
+			overlapPolicy = overlapPolicyWarn
+		}
+
+		if *importStream && (*importFormat == formatXLSX || *importFormat == formatICS) {
+			app.Fatalf("The --stream flag cannot be combined with --format %s", *importFormat)
+			return false
+		}
+
+		if *importFormat == formatICS && *importCalendarMap == "" {
+			app.Fatalf("The --format ics flag requires a --calendar-map file")
+			return false
+		}
+
+		if (*importCalendarMap != "" || *importFrom != "" || *importUntil != "") && *importFormat != formatICS {
+			app.Fatalf("The --calendar-map, --from and --until flags require --format ics")
+			return false
+		}
+
+		if *importSheet != "" && *importFormat != formatXLSX {
+			app.Fatalf("The --sheet flag requires --format xlsx")
+			return false
+		}
+
+		if *importWorkers < 1 {
+			app.Fatalf("The number of workers must be at least 1")
+			return false
+		}
+
+		files, filesError := expandImportFiles(*importFilePatterns)
+		if filesError != nil {
+			app.Fatalf("%s", filesError.Error())
+			return false
+		}
+
+		if len(files) == 0 {
+			files = []string{stdinFileName}
+		}
+
+		if len(files) > 1 && *importJournal != "" {
+			app.Fatalf("The --journal flag can only be used with a single input file")
+			return false
+		}
+
+		// every file is imported on its own, so a rollback could not undo the files imported before
+		if len(files) > 1 && *importAtomic {
+			app.Fatalf("The --atomic flag can only be used with a single input file")
+			return false
+		}
+
+		location, timezoneError := loadTimezone(*importTimezone)
+		if timezoneError != nil {
+			app.Fatalf("%s", timezoneError.Error())
+			return false
+		}
+
+		var transformers []TimeRecordTransformer
+		if *importMap != "" {
+			remapper, remapError := loadTimeRecordRemapper(*importMap)
+			if remapError != nil {
+				app.Fatalf("%s", remapError.Error())
+				return false
+			}
+
+			transformers = append(transformers, remapper)
+		}
+
+		// round after renaming so that the rules refer to the names in the target account
+		if *importRounding != "" {
+			rounder, roundingError := loadTimeRecordRounder(*importRounding)
+			if roundingError != nil {
+				app.Fatalf("%s", roundingError.Error())
+				return false
+			}
+
+			transformers = append(transformers, rounder)
+		}
+
+		var calendarMapper *CalendarMapper
+		var calendarFrom, calendarUntil time.Time
+		if *importFormat == formatICS {
+			mapper, calendarMapError := loadCalendarMapper(*importCalendarMap)
+			if calendarMapError != nil {
+				app.Fatalf("%s", calendarMapError.Error())
+				return false
+			}
+
+			calendarMapper = mapper
+
+			rangeLocation := time.UTC
+			if location != nil {
+				rangeLocation = location
+			}
+
+			now := time.Now().In(rangeLocation)
+
+			// import past events only unless an end date is given
+			calendarUntil = now
+
+			if *importFrom != "" {
+				fromRange, fromError := parseDateRange(*importFrom, now)
+				if fromError != nil {
+					app.Fatalf("Failed to parse the given --from date %q. %s", *importFrom, fromError.Error())
+					return false
+				}
+
+				calendarFrom = fromRange.start
+			}
+
+			if *importUntil != "" {
+				untilRange, untilError := parseDateRange(*importUntil, now)
+				if untilError != nil {
+					app.Fatalf("Failed to parse the given --until date %q. %s", *importUntil, untilError.Error())
+					return false
+				}
+
+				// include the whole last day
+				calendarUntil = untilRange.end.AddDate(0, 0, 1)
+			}
+		}
+
+		importOptions := ImportOptions{
+			DryRun:      *importDryRun,
+			JournalPath: *importJournal,
+			Resume:      *importResume,
+
+			Workers:           *importWorkers,
+			RequestsPerSecond: *importRequestsPerSecond,
+			Stream:            *importStream,
+			Atomic:            *importAtomic,
+			OverlapPolicy:     overlapPolicy,
+			Transformers:      transformers,
+			Location:          location,
+			SplitAt:           *importSplitAt,
+			Format:            *importFormat,
+			Sheet:             *importSheet,
+			CalendarMapper:    calendarMapper,
+			CalendarFrom:      calendarFrom,
+			CalendarUntil:     calendarUntil,
+		}
+
+		// use a new importer for every file so that every file is imported on its own
+		newImporter := func() CSVImporter {
+			return cli.importerFactory(*importAPIToken, importOptions)
+		}
+
+		if len(files) > 1 {
+			return importFiles(newImporter, files, input, output, errorOutput)
+		}
+
+		if importError := importFile(newImporter(), files[0], input); importError != nil {
 			fmt.Fprintf(errorOutput, "Error: %s\n", importError.Error())
 			return false
 		}
 
 		return true
 
+	// validate
+	case validateCommand.FullCommand():
+
+		location, timezoneError := loadTimezone(*validateTimezone)
+		if timezoneError != nil {
+			app.Fatalf("%s", timezoneError.Error())
+			return false
+		}
+
+		validator := cli.validatorFactory(*validateFormat, location)
+		if validationError := validator.Validate(input, output); validationError != nil {
+			fmt.Fprintf(errorOutput, "Error: %s\n", validationError.Error())
+			return false
+		}
+
+		return true
+
+	// merge
+	case mergeCommand.FullCommand():
+
+		location, timezoneError := loadTimezone(*mergeTimezone)
+		if timezoneError != nil {
+			app.Fatalf("%s", timezoneError.Error())
+			return false
+		}
+
+		merger := cli.mergerFactory(*mergeGap, location)
+		if mergeError := merger.Merge(input, output, errorOutput); mergeError != nil {
+			fmt.Fprintf(errorOutput, "Error: %s\n", mergeError.Error())
+			return false
+		}
+
+		return true
+
+	// report
+	case reportCommand.FullCommand():
+
+		groupBy, groupByError := parseReportGroups(*reportGroupBy)
+		if groupByError != nil {
+			app.Fatalf("%s", groupByError.Error())
+			return false
+		}
+
+		location, timezoneError := loadTimezone(*reportTimezone)
+		if timezoneError != nil {
+			app.Fatalf("%s", timezoneError.Error())
+			return false
+		}
+
+		startDate, endDate, dateRangeError := getDateRange(*reportStartDate, *reportEndDate, location)
+		if dateRangeError != nil {
+			app.Fatalf("%s", dateRangeError.Error())
+			return false
+		}
+
+		filter, filterError := reportFilterFlags.compile()
+		if filterError != nil {
+			app.Fatalf("%s", filterError.Error())
+			return false
+		}
+
+		reporter := cli.reporterFactory(*reportAPIToken, ReportOptions{
+			GroupBy:  groupBy,
+			TagMode:  *reportTagMode,
+			Format:   *reportFormat,
+			Location: location,
+			Filter:   filter,
+		})
+		if reportError := reporter.Report(startDate, endDate, output); reportError != nil {
+			fmt.Fprintf(errorOutput, "Error: %s\n", reportError.Error())
+			return false
+		}
+
+		return true
+
 	}
 
 	return false
 }
+
+// getDateRange returns the first and the last date of the given start and end date expressions in the given time zone
+// (default: UTC). Without an end date the range ends with the named start range or today.
+func getDateRange(startExpression, endExpression string, location *time.Location) (time.Time, time.Time, error) {
+	rangeLocation := time.UTC
+	if location != nil {
+		rangeLocation = location
+	}
+
+	now := time.Now().In(rangeLocation)
+
+	// start date (required)
+	startRange, startDateError := parseDateRange(startExpression, now)
+	if startDateError != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Failed to parse the given start date %q. %s", startExpression, startDateError.Error())
+	}
+
+	// end date (optional)
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, rangeLocation) // use current date as the default
+	if startRange.named {
+		// use the end of the named range
+		endDate = startRange.end
+	}
+
+	if len(endExpression) > 0 {
+		endRange, endDateError := parseDateRange(endExpression, now)
+		if endDateError != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Failed to parse the given end date %q. %s", endExpression, endDateError.Error())
+		}
+
+		endDate = endRange.end
+	}
+
+	return startRange.start, endDate, nil
+}
+
+// filterFlags contains the values of the flags that select the time records of the export and report commands.
+type filterFlags struct {
+	workspaces          *[]string
+	excludeWorkspaces   *[]string
+	clients             *[]string
+	excludeClients      *[]string
+	projects            *[]string
+	excludeProjects     *[]string
+	tags                *[]string
+	excludeTags         *[]string
+	descriptions        *[]string
+	excludeDescriptions *[]string
+}
+
+// addFilterFlags adds the filter flags to the given command. The verb describes what the command does with the time records.
+func addFilterFlags(command *kingpin.CmdClause, verb string) *filterFlags {
+	return &filterFlags{
+		workspaces:          command.Flag("workspace", fmt.Sprintf("Only %s the time records of workspaces matching the given glob or /regex/ (repeatable)", verb)).Strings(),
+		excludeWorkspaces:   command.Flag("exclude-workspace", fmt.Sprintf("Don't %s the time records of workspaces matching the given glob or /regex/ (repeatable)", verb)).Strings(),
+		clients:             command.Flag("client", fmt.Sprintf("Only %s the time records of clients matching the given glob or /regex/ (repeatable)", verb)).Strings(),
+		excludeClients:      command.Flag("exclude-client", fmt.Sprintf("Don't %s the time records of clients matching the given glob or /regex/ (repeatable)", verb)).Strings(),
+		projects:            command.Flag("project", fmt.Sprintf("Only %s the time records of projects matching the given glob or /regex/ (repeatable)", verb)).Strings(),
+		excludeProjects:     command.Flag("exclude-project", fmt.Sprintf("Don't %s the time records of projects matching the given glob or /regex/ (repeatable)", verb)).Strings(),
+		tags:                command.Flag("tag", fmt.Sprintf("Only %s time records with a tag matching the given glob or /regex/ (repeatable)", verb)).Strings(),
+		excludeTags:         command.Flag("exclude-tag", fmt.Sprintf("Don't %s time records with a tag matching the given glob or /regex/ (repeatable)", verb)).Strings(),
+		descriptions:        command.Flag("description-match", fmt.Sprintf("Only %s time records whose description matches the given regular expression (repeatable)", verb)).Strings(),
+		excludeDescriptions: command.Flag("exclude-description", fmt.Sprintf("Don't %s time records whose description matches the given regular expression (repeatable)", verb)).Strings(),
+	}
+}
+
+// compile returns the filter of the given flags or nil if no filter flag is given.
+func (flags *filterFlags) compile() (*ExportFilter, error) {
+	patterns := ExportFilterPatterns{
+		Workspaces:          *flags.workspaces,
+		ExcludeWorkspaces:   *flags.excludeWorkspaces,
+		Clients:             *flags.clients,
+		ExcludeClients:      *flags.excludeClients,
+		Projects:            *flags.projects,
+		ExcludeProjects:     *flags.excludeProjects,
+		Tags:                *flags.tags,
+		ExcludeTags:         *flags.excludeTags,
+		Descriptions:        *flags.descriptions,
+		ExcludeDescriptions: *flags.excludeDescriptions,
+	}
+
+	if !patterns.isSet() {
+		return nil, nil
+	}
+
+	return compileExportFilter(patterns)
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
 )
 
 func Test_togglCli_Execute_ExportActionIsGiven_NoTokenArgumemtGiven_ErrorIsPrinted(t *testing.T) {
@@ -26,7 +28,7 @@ func Test_togglCli_Execute_ExportActionIsGiven_NoTokenArgumemtGiven_ErrorIsPrint
 	}
 
 	cli := togglCli{
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(nil)
 		},
 	}
@@ -61,7 +63,7 @@ func Test_togglCli_Execute_ExportActionIsGiven_TokenArgumemtGiven_NoStartDatePro
 	}
 
 	cli := togglCli{
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(nil)
 		},
 	}
@@ -97,7 +99,7 @@ func Test_togglCli_Execute_ExportActionIsGiven_TokenArgumemtGiven_StartDateArgum
 	}
 
 	cli := togglCli{
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(nil)
 		},
 	}
@@ -134,7 +136,7 @@ func Test_togglCli_Execute_ExportActionIsGiven_TokenArgumemtGiven_StartDateValid
 	}
 
 	cli := togglCli{
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(nil)
 		},
 	}
@@ -171,7 +173,7 @@ func Test_togglCli_Execute_ExportActionIsGiven_TokenArgumemtGiven_StartDateValid
 	}
 
 	cli := togglCli{
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(nil)
 		},
 	}
@@ -222,7 +224,7 @@ func Test_togglCli_Execute_ExportActionIsGiven_TokenArgumemtGiven_StartDateValid
 	}
 
 	cli := togglCli{
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return mockCSVExporter
 		},
 	}
@@ -249,7 +251,7 @@ func Test_togglCli_Execute_ExportActionIsGiven_TokenArgumemtGiven_StartDateValid
 	}
 
 	cli := togglCli{
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(nil)
 		},
 	}
@@ -285,7 +287,7 @@ func Test_togglCli_Execute_ExportActionIsGiven_TokenArgumemtGiven_StartDateArgum
 	}
 
 	cli := togglCli{
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(fmt.Errorf("Export failed"))
 		},
 	}
@@ -302,3 +304,325 @@ func Test_togglCli_Execute_ExportActionIsGiven_TokenArgumemtGiven_StartDateArgum
 		t.Logf("togglCli_Execute should print an error if the CSV exporter returns one: %s", errorBuffer.String())
 	}
 }
+
+func Test_togglCli_Execute_ExportActionIsGiven_IncludeRunningFlagGiven_OptionIsPassedToExporter(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"export",
+		"--include-running",
+		"123456",
+		"2016-08-01",
+	}
+
+	var exportOptions ExportOptions
+	cli := togglCli{
+		exporterFactory: func(apiToken string, options ExportOptions) CSVExporter {
+			exportOptions = options
+			return getMockCSVExporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if !exportOptions.IncludeRunning {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the --include-running flag to the exporter (%s)", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ExportActionIsGiven_TimezoneFlagGiven_DatesAreInterpretedInTimezone(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"export",
+		"--timezone",
+		"Europe/Berlin",
+		"123456",
+		"2016-03-01",
+		"2016-03-31",
+	}
+
+	var exportStartDate time.Time
+	var exportOptions ExportOptions
+	cli := togglCli{
+		exporterFactory: func(apiToken string, options ExportOptions) CSVExporter {
+			exportOptions = options
+			return &MockCSVExporter{
+				exportFunc: func(startDate, endDate time.Time, writer io.Writer) error {
+					exportStartDate = startDate
+					return nil
+				},
+			}
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	expectedStartDate := time.Date(2016, 2, 29, 23, 0, 0, 0, time.UTC)
+	if !exportStartDate.Equal(expectedStartDate) {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the start date %s but passed %s (%s)", expectedStartDate, exportStartDate, errorBuffer.String())
+	}
+
+	if exportOptions.Location == nil || exportOptions.Location.String() != "Europe/Berlin" {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the time zone to the exporter but passed %v", exportOptions.Location)
+	}
+}
+
+func Test_togglCli_Execute_ExportActionIsGiven_UnknownTimezone_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"export",
+		"--timezone",
+		"Europe/Atlantis",
+		"123456",
+		"2016-03-01",
+	}
+
+	cli := togglCli{
+		exporterFactory: func(string, ExportOptions) CSVExporter {
+			return getMockCSVExporter(nil)
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if success || !strings.Contains(errorBuffer.String(), "Unknown time zone") {
+		t.Fail()
+		t.Logf("togglCli_Execute should have reported the unknown time zone but printed: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ExportActionIsGiven_FilterFlagsGiven_FilterIsPassedToExporter(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"export",
+		"--project",
+		"Website",
+		"--project",
+		"App*",
+		"--exclude-tag",
+		"private",
+		"123456",
+		"2016-08-01",
+	}
+
+	var exportOptions ExportOptions
+	cli := togglCli{
+		exporterFactory: func(apiToken string, options ExportOptions) CSVExporter {
+			exportOptions = options
+			return getMockCSVExporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if exportOptions.Filter == nil {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed a filter to the exporter (%s)", errorBuffer.String())
+		return
+	}
+
+	if !exportOptions.Filter.IncludesProject("App Relaunch") || exportOptions.Filter.IncludesProject("Marketing") {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed all --project patterns to the exporter")
+	}
+
+	if exportOptions.Filter.Includes(toggl.TimeRecord{ProjectName: "Website", Tags: []string{"private"}}) {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the --exclude-tag pattern to the exporter")
+	}
+}
+
+func Test_togglCli_Execute_ExportActionIsGiven_NoFilterFlagsGiven_NoFilterIsPassedToExporter(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"export",
+		"123456",
+		"2016-08-01",
+	}
+
+	exportOptions := ExportOptions{Filter: &ExportFilter{}}
+	cli := togglCli{
+		exporterFactory: func(apiToken string, options ExportOptions) CSVExporter {
+			exportOptions = options
+			return getMockCSVExporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if exportOptions.Filter != nil {
+		t.Fail()
+		t.Logf("togglCli_Execute should not have passed a filter to the exporter")
+	}
+}
+
+func Test_togglCli_Execute_ExportActionIsGiven_InvalidDescriptionPattern_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"export",
+		"--description-match",
+		"(unclosed",
+		"123456",
+		"2016-08-01",
+	}
+
+	cli := togglCli{
+		exporterFactory: func(string, ExportOptions) CSVExporter {
+			return getMockCSVExporter(nil)
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if success || !strings.Contains(errorBuffer.String(), "Invalid pattern") {
+		t.Fail()
+		t.Logf("togglCli_Execute should have reported the invalid pattern but printed: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ExportActionIsGiven_NamedRangeGiven_StartAndEndOfRangeArePassed(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"export",
+		"123456",
+		"2016-W32",
+	}
+
+	var exportStartDate time.Time
+	var exportEndDate time.Time
+	cli := togglCli{
+		exporterFactory: func(apiToken string, options ExportOptions) CSVExporter {
+			return &MockCSVExporter{
+				exportFunc: func(startDate, endDate time.Time, writer io.Writer) error {
+					exportStartDate = startDate
+					exportEndDate = endDate
+					return nil
+				},
+			}
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	expectedStartDate := time.Date(2016, 8, 8, 0, 0, 0, 0, time.UTC)
+	expectedEndDate := time.Date(2016, 8, 14, 0, 0, 0, 0, time.UTC)
+	if !exportStartDate.Equal(expectedStartDate) || !exportEndDate.Equal(expectedEndDate) {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed %s - %s but passed %s - %s (%s)", expectedStartDate, expectedEndDate, exportStartDate, exportEndDate, errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ExportActionIsGiven_UnknownNamedRange_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"export",
+		"123456",
+		"next-month",
+	}
+
+	exported := false
+	cli := togglCli{
+		exporterFactory: func(string, ExportOptions) CSVExporter {
+			exported = true
+			return getMockCSVExporter(nil)
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if success || exported || !strings.Contains(errorBuffer.String(), "Unknown date") {
+		t.Fail()
+		t.Logf("togglCli_Execute should have reported the unknown date range but printed: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ExportActionIsGiven_FormatFlagGiven_FormatIsPassedToExporter(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"export",
+		"--format",
+		"ndjson",
+		"123456",
+		"2016-08-01",
+	}
+
+	var exportOptions ExportOptions
+	cli := togglCli{
+		exporterFactory: func(apiToken string, options ExportOptions) CSVExporter {
+			exportOptions = options
+			return getMockCSVExporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if exportOptions.Format != formatNDJSON {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the format %q to the exporter but passed %q (%s)", formatNDJSON, exportOptions.Format, errorBuffer.String())
+	}
+}
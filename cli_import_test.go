@@ -24,7 +24,7 @@ func Test_togglCli_Execute_ImportActionIsGiven_NoTokenArgumemtGiven_ErrorIsPrint
 	}
 
 	cli := togglCli{
-		importerFactory: func(string) CSVImporter {
+		importerFactory: func(string, ImportOptions) CSVImporter {
 			return getMockCSVImporter(nil)
 		},
 	}
@@ -59,7 +59,7 @@ func Test_togglCli_Execute_ImportActionIsGiven_TokenArgumemtGiven_InvalidCSVInpu
 	}
 
 	cli := togglCli{
-		importerFactory: func(string) CSVImporter {
+		importerFactory: func(string, ImportOptions) CSVImporter {
 			return getMockCSVImporter(fmt.Errorf("invalid csv"))
 		},
 	}
@@ -94,7 +94,7 @@ func Test_togglCli_Execute_ImportActionIsGiven_TokenArgumemtGiven_NoInputGiven_N
 	}
 
 	cli := togglCli{
-		importerFactory: func(string) CSVImporter {
+		importerFactory: func(string, ImportOptions) CSVImporter {
 			return getMockCSVImporter(nil)
 		},
 	}
@@ -131,7 +131,7 @@ func Test_togglCli_Execute_ImportActionIsGiven_TokenArgumemtGiven_ValidCSV_Impor
 	}
 
 	cli := togglCli{
-		importerFactory: func(string) CSVImporter {
+		importerFactory: func(string, ImportOptions) CSVImporter {
 			return getMockCSVImporter(nil)
 		},
 	}
@@ -166,7 +166,7 @@ func Test_togglCli_Execute_ImportActionIsGiven_TokenArgumemtGiven_ImportFails_Er
 	}
 
 	cli := togglCli{
-		importerFactory: func(string) CSVImporter {
+		importerFactory: func(string, ImportOptions) CSVImporter {
 			return getMockCSVImporter(fmt.Errorf("Import failed"))
 		},
 	}
@@ -183,3 +183,520 @@ func Test_togglCli_Execute_ImportActionIsGiven_TokenArgumemtGiven_ImportFails_Er
 		t.Logf("togglCli_Execute should print an error if the CSV importer returns one: %s", errorBuffer.String())
 	}
 }
+
+func Test_togglCli_Execute_ImportActionIsGiven_DryRunFlagGiven_DryRunOptionIsPassedToImporter(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"--dry-run",
+	}
+
+	var importOptions ImportOptions
+	cli := togglCli{
+		importerFactory: func(apiToken string, options ImportOptions) CSVImporter {
+			importOptions = options
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	if !importOptions.DryRun {
+		t.Fail()
+		t.Logf("togglCli_Execute should have enabled the dry-run option of the importer")
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_ResumeWithoutJournal_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"--resume",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			t.Fail()
+			t.Logf("togglCli_Execute should not start an import if --resume is given without a journal")
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	outputWriter.Flush()
+	errorWriter.Flush()
+
+	if !strings.Contains(errorBuffer.String(), "requires a --journal file") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if --resume is given without a journal but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_AtomicWithJournal_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"--atomic",
+		"--journal",
+		"import.journal",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			t.Fail()
+			t.Logf("togglCli_Execute should not start an import if --atomic is combined with --journal")
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	outputWriter.Flush()
+	errorWriter.Flush()
+
+	if !strings.Contains(errorBuffer.String(), "cannot be combined with --journal") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if --atomic is combined with --journal but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_StreamWithDryRun_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"--stream",
+		"--dry-run",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			t.Fail()
+			t.Logf("togglCli_Execute should not start an import if --stream is combined with --dry-run")
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	outputWriter.Flush()
+	errorWriter.Flush()
+
+	if !strings.Contains(errorBuffer.String(), "cannot be combined with --dry-run") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if --stream is combined with --dry-run but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_MapFileDoesNotExist_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"--map",
+		"does-not-exist.csv",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			t.Fail()
+			t.Logf("togglCli_Execute should not start an import if the map file cannot be read")
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	outputWriter.Flush()
+	errorWriter.Flush()
+
+	if !strings.Contains(errorBuffer.String(), "Failed to open the map file") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if the map file cannot be read but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_MultipleFilesWithJournal_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"2016-01.csv",
+		"2016-02.csv",
+		"--journal",
+		"import.journal",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			t.Fail()
+			t.Logf("togglCli_Execute should not start an import if --journal is used with multiple files")
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	outputWriter.Flush()
+	errorWriter.Flush()
+
+	if !strings.Contains(errorBuffer.String(), "single input file") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if --journal is used with multiple files but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_MultipleFilesWithAtomic_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"import",
+		"--atomic",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"2016-01.csv",
+		"2016-02.csv",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			t.Fail()
+			t.Logf("togglCli_Execute should not start an import if --atomic is used with multiple files")
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if !strings.Contains(errorBuffer.String(), "The --atomic flag can only be used with a single input file") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if --atomic is used with multiple files but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_FileDoesNotExist_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"does-not-exist.csv",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	outputWriter.Flush()
+	errorWriter.Flush()
+
+	if success || !strings.Contains(errorBuffer.String(), "does-not-exist.csv") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if the given file does not exist but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_FormatFlagGiven_FormatIsPassedToImporter(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"--format",
+		"json",
+	}
+
+	var importOptions ImportOptions
+	cli := togglCli{
+		importerFactory: func(apiToken string, options ImportOptions) CSVImporter {
+			importOptions = options
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	if importOptions.Format != formatJSON {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the format %q to the importer but passed %q", formatJSON, importOptions.Format)
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_StreamWithFormatXLSX_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"--stream",
+		"--format",
+		"xlsx",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			t.Fail()
+			t.Logf("togglCli_Execute should not start an import if --stream is combined with --format xlsx")
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	outputWriter.Flush()
+	errorWriter.Flush()
+
+	if !strings.Contains(errorBuffer.String(), "cannot be combined with --format xlsx") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if --stream is combined with --format xlsx but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_SheetFlagGiven_SheetIsPassedToImporter(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"--format",
+		"xlsx",
+		"--sheet",
+		"Records",
+	}
+
+	var importOptions ImportOptions
+	cli := togglCli{
+		importerFactory: func(apiToken string, options ImportOptions) CSVImporter {
+			importOptions = options
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	if importOptions.Sheet != "Records" {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the sheet %q to the importer but passed %q", "Records", importOptions.Sheet)
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_FormatICSWithoutCalendarMap_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+		"--format",
+		"ics",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			t.Fail()
+			t.Logf("togglCli_Execute should not start an import of an ics input without a calendar map")
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	outputWriter.Flush()
+	errorWriter.Flush()
+
+	if !strings.Contains(errorBuffer.String(), "requires a --calendar-map file") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if --format ics is used without --calendar-map but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_StreamWithOverlaps_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"import",
+		"--stream",
+		"--overlaps",
+		"skip-later",
+		"1971800d4d82861d8f2c1651fea4d212",
+	}
+
+	cli := togglCli{
+		importerFactory: func(string, ImportOptions) CSVImporter {
+			t.Fail()
+			t.Logf("togglCli_Execute should not start an import if --stream is used with --overlaps")
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if !strings.Contains(errorBuffer.String(), "The --stream flag cannot be combined with --overlaps") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error if --stream is used with --overlaps but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ImportActionIsGiven_NoOverlapsFlag_WarnPolicyIsPassed(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"import",
+		"1971800d4d82861d8f2c1651fea4d212",
+	}
+
+	var importOptions ImportOptions
+	cli := togglCli{
+		importerFactory: func(apiToken string, options ImportOptions) CSVImporter {
+			importOptions = options
+			return getMockCSVImporter(nil)
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if importOptions.OverlapPolicy != overlapPolicyWarn {
+		t.Fail()
+		t.Logf("togglCli_Execute should pass the warn policy by default but passed %q (%s)", importOptions.OverlapPolicy, errorBuffer.String())
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockCSVMerger struct {
+	mergeFunc func(input io.Reader, writer, messageOutput io.Writer) error
+}
+
+func (merger *MockCSVMerger) Merge(input io.Reader, writer, messageOutput io.Writer) error {
+	return merger.mergeFunc(input, writer, messageOutput)
+}
+
+func Test_togglCli_Execute_MergeActionIsGiven_MergeGapGiven_GapIsPassedToMerger(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"merge",
+		"--merge-gap",
+		"2m",
+	}
+
+	var mergerGap time.Duration
+	cli := togglCli{
+		mergerFactory: func(maxGap time.Duration, location *time.Location) CSVMerger {
+			mergerGap = maxGap
+			return &MockCSVMerger{
+				mergeFunc: func(input io.Reader, writer, messageOutput io.Writer) error {
+					return nil
+				},
+			}
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if !success || mergerGap != 2*time.Minute {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the gap of 2m to the merger but passed %s (%s)", mergerGap, errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_MergeActionIsGiven_MergerReturnsError_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"merge",
+	}
+
+	cli := togglCli{
+		mergerFactory: func(time.Duration, *time.Location) CSVMerger {
+			return &MockCSVMerger{
+				mergeFunc: func(input io.Reader, writer, messageOutput io.Writer) error {
+					return io.ErrUnexpectedEOF
+				},
+			}
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if success || !strings.Contains(errorBuffer.String(), "Error: unexpected EOF") {
+		t.Fail()
+		t.Logf("togglCli_Execute should have printed the error of the merger but printed: %s", errorBuffer.String())
+	}
+}
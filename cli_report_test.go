@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockReporter struct {
+	report func(startDate, endDate time.Time, writer io.Writer) error
+}
+
+func (reporter *mockReporter) Report(startDate, endDate time.Time, writer io.Writer) error {
+	return reporter.report(startDate, endDate, writer)
+}
+
+func Test_togglCli_Execute_ReportActionIsGiven_FlagsGiven_OptionsArePassedToReporter(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"report",
+		"--group-by",
+		"client,project",
+		"--group-by",
+		"week",
+		"--tag-mode",
+		"full",
+		"--format",
+		"csv",
+		"--project",
+		"Website",
+		"123456",
+		"2016-W32",
+	}
+
+	var reportOptions ReportOptions
+	var reportStart, reportEnd time.Time
+	cli := togglCli{
+		reporterFactory: func(apiToken string, options ReportOptions) Reporter {
+			reportOptions = options
+			return &mockReporter{
+				report: func(startDate, endDate time.Time, writer io.Writer) error {
+					reportStart, reportEnd = startDate, endDate
+					return nil
+				},
+			}
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if !success {
+		t.Fail()
+		t.Logf("togglCli_Execute should succeed but printed: %s", errorBuffer.String())
+		return
+	}
+
+	if strings.Join(reportOptions.GroupBy, ",") != "client,project,week" || reportOptions.TagMode != tagModeFull || reportOptions.Format != formatCSV {
+		t.Fail()
+		t.Logf("togglCli_Execute passed the wrong options to the reporter: %#v", reportOptions)
+	}
+
+	if reportOptions.Filter == nil || reportOptions.Filter.IncludesProject("App") {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the --project filter to the reporter")
+	}
+
+	if reportStart.Format(exportDateFormat) != "2016-08-08" || reportEnd.Format(exportDateFormat) != "2016-08-14" {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the dates of the week 2016-W32 but passed %s and %s", reportStart, reportEnd)
+	}
+}
+
+func Test_togglCli_Execute_ReportActionIsGiven_UnknownGroup_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"report",
+		"--group-by",
+		"year",
+		"123456",
+		"2016-08-01",
+	}
+
+	reporterCreated := false
+	cli := togglCli{
+		reporterFactory: func(apiToken string, options ReportOptions) Reporter {
+			reporterCreated = true
+			return nil
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if success || reporterCreated || !strings.Contains(errorBuffer.String(), `Unknown group "year"`) {
+		t.Fail()
+		t.Logf("togglCli_Execute should print an error for an unknown group but printed: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ReportActionIsGiven_ReportFails_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputReader := strings.NewReader(``)
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	arguments := []string{
+		"report",
+		"123456",
+		"2016-08-01",
+	}
+
+	cli := togglCli{
+		reporterFactory: func(apiToken string, options ReportOptions) Reporter {
+			return &mockReporter{
+				report: func(startDate, endDate time.Time, writer io.Writer) error {
+					return fmt.Errorf("Report error")
+				},
+			}
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, &outputBuffer, &errorBuffer, arguments)
+
+	// assert
+	if success || !strings.Contains(errorBuffer.String(), "Report error") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print the error of the reporter but printed: %s", errorBuffer.String())
+	}
+}
@@ -53,10 +53,10 @@ func Test_togglCli_Execute_NoArguments_HelpIsPrinted(t *testing.T) {
 	errorWriter := bufio.NewWriter(&errorBuffer)
 
 	cli := togglCli{
-		importerFactory: func(string) CSVImporter {
+		importerFactory: func(string, ImportOptions) CSVImporter {
 			return getMockCSVImporter(nil)
 		},
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(nil)
 		},
 	}
@@ -92,10 +92,10 @@ func Test_togglCli_Execute_HelpActionIsGiven_HelpIsPrinted(t *testing.T) {
 	}
 
 	cli := togglCli{
-		importerFactory: func(string) CSVImporter {
+		importerFactory: func(string, ImportOptions) CSVImporter {
 			return getMockCSVImporter(nil)
 		},
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(nil)
 		},
 	}
@@ -129,10 +129,10 @@ func Test_togglCli_Execute_IvalidActionIsGiven_ErrorIsPrinted(t *testing.T) {
 	}
 
 	cli := togglCli{
-		importerFactory: func(string) CSVImporter {
+		importerFactory: func(string, ImportOptions) CSVImporter {
 			return getMockCSVImporter(nil)
 		},
-		exporterFactory: func(string) CSVExporter {
+		exporterFactory: func(string, ExportOptions) CSVExporter {
 			return getMockCSVExporter(nil)
 		},
 	}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockCSVValidator struct {
+	validateFunc func(input io.Reader, writer io.Writer) error
+}
+
+func (validator *MockCSVValidator) Validate(input io.Reader, writer io.Writer) error {
+	return validator.validateFunc(input, writer)
+}
+
+func Test_togglCli_Execute_ValidateActionIsGiven_NoTokenRequired_ValidatorIsCalled(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"validate",
+	}
+
+	validatorFormat := ""
+	cli := togglCli{
+		validatorFactory: func(format string, location *time.Location) CSVValidator {
+			validatorFormat = format
+			return &MockCSVValidator{
+				validateFunc: func(input io.Reader, writer io.Writer) error {
+					return nil
+				},
+			}
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	errorWriter.Flush()
+
+	if !success || validatorFormat != "table" {
+		t.Fail()
+		t.Logf("togglCli_Execute should have validated the input using the table format but wrote this instead: %s", errorBuffer.String())
+	}
+}
+
+func Test_togglCli_Execute_ValidateActionIsGiven_JSONFormat_FormatIsPassedToValidator(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"validate",
+		"--format",
+		"json",
+	}
+
+	validatorFormat := ""
+	cli := togglCli{
+		validatorFactory: func(format string, location *time.Location) CSVValidator {
+			validatorFormat = format
+			return &MockCSVValidator{
+				validateFunc: func(input io.Reader, writer io.Writer) error {
+					return nil
+				},
+			}
+		},
+	}
+
+	// act
+	cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	if validatorFormat != "json" {
+		t.Fail()
+		t.Logf("togglCli_Execute should have passed the json format to the validator but passed %q", validatorFormat)
+	}
+}
+
+func Test_togglCli_Execute_ValidateActionIsGiven_ValidationFails_ErrorIsPrinted(t *testing.T) {
+	// arrange
+	inputString := ``
+	inputReader := strings.NewReader(inputString)
+
+	var outputBuffer bytes.Buffer
+	outputWriter := bufio.NewWriter(&outputBuffer)
+
+	var errorBuffer bytes.Buffer
+	errorWriter := bufio.NewWriter(&errorBuffer)
+
+	arguments := []string{
+		"validate",
+	}
+
+	cli := togglCli{
+		validatorFactory: func(string, *time.Location) CSVValidator {
+			return &MockCSVValidator{
+				validateFunc: func(input io.Reader, writer io.Writer) error {
+					return fmt.Errorf("Found 3 problem(s) in the input")
+				},
+			}
+		},
+	}
+
+	// act
+	success := cli.Execute(inputReader, outputWriter, errorWriter, arguments)
+
+	// assert
+	errorWriter.Flush()
+
+	if success || !strings.Contains(errorBuffer.String(), "Found 3 problem(s)") {
+		t.Fail()
+		t.Logf("togglCli_Execute should print the validation error but wrote this instead: %s", errorBuffer.String())
+	}
+}
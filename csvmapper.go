@@ -1,17 +1,48 @@
 package main
 
 import (
+	"encoding/csv"
 	"fmt"
+	"io"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/andreaskoch/togglapi/date"
 	"github.com/andreaskoch/togglcsv/toggl"
 )
 
+// The names of the CSV columns.
+const (
+	columnStart         = "Start"
+	columnStop          = "Stop"
+	columnDuration      = "Duration"
+	columnWorkspaceName = "Workspace Name"
+	columnProjectName   = "Project Name"
+	columnClientName    = "Client Name"
+	columnTags          = "Tag(s)"
+	columnDescription   = "Description"
+	columnBillable      = "Billable"
+)
+
+// knownColumnNames contains the names of all columns the mapper can read.
+var knownColumnNames = []string{columnStart, columnStop, columnDuration, columnWorkspaceName, columnProjectName, columnClientName, columnTags, columnDescription, columnBillable}
+
+// optionalColumns contains the columns that can be left out of a CSV row.
+// The "Stop" column is optional as well if there is a "Duration" column.
+var optionalColumns = map[string]bool{
+	columnDuration: true,
+	columnBillable: true,
+}
+
+// maxDescriptionLength defines the maximum number of characters of a time entry description.
+const maxDescriptionLength = 3000
+
 // The TimeRecordMapper interface provides functions for mapping CSV records to time records and vice versa.
 type TimeRecordMapper interface {
 	// GetTimeRecords returns a list of time records for the given CSV table rows.
-	GetTimeRecords(rows [][]string) ([]toggl.TimeRecord, error)
+	// lines contains the line number of every row (optional; default: the position of the row).
+	GetTimeRecords(rows [][]string, lines []int) ([]toggl.TimeRecord, error)
 
 	// GetTimeRecord returns a TimeRecord model from an CSV row.
 	GetTimeRecord(row []string) (toggl.TimeRecord, error)
@@ -21,102 +52,372 @@ type TimeRecordMapper interface {
 
 	// GetRow returns an CSV row for the given TimeRecord model.
 	GetRow(timeRecord toggl.TimeRecord) []string
+
+	// WithHeadline returns a mapper that reads the columns in the order of the given headline.
+	// Returns ValidationErrors if the headline contains unknown columns or lacks required ones.
+	WithHeadline(headline []string) (TimeRecordMapper, error)
+
+	// WithBillableColumn returns a mapper that also writes the "Billable" column.
+	WithBillableColumn() TimeRecordMapper
 }
 
 // NewCSVTimeRecordMapper converts CSV rows to TimeRecord models and vice versa.
 func NewCSVTimeRecordMapper(dateFormatter date.Formatter) TimeRecordMapper {
 	return &CSVTimeRecordMapper{
 		dateFormatter: dateFormatter,
-		columnNames:   []string{"Start", "Stop", "Workspace Name", "Project Name", "Client Name", "Tag(s)", "Description"},
+		columnNames:   []string{columnStart, columnStop, columnWorkspaceName, columnProjectName, columnClientName, columnTags, columnDescription},
 		tagsSeparator: ",",
 	}
 }
 
+// NewCSVTimeRecordMapperWithDuration converts CSV rows to TimeRecord models and vice versa
+// and writes an additional "Duration" column in the given format (clock, hours or minutes) next to "Start" and "Stop".
+func NewCSVTimeRecordMapperWithDuration(dateFormatter date.Formatter, durationFormat string) TimeRecordMapper {
+	return &CSVTimeRecordMapper{
+		dateFormatter:  dateFormatter,
+		columnNames:    []string{columnStart, columnStop, columnDuration, columnWorkspaceName, columnProjectName, columnClientName, columnTags, columnDescription},
+		tagsSeparator:  ",",
+		durationFormat: durationFormat,
+	}
+}
+
 // CSVTimeRecordMapper converts CSV time records into TimeRecord models.
 type CSVTimeRecordMapper struct {
 	dateFormatter date.Formatter
 
 	// columnNames contains the list of all CSV column names for CSV-based time reports used for import or export.
+	// The "Billable" column is only written if it is part of the list but it is always read.
 	columnNames []string
 
+	// inputColumnNames contains the column names of the headline of the CSV input (optional).
+	// Rows without a headline are read in the order of the columnNames followed by the optional "Billable" column.
+	inputColumnNames []string
+
 	// tagsSeparator contains the separator sign/string that is used to split and concatenate tags
 	tagsSeparator string
+
+	// durationFormat defines how values of the "Duration" column are written (clock, hours or minutes)
+	durationFormat string
 }
 
 // GetTimeRecords returns a list of time records for the given CSV table rows.
-func (mapper *CSVTimeRecordMapper) GetTimeRecords(rows [][]string) ([]toggl.TimeRecord, error) {
+// lines contains the line number of every row (optional; default: the position of the row).
+// All rows are validated; if any row is invalid ValidationErrors with all problems are returned.
+// Only one time record can be running because Toggl allows only one running timer per account.
+func (mapper *CSVTimeRecordMapper) GetTimeRecords(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+	if lines == nil {
+		lines = make([]int, len(rows))
+		for index := range lines {
+			lines[index] = index + 1
+		}
+	}
 
 	// cut the headline
-	if len(rows) > 0 {
-		firstLine := rows[0]
-		firstColumnName := mapper.GetColumnNames()[0]
-		if firstLine[0] == firstColumnName {
-			rows = rows[1:]
+	if len(rows) > 0 && isTimeRecordHeadline(rows[0]) {
+		headlineMapper, headlineError := mapper.WithHeadline(rows[0])
+		if headlineError != nil {
+			return nil, headlineError
 		}
+
+		return headlineMapper.(*CSVTimeRecordMapper).getTimeRecords(rows[1:], lines[1:])
 	}
 
+	return mapper.getTimeRecords(rows, lines)
+}
+
+// getTimeRecords returns a list of time records for the given CSV table rows without headline.
+// lines contains the line number of every row.
+func (mapper *CSVTimeRecordMapper) getTimeRecords(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+
 	// create time record models from each row
+	validate := func(index int) (toggl.TimeRecord, []ValidationError) {
+		return mapper.validateRow(rows[index])
+	}
+
+	getLine := func(index int) int {
+		return lines[index]
+	}
+
+	return collectTimeRecords(len(rows), columnStop, validate, getLine)
+}
+
+// collectTimeRecords validates the given number of time records with the given validate function
+// and returns them or ValidationErrors with the problems of all time records.
+// getLine returns the line number of the time record with the given index and
+// stopColumn the name of the stop date column in the input format.
+// Only one time record can be running because Toggl allows only one running timer per account.
+func collectTimeRecords(count int, stopColumn string, validate func(index int) (toggl.TimeRecord, []ValidationError), getLine func(index int) int) ([]toggl.TimeRecord, error) {
 	var timeRecords []toggl.TimeRecord
-	for _, row := range rows {
+	var validationErrors ValidationErrors
+	runningLine := 0
+	for index := 0; index < count; index++ {
 
-		timeRecord, timeRecordError := mapper.GetTimeRecord(row)
-		if timeRecordError != nil {
-			return nil, fmt.Errorf("Failed to create time entry from (%v): %s", row, timeRecordError.Error())
+		timeRecord, problems := validate(index)
+		if len(problems) == 0 && timeRecord.IsRunning() {
+			if runningLine > 0 {
+				problems = append(problems, ValidationError{
+					Column: stopColumn,
+					Reason: fmt.Sprintf("Only one time record can be running but line %d is running already", runningLine),
+				})
+			} else {
+				runningLine = getLine(index)
+			}
+		}
+
+		if len(problems) > 0 {
+			for _, problem := range problems {
+				problem.Line = getLine(index)
+				validationErrors = append(validationErrors, problem)
+			}
+
+			continue
 		}
 
 		timeRecords = append(timeRecords, timeRecord)
 
 	}
 
+	if len(validationErrors) > 0 {
+		return nil, validationErrors
+	}
+
 	return timeRecords, nil
 }
 
 // GetTimeRecord returns a TimeRecord model from an CSV row.
 func (mapper *CSVTimeRecordMapper) GetTimeRecord(row []string) (toggl.TimeRecord, error) {
+	timeRecord, problems := mapper.validateRow(row)
+	if len(problems) > 0 {
+		return toggl.TimeRecord{}, fmt.Errorf("%s", problems[0].Reason)
+	}
+
+	return timeRecord, nil
+}
+
+// WithHeadline returns a mapper that reads the columns in the order of the given headline.
+// Column names are compared case-insensitively. Columns with an empty headline cell are ignored
+// like in isHeadline because spreadsheet tools often add them.
+// Returns ValidationErrors if the headline contains unknown columns or lacks required ones.
+func (mapper *CSVTimeRecordMapper) WithHeadline(headline []string) (TimeRecordMapper, error) {
+	var problems ValidationErrors
+
+	inputColumnNames := make([]string, len(headline))
+	for index, value := range headline {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		columnName := getKnownColumnName(value)
+		if columnName == "" {
+			problems = append(problems, ValidationError{
+				Line:   1,
+				Value:  value,
+				Reason: fmt.Sprintf("Unknown column %q", value),
+			})
+		}
+
+		inputColumnNames[index] = columnName
+	}
+
+	headlineMapper := *mapper
+	headlineMapper.inputColumnNames = inputColumnNames
+
+	for _, columnName := range knownColumnNames {
+		if headlineMapper.getColumnIndex(columnName) < 0 && !headlineMapper.isOptionalColumn(columnName) {
+			problems = append(problems, ValidationError{
+				Line:   1,
+				Column: columnName,
+				Reason: fmt.Sprintf("The headline has no %q column", columnName),
+			})
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return &headlineMapper, nil
+}
+
+// validateRow returns a TimeRecord model from an CSV row and all problems of the row.
+// The line numbers of the returned problems are not set.
+func (mapper *CSVTimeRecordMapper) validateRow(row []string) (toggl.TimeRecord, []ValidationError) {
 
 	// check the number of columns
-	if len(row) != len(mapper.GetColumnNames()) {
-		return toggl.TimeRecord{}, fmt.Errorf("Wrong number of values in the given row. The required: %d. Given: %d", len(mapper.GetColumnNames()), len(row))
+	columnNames := mapper.getInputColumnNames()
+	minColumns := 0
+	for index, columnName := range columnNames {
+		if !mapper.isOptionalColumn(columnName) {
+			minColumns = index + 1
+		}
+	}
+
+	if len(row) < minColumns || len(row) > len(columnNames) {
+		reason := fmt.Sprintf("Wrong number of values in the given row. The required: %d. Given: %d", len(columnNames), len(row))
+		if minColumns < len(columnNames) {
+			reason = fmt.Sprintf("Wrong number of values in the given row. The required: %d to %d. Given: %d", minColumns, len(columnNames), len(row))
+		}
+
+		return toggl.TimeRecord{}, []ValidationError{
+			ValidationError{
+				Value:  strings.Join(row, ","),
+				Reason: reason,
+			},
+		}
+	}
+
+	// getValue returns the value of the given column or an empty string if the row doesn't have the column
+	getValue := func(columnName string) string {
+		index := mapper.getColumnIndex(columnName)
+		if index < 0 || index >= len(row) {
+			return ""
+		}
+
+		return row[index]
 	}
 
+	// Tags
+	tags := strings.Split(getValue(columnTags), mapper.tagsSeparator)
+
+	return validateTimeRecordValues(timeRecordValues{
+		start:       getValue(columnStart),
+		stop:        getValue(columnStop),
+		duration:    getValue(columnDuration),
+		workspace:   getValue(columnWorkspaceName),
+		project:     getValue(columnProjectName),
+		client:      getValue(columnClientName),
+		tags:        tags,
+		description: getValue(columnDescription),
+		billable:    getValue(columnBillable),
+	}, mapper.dateFormatter)
+}
+
+// timeRecordValues contains the unparsed values of a time record in any input format.
+type timeRecordValues struct {
+	start       string
+	stop        string
+	duration    string
+	workspace   string
+	project     string
+	client      string
+	tags        []string
+	description string
+	billable    string
+}
+
+// validateTimeRecordValues returns a TimeRecord model for the given values and all problems of the values.
+// The problems refer to the values by their CSV column names; their line numbers are not set.
+func validateTimeRecordValues(values timeRecordValues, dateFormatter date.Formatter) (toggl.TimeRecord, []ValidationError) {
+	var problems []ValidationError
+
 	// Start date
-	startDateVal := row[0]
-	startDate, startDateError := mapper.dateFormatter.GetDate(startDateVal)
+	startDateVal := values.start
+	startDate, startDateError := dateFormatter.GetDate(startDateVal)
 	if startDateError != nil {
-		return toggl.TimeRecord{}, fmt.Errorf("Cannot parse the start date: %s", startDateError)
+		problems = append(problems, ValidationError{
+			Column: columnStart,
+			Value:  startDateVal,
+			Reason: fmt.Sprintf("Cannot parse the start date: %s", startDateError),
+		})
 	}
 
-	// Stop Date
-	stopDateVal := row[1]
-	stopDate, stopDateError := mapper.dateFormatter.GetDate(stopDateVal)
-	if stopDateError != nil {
-		return toggl.TimeRecord{}, fmt.Errorf("Cannot parse the stop date: %s", stopDateError)
+	// Stop Date (empty for a running time record)
+	stopDateVal := values.stop
+	var stopDate time.Time
+	var stopDateError error
+	if strings.TrimSpace(stopDateVal) != "" {
+		stopDate, stopDateError = dateFormatter.GetDate(stopDateVal)
+		if stopDateError != nil {
+			problems = append(problems, ValidationError{
+				Column: columnStop,
+				Value:  stopDateVal,
+				Reason: fmt.Sprintf("Cannot parse the stop date: %s", stopDateError),
+			})
+		}
+	}
+
+	// Duration (optional; replaces or confirms the stop date)
+	durationVal := strings.TrimSpace(values.duration)
+	if durationVal != "" {
+		duration, durationError := parseDuration(durationVal)
+		switch {
+		case durationError != nil:
+			problems = append(problems, ValidationError{
+				Column: columnDuration,
+				Value:  durationVal,
+				Reason: durationError.Error(),
+			})
+
+		case startDateError != nil || stopDateError != nil:
+			// the dates have been reported already
+
+		case stopDate.IsZero():
+			stopDate = startDate.Add(duration)
+
+		case !durationMatches(stopDate.Sub(startDate), duration):
+			problems = append(problems, ValidationError{
+				Column: columnDuration,
+				Value:  durationVal,
+				Reason: fmt.Sprintf("The duration does not match the %s between the start and the stop date", stopDate.Sub(startDate)),
+			})
+		}
+	}
+
+	if startDateError == nil && stopDateError == nil && !stopDate.IsZero() && stopDate.Before(startDate) {
+		problems = append(problems, ValidationError{
+			Column: columnStop,
+			Value:  stopDateVal,
+			Reason: fmt.Sprintf("The stop date is before the start date %q", startDateVal),
+		})
 	}
 
 	// Workspace Name
-	workspaceVal := row[2]
-	workspaceVal = strings.TrimSpace(workspaceVal)
+	workspaceVal := strings.TrimSpace(values.workspace)
 
 	// Project Name
-	projectVal := row[3]
-	projectVal = strings.TrimSpace(projectVal)
+	projectVal := strings.TrimSpace(values.project)
 
 	// Client Name
-	clientVal := row[4]
-	clientVal = strings.TrimSpace(clientVal)
+	clientVal := strings.TrimSpace(values.client)
+
+	// clients are assigned via projects
+	if projectVal == "" && clientVal != "" {
+		problems = append(problems, ValidationError{
+			Column: columnClientName,
+			Value:  clientVal,
+			Reason: "A client can only be assigned together with a project",
+		})
+	}
 
 	// Tags
-	tagsVal := row[5]
-	tags := strings.Split(tagsVal, mapper.tagsSeparator)
-	for index, tag := range tags {
+	tags := make([]string, len(values.tags))
+	for index, tag := range values.tags {
 		tags[index] = strings.TrimSpace(tag)
 	}
 
 	// Description
-	descriptionVal := row[6]
-	description := strings.TrimSpace(descriptionVal)
-	if len(description) >= 3000 {
-		return toggl.TimeRecord{}, fmt.Errorf("The description text of the time entry %q is too long", startDate)
+	description := strings.TrimSpace(values.description)
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		problems = append(problems, ValidationError{
+			Column: columnDescription,
+			Value:  description,
+			Reason: fmt.Sprintf("The description is longer than %d characters", maxDescriptionLength),
+		})
+	}
+
+	// Billable (optional)
+	billableVal := strings.TrimSpace(values.billable)
+	billable, billableError := parseBillable(billableVal)
+	if billableError != nil {
+		problems = append(problems, ValidationError{
+			Column: columnBillable,
+			Value:  billableVal,
+			Reason: billableError.Error(),
+		})
+	}
+
+	if len(problems) > 0 {
+		return toggl.TimeRecord{}, problems
 	}
 
 	entry := toggl.TimeRecord{
@@ -127,6 +428,7 @@ func (mapper *CSVTimeRecordMapper) GetTimeRecord(row []string) (toggl.TimeRecord
 		ClientName:    clientVal,
 		Description:   description,
 		Tags:          tags,
+		Billable:      billable,
 	}
 
 	return entry, nil
@@ -139,14 +441,204 @@ func (mapper *CSVTimeRecordMapper) GetColumnNames() []string {
 }
 
 // GetRow returns an CSV row for the given TimeRecord model.
+// The stop date and the duration of a running time record are empty.
 func (mapper *CSVTimeRecordMapper) GetRow(timeRecord toggl.TimeRecord) []string {
-	return []string{
-		mapper.dateFormatter.GetDateString(timeRecord.Start),
-		mapper.dateFormatter.GetDateString(timeRecord.Stop),
-		timeRecord.WorkspaceName,
-		timeRecord.ProjectName,
-		timeRecord.ClientName,
-		strings.Join(timeRecord.Tags, mapper.tagsSeparator),
-		timeRecord.Description,
+	var row []string
+	for _, columnName := range mapper.GetColumnNames() {
+		var value string
+
+		switch columnName {
+		case columnStart:
+			value = mapper.dateFormatter.GetDateString(timeRecord.Start)
+
+		case columnStop:
+			if !timeRecord.IsRunning() {
+				value = mapper.dateFormatter.GetDateString(timeRecord.Stop)
+			}
+
+		case columnDuration:
+			if !timeRecord.IsRunning() {
+				value = formatDuration(timeRecord.Stop.Sub(timeRecord.Start), mapper.durationFormat)
+			}
+
+		case columnWorkspaceName:
+			value = timeRecord.WorkspaceName
+
+		case columnProjectName:
+			value = timeRecord.ProjectName
+
+		case columnClientName:
+			value = timeRecord.ClientName
+
+		case columnTags:
+			value = strings.Join(timeRecord.Tags, mapper.tagsSeparator)
+
+		case columnDescription:
+			value = timeRecord.Description
+
+		case columnBillable:
+			value = formatBillable(timeRecord.Billable)
+		}
+
+		row = append(row, value)
+	}
+
+	return row
+}
+
+// WithBillableColumn returns a mapper that also writes the "Billable" column after all other columns.
+func (mapper *CSVTimeRecordMapper) WithBillableColumn() TimeRecordMapper {
+	billableMapper := *mapper
+	if billableMapper.hasColumn(columnBillable) {
+		return &billableMapper
+	}
+
+	billableMapper.columnNames = append(append([]string{}, mapper.columnNames...), columnBillable)
+	return &billableMapper
+}
+
+// hasColumn returns true if the given column is one of the written columns.
+func (mapper *CSVTimeRecordMapper) hasColumn(columnName string) bool {
+	for _, name := range mapper.columnNames {
+		if name == columnName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getInputColumnNames returns the names of the columns in the order they appear in the CSV input.
+// Without a headline the optional "Billable" column can follow the written columns.
+func (mapper *CSVTimeRecordMapper) getInputColumnNames() []string {
+	if mapper.inputColumnNames != nil {
+		return mapper.inputColumnNames
+	}
+
+	if mapper.hasColumn(columnBillable) {
+		return mapper.columnNames
+	}
+
+	return append(append([]string{}, mapper.columnNames...), columnBillable)
+}
+
+// getColumnIndex returns the position of the given column in the CSV input or -1 if the input doesn't have the column.
+func (mapper *CSVTimeRecordMapper) getColumnIndex(columnName string) int {
+	for index, inputColumnName := range mapper.getInputColumnNames() {
+		if inputColumnName == columnName {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// isOptionalColumn returns true if the given column can be left out of the CSV input.
+func (mapper *CSVTimeRecordMapper) isOptionalColumn(columnName string) bool {
+	if columnName == columnStop {
+		return mapper.getColumnIndex(columnDuration) >= 0
+	}
+
+	return optionalColumns[columnName]
+}
+
+// getKnownColumnName returns the name of the known column that matches the given headline value
+// or an empty string if the column is unknown.
+func getKnownColumnName(value string) string {
+	return getColumnName(value, knownColumnNames)
+}
+
+// getColumnName returns the name of the given column names that matches the given headline value
+// case-insensitively or an empty string if none matches.
+func getColumnName(value string, columnNames []string) string {
+	for _, columnName := range columnNames {
+		if strings.EqualFold(strings.TrimSpace(value), columnName) {
+			return columnName
+		}
+	}
+
+	return ""
+}
+
+// parseBillable parses the value of the "Billable" column.
+// Accepts yes/no, true/false and 1/0 (case-insensitive); an empty value is not billable.
+func parseBillable(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "true", "1":
+		return true, nil
+
+	case "", "no", "false", "0":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("Cannot parse the billable flag %q. Use yes/no, true/false or 1/0", value)
+}
+
+// formatBillable returns the value of the "Billable" column for the given flag.
+func formatBillable(billable bool) string {
+	if billable {
+		return "yes"
+	}
+
+	return "no"
+}
+
+// isHeadline returns true if every non-empty value of the given row is one of the given column names.
+// The names are compared case-insensitively so that the columns can appear in any order and case.
+func isHeadline(row []string, columnNames []string) bool {
+	hasColumnName := false
+	for _, value := range row {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		if getColumnName(value, columnNames) == "" {
+			return false
+		}
+
+		hasColumnName = true
+	}
+
+	return hasColumnName
+}
+
+// hasColumnName returns true if one of the values of the given headline matches the given column name.
+func hasColumnName(headline []string, columnName string) bool {
+	for _, value := range headline {
+		if getColumnName(value, []string{columnName}) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTimeRecordHeadline returns true if every value of the given row is the name of a time record column.
+func isTimeRecordHeadline(row []string) bool {
+	return isHeadline(row, knownColumnNames)
+}
+
+// readCSVRows reads all rows from the given CSV input and returns them with the line number of every row.
+// The line numbers account for blank lines and values that span several lines.
+// The number of values per row is not checked so that the mapper can report it for every row.
+func readCSVRows(input io.Reader) ([][]string, []int, error) {
+	csvReader := csv.NewReader(input)
+	csvReader.FieldsPerRecord = -1
+
+	var rows [][]string
+	var lines []int
+	for {
+		row, readError := csvReader.Read()
+		if readError == io.EOF {
+			return rows, lines, nil
+		}
+
+		if readError != nil {
+			return nil, nil, readError
+		}
+
+		line, _ := csvReader.FieldPos(0)
+		rows = append(rows, row)
+		lines = append(lines, line)
 	}
 }
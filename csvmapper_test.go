@@ -3,6 +3,7 @@ package main
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/andreaskoch/togglapi/date"
 	"github.com/andreaskoch/togglcsv/toggl"
@@ -176,7 +177,7 @@ func Test_GetTimeRecords_NoRowsGiven_EmptyListReturned(t *testing.T) {
 	var rows [][]string
 
 	// act
-	records, _ := csvMapper.GetTimeRecords(rows)
+	records, _ := csvMapper.GetTimeRecords(rows, nil)
 
 	// assert
 	if len(records) > 0 {
@@ -200,7 +201,7 @@ func Test_GetTimeRecords_OnlyHeadlineGiven_EmptyListReturned(t *testing.T) {
 	}
 
 	// act
-	records, _ := csvMapper.GetTimeRecords(rows)
+	records, _ := csvMapper.GetTimeRecords(rows, nil)
 
 	// assert
 	if len(records) > 0 {
@@ -225,7 +226,7 @@ func Test_GetTimeRecords_HeadlineGiven_RowCannotBeParsed_ErrorIsReturned(t *test
 	}
 
 	// act
-	_, err := csvMapper.GetTimeRecords(rows)
+	_, err := csvMapper.GetTimeRecords(rows, nil)
 
 	// assert
 	if err == nil {
@@ -250,7 +251,7 @@ func Test_GetTimeRecords_HeadlineGiven_ValidRow_OneRecordIsReturned(t *testing.T
 	}
 
 	// act
-	records, _ := csvMapper.GetTimeRecords(rows)
+	records, _ := csvMapper.GetTimeRecords(rows, nil)
 
 	// assert
 	if len(records) != 1 {
@@ -277,7 +278,7 @@ func Test_GetTimeRecords_HeadlineGiven_ThreeRows_ThreeRecordsAreReturned(t *test
 	}
 
 	// act
-	records, _ := csvMapper.GetTimeRecords(rows)
+	records, _ := csvMapper.GetTimeRecords(rows, nil)
 
 	// assert
 	if len(records) != 3 {
@@ -318,6 +319,8 @@ func Test_GetRow(t *testing.T) {
 	}
 
 	timeRecord := toggl.TimeRecord{
+		Start:         time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+		Stop:          time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC),
 		WorkspaceName: "Workspace",
 		ProjectName:   "Project XY",
 		ClientName:    "Client X",
@@ -329,9 +332,431 @@ func Test_GetRow(t *testing.T) {
 	row := csvMapper.GetRow(timeRecord)
 
 	// assert
-	expected := "0001-01-01T00:00:00+00:00|0001-01-01T00:00:00+00:00|Workspace|Project XY|Client X|Tag 1,Tag 2,XYZ|Some stuff"
+	expected := "2016-08-12T09:00:00+00:00|2016-08-12T10:00:00+00:00|Workspace|Project XY|Client X|Tag 1,Tag 2,XYZ|Some stuff"
 	if strings.Join(row, "|") != expected {
 		t.Fail()
 		t.Logf("GetRow returned an invalid value. Expected: %q, Actual: %q", expected, strings.Join(row, "|"))
 	}
 }
+
+func Test_GetTimeRecord_StopBeforeStart_ErrorIsReturned(t *testing.T) {
+	// arrange
+	dateFormatter := date.NewISO8601Formatter()
+	csvMapper := &CSVTimeRecordMapper{
+		dateFormatter: dateFormatter,
+		columnNames:   []string{"Start", "Stop", "Workspace Name", "Project Name", "Client Name", "Tag(s)", "Description"},
+		tagsSeparator: ",",
+	}
+
+	row := []string{"2015-03-26T11:00:00+01:00", "2015-03-26T08:00:00+01:00", "Workspace", "Project XY", "Client X", "", "Some stuff"}
+
+	// act
+	_, err := csvMapper.GetTimeRecord(row)
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("GetTimeRecord should return an error if the stop date is before the start date")
+	}
+}
+
+func Test_GetTimeRecords_MultipleInvalidRows_AllProblemsAreReturned(t *testing.T) {
+	// arrange
+	dateFormatter := date.NewISO8601Formatter()
+	csvMapper := &CSVTimeRecordMapper{
+		dateFormatter: dateFormatter,
+		columnNames:   []string{"Start", "Stop", "Workspace Name", "Project Name", "Client Name", "Tag(s)", "Description"},
+		tagsSeparator: ",",
+	}
+
+	rows := [][]string{
+		[]string{"Start", "Stop", "Workspace Name", "Project Name", "Client Name", "Tag(s)", "Description"},
+		[]string{"2015-03-26T08:00:00+01:00", "2015-03-26T11:30:00+01:00", "Workspace", "Project XY", "Client X", "", "Some stuff"},
+		[]string{"Invalid Date", "2015-03-27T11:30:00+01:00", "Workspace", "Project XY", "Client X", "", "Some more stuff"},
+		[]string{"2015-03-28T08:00:00+01:00", "Workspace", "Project XY"},
+		[]string{"2015-03-29T11:30:00+01:00", "2015-03-29T08:00:00+01:00", "Workspace", "Project XY", "Client X", "", "Some more stuff"},
+	}
+
+	// act
+	_, err := csvMapper.GetTimeRecords(rows, nil)
+
+	// assert
+	validationErrors, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fail()
+		t.Logf("GetTimeRecords should have returned validation errors but returned: %v", err)
+		return
+	}
+
+	expected := []ValidationError{
+		ValidationError{Line: 3, Column: "Start", Value: "Invalid Date"},
+		ValidationError{Line: 4, Column: "", Value: "2015-03-28T08:00:00+01:00,Workspace,Project XY"},
+		ValidationError{Line: 5, Column: "Stop", Value: "2015-03-29T08:00:00+01:00"},
+	}
+
+	if len(validationErrors) != len(expected) {
+		t.Fail()
+		t.Logf("GetTimeRecords should have returned %d problems but returned %d: %#v", len(expected), len(validationErrors), validationErrors)
+		return
+	}
+
+	for index, validationError := range validationErrors {
+		if validationError.Line != expected[index].Line || validationError.Column != expected[index].Column || validationError.Value != expected[index].Value || validationError.Reason == "" {
+			t.Fail()
+			t.Logf("GetTimeRecords should have returned %#v but returned %#v", expected[index], validationError)
+		}
+	}
+}
+
+func Test_GetTimeRecord_BillableColumn_ValuesAreParsed(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+
+	inputs := map[string]bool{
+		"yes":   true,
+		"True":  true,
+		"1":     true,
+		"no":    false,
+		"false": false,
+		"0":     false,
+		"":      false,
+	}
+
+	for value, expected := range inputs {
+		row := []string{"2015-03-26T08:00:00+01:00", "2015-03-26T11:00:00+01:00", "Workspace", "Project XY", "Client X", "", "Some stuff", value}
+
+		// act
+		timeRecord, err := csvMapper.GetTimeRecord(row)
+
+		// assert
+		if err != nil || timeRecord.Billable != expected {
+			t.Fail()
+			t.Logf("GetTimeRecord should have parsed %q as %t but returned %t (%v)", value, expected, timeRecord.Billable, err)
+		}
+	}
+}
+
+func Test_GetTimeRecord_BillableColumnMissing_TimeRecordIsNotBillable(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	row := []string{"2015-03-26T08:00:00+01:00", "2015-03-26T11:00:00+01:00", "Workspace", "Project XY", "Client X", "", "Some stuff"}
+
+	// act
+	timeRecord, err := csvMapper.GetTimeRecord(row)
+
+	// assert
+	if err != nil || timeRecord.Billable {
+		t.Fail()
+		t.Logf("GetTimeRecord should accept rows without billable column (%v)", err)
+	}
+}
+
+func Test_GetTimeRecord_InvalidBillableValue_ErrorIsReturned(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	row := []string{"2015-03-26T08:00:00+01:00", "2015-03-26T11:00:00+01:00", "Workspace", "Project XY", "Client X", "", "Some stuff", "maybe"}
+
+	// act
+	_, err := csvMapper.GetTimeRecord(row)
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("GetTimeRecord should return an error if the billable value is invalid")
+	}
+}
+
+func Test_GetRow_BillableColumn_FlagIsWritten(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter()).WithBillableColumn()
+	timeRecord := toggl.TimeRecord{
+		Billable: true,
+	}
+
+	// act
+	row := csvMapper.GetRow(timeRecord)
+
+	// assert
+	if len(row) != 8 || row[7] != "yes" || csvMapper.GetColumnNames()[7] != "Billable" {
+		t.Fail()
+		t.Logf("GetRow should have written the billable flag but returned: %v", row)
+	}
+}
+
+func Test_GetRow_NoBillableColumn_SevenColumnsAreWritten(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	timeRecord := toggl.TimeRecord{
+		Billable: true,
+	}
+
+	// act
+	row := csvMapper.GetRow(timeRecord)
+
+	// assert
+	if len(row) != 7 || len(csvMapper.GetColumnNames()) != 7 {
+		t.Fail()
+		t.Logf("GetRow should only write the seven default columns but returned: %v", row)
+	}
+}
+
+func Test_GetTimeRecord_NoProjectName_TimeRecordWithoutProjectIsReturned(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	row := []string{"2015-03-26T08:00:00+01:00", "2015-03-26T11:00:00+01:00", "Workspace", "", "", "", "Some stuff"}
+
+	// act
+	timeRecord, err := csvMapper.GetTimeRecord(row)
+
+	// assert
+	if err != nil || timeRecord.ProjectName != "" {
+		t.Fail()
+		t.Logf("GetTimeRecord should accept time records without project but returned %#v (%v)", timeRecord, err)
+	}
+}
+
+func Test_GetTimeRecord_ClientWithoutProject_ErrorIsReturned(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	row := []string{"2015-03-26T08:00:00+01:00", "2015-03-26T11:00:00+01:00", "Workspace", "", "Client X", "", "Some stuff"}
+
+	// act
+	_, err := csvMapper.GetTimeRecord(row)
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("GetTimeRecord should return an error if a client is given without a project")
+	}
+}
+
+func Test_GetTimeRecord_EmptyStopDate_RunningTimeRecordIsReturned(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	row := []string{"2015-03-26T08:00:00+01:00", "", "Workspace", "Project XY", "Client X", "", "Some stuff"}
+
+	// act
+	timeRecord, err := csvMapper.GetTimeRecord(row)
+
+	// assert
+	if err != nil || !timeRecord.IsRunning() {
+		t.Fail()
+		t.Logf("GetTimeRecord should have returned a running time record but returned %#v (%v)", timeRecord, err)
+	}
+}
+
+func Test_GetTimeRecords_TwoRunningTimeRecords_ErrorIsReturned(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	rows := [][]string{
+		[]string{"2015-03-26T08:00:00+01:00", "", "Workspace", "Project XY", "Client X", "", "First"},
+		[]string{"2015-03-26T09:00:00+01:00", "", "Workspace", "Project XY", "Client X", "", "Second"},
+	}
+
+	// act
+	_, err := csvMapper.GetTimeRecords(rows, nil)
+
+	// assert
+	validationErrors, isValidationErrors := err.(ValidationErrors)
+	if !isValidationErrors || len(validationErrors) != 1 || validationErrors[0].Line != 2 {
+		t.Fail()
+		t.Logf("GetTimeRecords should have reported the second running time record in line 2 but returned: %v", err)
+	}
+}
+
+func Test_GetRow_RunningTimeRecord_StopDateIsEmpty(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	timeRecord := toggl.TimeRecord{
+		Start: time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+	}
+
+	// act
+	row := csvMapper.GetRow(timeRecord)
+
+	// assert
+	if row[0] == "" || row[1] != "" {
+		t.Fail()
+		t.Logf("GetRow should have written an empty stop date for a running time record but returned: %v", row)
+	}
+}
+
+func Test_GetTimeRecords_DurationInsteadOfStop_StopIsCalculated(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	rows := [][]string{
+		[]string{"Start", "Duration", "Workspace Name", "Project Name", "Client Name", "Tag(s)", "Description"},
+		[]string{"2015-03-26T08:00:00+01:00", "1:30", "Workspace", "Project XY", "Client X", "", "Some stuff"},
+	}
+
+	// act
+	timeRecords, err := csvMapper.GetTimeRecords(rows, nil)
+
+	// assert
+	expectedStop := time.Date(2015, 3, 26, 8, 30, 0, 0, time.UTC)
+	if err != nil || len(timeRecords) != 1 || !timeRecords[0].Stop.Equal(expectedStop) || timeRecords[0].WorkspaceName != "Workspace" {
+		t.Fail()
+		t.Logf("GetTimeRecords should have calculated the stop date %s but returned %#v (%v)", expectedStop, timeRecords, err)
+	}
+}
+
+func Test_GetTimeRecords_StopAndDurationDoNotMatch_ErrorIsReturned(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	rows := [][]string{
+		[]string{"Start", "Stop", "Duration", "Workspace Name", "Project Name", "Client Name", "Tag(s)", "Description"},
+		[]string{"2015-03-26T08:00:00+01:00", "2015-03-26T09:30:00+01:00", "1.5h", "Workspace", "Project XY", "Client X", "", "Matching"},
+		[]string{"2015-03-26T10:00:00+01:00", "2015-03-26T11:00:00+01:00", "90m", "Workspace", "Project XY", "Client X", "", "Not matching"},
+	}
+
+	// act
+	_, err := csvMapper.GetTimeRecords(rows, nil)
+
+	// assert
+	validationErrors, isValidationErrors := err.(ValidationErrors)
+	if !isValidationErrors || len(validationErrors) != 1 || validationErrors[0].Line != 3 || validationErrors[0].Column != "Duration" {
+		t.Fail()
+		t.Logf("GetTimeRecords should have reported the mismatching duration in line 3 but returned: %v", err)
+	}
+}
+
+func Test_GetTimeRecords_ColumnsInDifferentOrder_ColumnsAreMappedByHeadline(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	rows := [][]string{
+		[]string{"Start", "Description", "Stop", "Workspace Name", "Project Name", "Client Name", "Tag(s)"},
+		[]string{"2015-03-26T08:00:00+01:00", "Some stuff", "2015-03-26T09:00:00+01:00", "Workspace", "Project XY", "Client X", "Tag 1"},
+	}
+
+	// act
+	timeRecords, err := csvMapper.GetTimeRecords(rows, nil)
+
+	// assert
+	if err != nil || len(timeRecords) != 1 || timeRecords[0].Description != "Some stuff" || timeRecords[0].Tags[0] != "Tag 1" {
+		t.Fail()
+		t.Logf("GetTimeRecords should have mapped the columns by the headline but returned %#v (%v)", timeRecords, err)
+	}
+}
+
+func Test_GetTimeRecords_HeadlineWithUnknownAndMissingColumns_ErrorIsReturned(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	rows := [][]string{
+		[]string{"Start", "Workspace Name", "Project Name", "Client Name", "Tag(s)", "Description", "Notes"},
+	}
+
+	// act
+	_, err := csvMapper.GetTimeRecords(rows, nil)
+
+	// assert
+	validationErrors, isValidationErrors := err.(ValidationErrors)
+	if !isValidationErrors || len(validationErrors) != 2 {
+		t.Fail()
+		t.Logf("GetTimeRecords should have reported the unknown Notes column and the missing Stop column but returned: %v", err)
+	}
+}
+
+func Test_GetRow_DurationFormat_DurationIsWrittenNextToStop(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapperWithDuration(date.NewISO8601Formatter(), durationFormatHours)
+	timeRecord := toggl.TimeRecord{
+		Start: time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+		Stop:  time.Date(2016, 8, 12, 10, 30, 0, 0, time.UTC),
+	}
+
+	// act
+	row := csvMapper.GetRow(timeRecord)
+
+	// assert
+	if csvMapper.GetColumnNames()[2] != "Duration" || row[2] != "1.5h" {
+		t.Fail()
+		t.Logf("GetRow should have written the duration into the third column but returned: %v", row)
+	}
+}
+
+func Test_GetTimeRecords_HeadlineInOtherOrderAndCase_RecordsAreReturned(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+
+	rows := [][]string{
+		[]string{"Description", "start", "DURATION", "Workspace Name", "project name", "Client Name", "tag(s)"},
+		[]string{"Planning", "2016-08-01T09:00:00+00:00", "1:30", "Work", "Website", "", ""},
+	}
+
+	// act
+	records, err := csvMapper.GetTimeRecords(rows, nil)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("GetTimeRecords should detect the headline but returned an error: %s", err.Error())
+		return
+	}
+
+	if len(records) != 1 || records[0].Description != "Planning" || records[0].Stop.Sub(records[0].Start) != 90*time.Minute {
+		t.Fail()
+		t.Logf("GetTimeRecords should have read the columns in the order of the headline but returned %#v", records)
+	}
+}
+
+func Test_isTimeRecordHeadline(t *testing.T) {
+	inputs := map[string]bool{
+		"Start|Stop|Workspace Name":          true,
+		"description|START|duration":         true,
+		"Start||Workspace Name":              true,
+		"2016-08-01T09:00:00+00:00|Stop|Tag": false,
+		"Start|Stop|Comment":                 false,
+		"|":                                  false,
+	}
+
+	for input, expected := range inputs {
+		// act
+		result := isTimeRecordHeadline(strings.Split(input, "|"))
+
+		// assert
+		if result != expected {
+			t.Fail()
+			t.Logf("isTimeRecordHeadline(%q) should return %t but returned %t", input, expected, result)
+		}
+	}
+}
+
+func Test_GetTimeRecords_HeadlineWithEmptyTrailingCell_RecordsAreReturned(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	rows := [][]string{
+		[]string{"Start", "Stop", "Workspace Name", "Project Name", "Client Name", "Tag(s)", "Description", ""},
+		[]string{"2015-03-26T08:00:00+01:00", "2015-03-26T09:00:00+01:00", "Workspace", "Project XY", "Client X", "", "Some stuff", ""},
+	}
+
+	// act
+	timeRecords, err := csvMapper.GetTimeRecords(rows, nil)
+
+	// assert
+	if err != nil || len(timeRecords) != 1 || timeRecords[0].Description != "Some stuff" {
+		t.Fail()
+		t.Logf("GetTimeRecords should have ignored the empty headline cell but returned %#v (%v)", timeRecords, err)
+	}
+}
+
+func Test_GetTimeRecords_BlankLinesAndMultiLineValues_LinesOfTheInputAreReported(t *testing.T) {
+	// arrange
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+	input := `Start,Stop,Workspace Name,Project Name,Client Name,Tag(s),Description
+
+2015-03-26T08:00:00+01:00,2015-03-26T09:00:00+01:00,Workspace,Project XY,Client X,,"First line
+Second line"
+Invalid Date,2015-03-26T11:00:00+01:00,Workspace,Project XY,Client X,,Record 2`
+
+	rows, lines, readError := readCSVRows(strings.NewReader(input))
+
+	// act
+	_, err := csvMapper.GetTimeRecords(rows, lines)
+
+	// assert
+	validationErrors, isValidationErrors := err.(ValidationErrors)
+	if readError != nil || !isValidationErrors || len(validationErrors) != 1 || validationErrors[0].Line != 5 {
+		t.Fail()
+		t.Logf("GetTimeRecords should have reported the invalid date in line 5 but returned: %v (%v)", err, readError)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/now"
+)
+
+// The named date ranges of the export command.
+const (
+	dateRangeToday       = "today"
+	dateRangeYesterday   = "yesterday"
+	dateRangeThisWeek    = "this-week"
+	dateRangeLastWeek    = "last-week"
+	dateRangeThisMonth   = "this-month"
+	dateRangeLastMonth   = "last-month"
+	dateRangeThisQuarter = "this-quarter"
+	dateRangeLastQuarter = "last-quarter"
+	dateRangeThisYear    = "this-year"
+	dateRangeLastYear    = "last-year"
+	dateRangeYearToDate  = "ytd"
+)
+
+// dateRangeNames contains all named date ranges.
+var dateRangeNames = []string{
+	dateRangeToday, dateRangeYesterday,
+	dateRangeThisWeek, dateRangeLastWeek,
+	dateRangeThisMonth, dateRangeLastMonth,
+	dateRangeThisQuarter, dateRangeLastQuarter,
+	dateRangeThisYear, dateRangeLastYear,
+	dateRangeYearToDate,
+}
+
+// lastDaysPattern matches the last n days including today (e.g. "last-7d").
+var lastDaysPattern = regexp.MustCompile(`^last-(\d+)d$`)
+
+// isoWeekPattern matches ISO 8601 weeks (e.g. "2016-W32").
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
+// dateRange contains the first and the last day of a date range.
+type dateRange struct {
+	start time.Time
+	end   time.Time
+
+	// named is false if the range was given as a single date (e.g. "2016-08-01")
+	named bool
+}
+
+// parseDateRange returns the days covered by the given expression relative to the given current date.
+// The expression can be a date ("2016-08-01"), an ISO week ("2016-W32"), the last n days including today ("last-7d")
+// or one of the dateRangeNames. The returned days begin at midnight in the time zone of the given current date.
+// Weeks start on Monday unless now.FirstDayMonday is disabled; ISO weeks always start on Monday.
+func parseDateRange(expression string, today time.Time) (dateRange, error) {
+	location := today.Location()
+	expression = strings.ToLower(strings.TrimSpace(expression))
+
+	if date, parseError := time.ParseInLocation(exportDateFormat, expression, location); parseError == nil {
+		return dateRange{start: date, end: date}, nil
+	}
+
+	if matches := isoWeekPattern.FindStringSubmatch(strings.ToUpper(expression)); matches != nil {
+		year, _ := strconv.Atoi(matches[1])
+		week, _ := strconv.Atoi(matches[2])
+
+		monday, weekError := getISOWeekStart(year, week)
+		if weekError != nil {
+			return dateRange{}, weekError
+		}
+
+		return newNamedDateRange(monday, monday.AddDate(0, 0, 6), location), nil
+	}
+
+	// calculate the ranges on the calendar day in UTC so that
+	// daylight saving time changes don't shift the day boundaries
+	day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	calendar := now.New(day)
+
+	if matches := lastDaysPattern.FindStringSubmatch(expression); matches != nil {
+		days, _ := strconv.Atoi(matches[1])
+		if days < 1 {
+			return dateRange{}, fmt.Errorf("The date range %q must contain at least one day", expression)
+		}
+
+		return newNamedDateRange(day.AddDate(0, 0, -days+1), day, location), nil
+	}
+
+	switch expression {
+	case dateRangeToday:
+		return newNamedDateRange(day, day, location), nil
+
+	case dateRangeYesterday:
+		yesterday := day.AddDate(0, 0, -1)
+		return newNamedDateRange(yesterday, yesterday, location), nil
+
+	case dateRangeThisWeek:
+		return newNamedDateRange(calendar.BeginningOfWeek(), calendar.EndOfWeek(), location), nil
+
+	case dateRangeLastWeek:
+		lastWeek := now.New(calendar.BeginningOfWeek().AddDate(0, 0, -7))
+		return newNamedDateRange(lastWeek.BeginningOfWeek(), lastWeek.EndOfWeek(), location), nil
+
+	case dateRangeThisMonth:
+		return newNamedDateRange(calendar.BeginningOfMonth(), calendar.EndOfMonth(), location), nil
+
+	case dateRangeLastMonth:
+		lastMonth := now.New(calendar.BeginningOfMonth().AddDate(0, -1, 0))
+		return newNamedDateRange(lastMonth.BeginningOfMonth(), lastMonth.EndOfMonth(), location), nil
+
+	case dateRangeThisQuarter:
+		return newNamedDateRange(calendar.BeginningOfQuarter(), calendar.EndOfQuarter(), location), nil
+
+	case dateRangeLastQuarter:
+		lastQuarter := now.New(calendar.BeginningOfQuarter().AddDate(0, -3, 0))
+		return newNamedDateRange(lastQuarter.BeginningOfQuarter(), lastQuarter.EndOfQuarter(), location), nil
+
+	case dateRangeThisYear:
+		return newNamedDateRange(calendar.BeginningOfYear(), calendar.EndOfYear(), location), nil
+
+	case dateRangeLastYear:
+		lastYear := now.New(calendar.BeginningOfYear().AddDate(-1, 0, 0))
+		return newNamedDateRange(lastYear.BeginningOfYear(), lastYear.EndOfYear(), location), nil
+
+	case dateRangeYearToDate:
+		return newNamedDateRange(calendar.BeginningOfYear(), day, location), nil
+	}
+
+	return dateRange{}, fmt.Errorf("Unknown date %q. Use a date such as 2006-01-26, a week such as 2016-W32, the last days such as last-7d or one of: %s", expression, strings.Join(dateRangeNames, ", "))
+}
+
+// newNamedDateRange returns a named date range from the day of the given start date
+// until the day of the given end date in the given time zone.
+func newNamedDateRange(start, end time.Time, location *time.Location) dateRange {
+	return dateRange{
+		start: time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location),
+		end:   time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, location),
+		named: true,
+	}
+}
+
+// getISOWeekStart returns the Monday of the given ISO 8601 week (in UTC).
+// Returns an error if the year doesn't have the given week.
+func getISOWeekStart(year, week int) (time.Time, error) {
+
+	// the 4th of January is always in the first week
+	januaryFourth := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	daysSinceMonday := (int(januaryFourth.Weekday()) + 6) % 7
+	monday := januaryFourth.AddDate(0, 0, -daysSinceMonday+(week-1)*7)
+
+	if isoYear, isoWeek := monday.ISOWeek(); week < 1 || isoYear != year || isoWeek != week {
+		return time.Time{}, fmt.Errorf("The year %d has no week %d", year, week)
+	}
+
+	return monday, nil
+}
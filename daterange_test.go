@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/now"
+)
+
+func Test_parseDateRange_NamedRanges_FirstAndLastDayAreReturned(t *testing.T) {
+	// arrange
+	today := time.Date(2016, 8, 17, 15, 30, 0, 0, time.UTC) // Wednesday
+
+	inputs := map[string][2]string{
+		"2016-08-01":   {"2016-08-01", "2016-08-01"},
+		"today":        {"2016-08-17", "2016-08-17"},
+		"yesterday":    {"2016-08-16", "2016-08-16"},
+		"this-week":    {"2016-08-15", "2016-08-21"},
+		"last-week":    {"2016-08-08", "2016-08-14"},
+		"this-month":   {"2016-08-01", "2016-08-31"},
+		"last-month":   {"2016-07-01", "2016-07-31"},
+		"this-quarter": {"2016-07-01", "2016-09-30"},
+		"last-quarter": {"2016-04-01", "2016-06-30"},
+		"this-year":    {"2016-01-01", "2016-12-31"},
+		"last-year":    {"2015-01-01", "2015-12-31"},
+		"ytd":          {"2016-01-01", "2016-08-17"},
+		"last-7d":      {"2016-08-11", "2016-08-17"},
+		"Last-Month":   {"2016-07-01", "2016-07-31"},
+		"2016-W32":     {"2016-08-08", "2016-08-14"},
+		"2015-W53":     {"2015-12-28", "2016-01-03"},
+		"2016-W01":     {"2016-01-04", "2016-01-10"},
+	}
+
+	for expression, expected := range inputs {
+		// act
+		result, err := parseDateRange(expression, today)
+
+		// assert
+		if err != nil {
+			t.Fail()
+			t.Logf("parseDateRange(%q) returned an error: %s", expression, err)
+			continue
+		}
+
+		start := result.start.Format(exportDateFormat)
+		end := result.end.Format(exportDateFormat)
+		if start != expected[0] || end != expected[1] {
+			t.Fail()
+			t.Logf("parseDateRange(%q) should have returned %s - %s but returned %s - %s", expression, expected[0], expected[1], start, end)
+		}
+	}
+}
+
+func Test_parseDateRange_SingleDate_RangeIsNotNamed(t *testing.T) {
+	// arrange
+	today := time.Date(2016, 8, 17, 15, 30, 0, 0, time.UTC)
+
+	// act
+	dateResult, _ := parseDateRange("2016-08-01", today)
+	namedResult, _ := parseDateRange("last-month", today)
+
+	// assert
+	if dateResult.named || !namedResult.named {
+		t.Fail()
+		t.Logf("parseDateRange should only mark named ranges as named")
+	}
+}
+
+func Test_parseDateRange_FirstDayMondayDisabled_WeekStartsOnSunday(t *testing.T) {
+	// arrange
+	now.FirstDayMonday = false
+	defer func() { now.FirstDayMonday = true }()
+
+	today := time.Date(2016, 8, 17, 15, 30, 0, 0, time.UTC) // Wednesday
+
+	// act
+	result, err := parseDateRange("this-week", today)
+
+	// assert
+	if err != nil || result.start.Format(exportDateFormat) != "2016-08-14" || result.end.Format(exportDateFormat) != "2016-08-20" {
+		t.Fail()
+		t.Logf("parseDateRange should have returned the week from Sunday to Saturday but returned %s - %s (%v)", result.start, result.end, err)
+	}
+}
+
+func Test_parseDateRange_Timezone_DaysBeginAtLocalMidnight(t *testing.T) {
+	// arrange
+	location, _ := time.LoadLocation("Asia/Kolkata")
+	today := time.Date(2016, 8, 1, 0, 30, 0, 0, location)
+
+	// act
+	result, err := parseDateRange("last-month", today)
+
+	// assert
+	expectedStart := time.Date(2016, 7, 1, 0, 0, 0, 0, location)
+	expectedEnd := time.Date(2016, 7, 31, 0, 0, 0, 0, location)
+	if err != nil || !result.start.Equal(expectedStart) || !result.end.Equal(expectedEnd) {
+		t.Fail()
+		t.Logf("parseDateRange should have returned %s - %s but returned %s - %s (%v)", expectedStart, expectedEnd, result.start, result.end, err)
+	}
+}
+
+func Test_parseDateRange_InvalidExpressions_ErrorIsReturned(t *testing.T) {
+	// arrange
+	today := time.Date(2016, 8, 17, 15, 30, 0, 0, time.UTC)
+	inputs := []string{
+		"",
+		"next-month",
+		"last-0d",
+		"2016-W00",
+		"2016-W53",
+		"2016-13-01",
+	}
+
+	for _, expression := range inputs {
+		// act
+		_, err := parseDateRange(expression, today)
+
+		// assert
+		if err == nil {
+			t.Fail()
+			t.Logf("parseDateRange(%q) should have returned an error", expression)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// timeRecordIdentity contains the attributes that are compared
+// in order to decide whether two time records are duplicates.
+type timeRecordIdentity struct {
+	start         int64
+	stop          int64
+	workspaceName string
+	projectName   string
+	clientName    string
+	description   string
+}
+
+// getTimeRecordIdentity returns the identity of the given time record.
+func getTimeRecordIdentity(timeRecord toggl.TimeRecord) timeRecordIdentity {
+	return timeRecordIdentity{
+		start:         timeRecord.Start.Unix(),
+		stop:          timeRecord.Stop.Unix(),
+		workspaceName: timeRecord.WorkspaceName,
+		projectName:   timeRecord.ProjectName,
+		clientName:    timeRecord.ClientName,
+		description:   timeRecord.Description,
+	}
+}
+
+// getTimeSpan returns the earliest start and the latest stop date of the given time records.
+// Running time records count with their start date.
+func getTimeSpan(timeRecords []toggl.TimeRecord) (start, stop time.Time) {
+	for index, timeRecord := range timeRecords {
+		if index == 0 || timeRecord.Start.Before(start) {
+			start = timeRecord.Start
+		}
+
+		end := timeRecord.Stop
+		if timeRecord.IsRunning() {
+			end = timeRecord.Start
+		}
+
+		if index == 0 || end.After(stop) {
+			stop = end
+		}
+	}
+
+	return start, stop
+}
+
+// removeDuplicates returns all given time records that are not contained in the list of existing time records
+// and the number of time records that have been removed.
+func removeDuplicates(timeRecords, existingTimeRecords []toggl.TimeRecord) ([]toggl.TimeRecord, int) {
+	existing := make(map[timeRecordIdentity]bool)
+	for _, existingTimeRecord := range existingTimeRecords {
+		existing[getTimeRecordIdentity(existingTimeRecord)] = true
+	}
+
+	var newTimeRecords []toggl.TimeRecord
+	for _, timeRecord := range timeRecords {
+		if existing[getTimeRecordIdentity(timeRecord)] {
+			continue
+		}
+
+		newTimeRecords = append(newTimeRecords, timeRecord)
+	}
+
+	return newTimeRecords, len(timeRecords) - len(newTimeRecords)
+}
+
+// newExistingTimeRecordIndex creates an index of the time records that exist in Toggl.
+// The existing time records are loaded month by month when a time record of that month is looked up.
+func newExistingTimeRecordIndex(repository toggl.TimeRecorder) *existingTimeRecordIndex {
+	return &existingTimeRecordIndex{
+		repository:   repository,
+		loadedMonths: make(map[time.Time]bool),
+		identities:   make(map[timeRecordIdentity]bool),
+	}
+}
+
+// existingTimeRecordIndex checks whether time records exist in Toggl already.
+type existingTimeRecordIndex struct {
+	repository toggl.TimeRecorder
+
+	// loadedMonths contains the first day (UTC) of all months whose time records have been loaded
+	loadedMonths map[time.Time]bool
+
+	identities map[timeRecordIdentity]bool
+}
+
+// Contains returns true if the given time record exists in Toggl.
+func (index *existingTimeRecordIndex) Contains(timeRecord toggl.TimeRecord) (bool, error) {
+	start := timeRecord.Start.UTC()
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	if !index.loadedMonths[month] {
+
+		// extend the month by one day in each direction because
+		// the time ranges are normalized to full days in UTC
+		existingTimeRecords, err := index.repository.GetTimeRecords(month.AddDate(0, 0, -1), month.AddDate(0, 1, 1))
+		if err != nil {
+			return false, err
+		}
+
+		for _, existingTimeRecord := range existingTimeRecords {
+			index.identities[getTimeRecordIdentity(existingTimeRecord)] = true
+		}
+
+		index.loadedMonths[month] = true
+	}
+
+	return index.identities[getTimeRecordIdentity(timeRecord)], nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func Test_getTimeSpan_EarliestStartAndLatestStopAreReturned(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{
+			Start: time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+			Stop:  time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC),
+		},
+		toggl.TimeRecord{
+			Start: time.Date(2016, 7, 1, 9, 0, 0, 0, time.UTC),
+			Stop:  time.Date(2016, 7, 1, 10, 0, 0, 0, time.UTC),
+		},
+		toggl.TimeRecord{
+			Start: time.Date(2016, 9, 30, 9, 0, 0, 0, time.UTC),
+			Stop:  time.Date(2016, 9, 30, 23, 0, 0, 0, time.UTC),
+		},
+	}
+
+	// act
+	start, stop := getTimeSpan(timeRecords)
+
+	// assert
+	expectedStart := time.Date(2016, 7, 1, 9, 0, 0, 0, time.UTC)
+	expectedStop := time.Date(2016, 9, 30, 23, 0, 0, 0, time.UTC)
+	if !start.Equal(expectedStart) || !stop.Equal(expectedStop) {
+		t.Fail()
+		t.Logf("getTimeSpan should have returned %s - %s but returned %s - %s", expectedStart, expectedStop, start, stop)
+	}
+}
+
+func Test_removeDuplicates_MatchingRecordsAreRemoved(t *testing.T) {
+	// arrange
+	berlin := time.FixedZone("CEST", 2*60*60)
+
+	existingTimeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{
+			Start:         time.Date(2016, 8, 12, 7, 0, 0, 0, time.UTC),
+			Stop:          time.Date(2016, 8, 12, 8, 0, 0, 0, time.UTC),
+			WorkspaceName: "Workspace",
+			ProjectName:   "Project",
+			ClientName:    "Client",
+			Description:   "Retrospective",
+		},
+	}
+
+	timeRecords := []toggl.TimeRecord{
+		// same entry in a different time zone
+		toggl.TimeRecord{
+			Start:         time.Date(2016, 8, 12, 9, 0, 0, 0, berlin),
+			Stop:          time.Date(2016, 8, 12, 10, 0, 0, 0, berlin),
+			WorkspaceName: "Workspace",
+			ProjectName:   "Project",
+			ClientName:    "Client",
+			Description:   "Retrospective",
+		},
+
+		// different description
+		toggl.TimeRecord{
+			Start:         time.Date(2016, 8, 12, 7, 0, 0, 0, time.UTC),
+			Stop:          time.Date(2016, 8, 12, 8, 0, 0, 0, time.UTC),
+			WorkspaceName: "Workspace",
+			ProjectName:   "Project",
+			ClientName:    "Client",
+			Description:   "Sprint Review",
+		},
+	}
+
+	// act
+	newTimeRecords, duplicates := removeDuplicates(timeRecords, existingTimeRecords)
+
+	// assert
+	if duplicates != 1 {
+		t.Fail()
+		t.Logf("removeDuplicates should have found one duplicate but found %d", duplicates)
+	}
+
+	if len(newTimeRecords) != 1 || newTimeRecords[0].Description != "Sprint Review" {
+		t.Fail()
+		t.Logf("removeDuplicates should have returned only the new time record but returned: %#v", newTimeRecords)
+	}
+}
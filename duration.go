@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The formats of the "Duration" column.
+const (
+	// durationFormatClock writes durations as hours, minutes and seconds (e.g. "1:30:00")
+	durationFormatClock = "clock"
+
+	// durationFormatHours writes durations as decimal hours (e.g. "1.5h")
+	durationFormatHours = "hours"
+
+	// durationFormatMinutes writes durations as decimal minutes (e.g. "90m")
+	durationFormatMinutes = "minutes"
+)
+
+// durationFormats contains all available duration formats.
+var durationFormats = []string{durationFormatClock, durationFormatHours, durationFormatMinutes}
+
+// durationTolerance defines how much a duration may differ from the time between start and stop
+// because the hours and minutes formats are rounded to two decimal places.
+const durationTolerance = time.Minute
+
+// parseDuration parses the value of the "Duration" column.
+// Accepts h:mm, h:mm:ss and Go duration strings such as "90m", "1.5h" or "1h30m".
+func parseDuration(value string) (time.Duration, error) {
+	var duration time.Duration
+	var err error
+	if strings.Contains(value, ":") {
+		duration, err = parseClockDuration(value)
+	} else {
+		duration, err = time.ParseDuration(value)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("Cannot parse the duration %q. Use h:mm, h:mm:ss, 90m or 1.5h", value)
+	}
+
+	if duration < 0 {
+		return 0, fmt.Errorf("The duration %q is negative", value)
+	}
+
+	return duration, nil
+}
+
+// parseClockDuration parses durations in the format h:mm or h:mm:ss.
+func parseClockDuration(value string) (time.Duration, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("Invalid clock duration %q", value)
+	}
+
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+
+	var duration time.Duration
+	for index, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 || (index > 0 && (number > 59 || len(part) != 2)) {
+			return 0, fmt.Errorf("Invalid clock duration %q", value)
+		}
+
+		duration += time.Duration(number) * units[index]
+	}
+
+	return duration, nil
+}
+
+// formatDuration returns the value of the "Duration" column for the given duration in the given format.
+func formatDuration(duration time.Duration, format string) string {
+	switch format {
+	case durationFormatHours:
+		return formatDecimal(duration.Hours()) + "h"
+
+	case durationFormatMinutes:
+		return formatDecimal(duration.Minutes()) + "m"
+	}
+
+	seconds := int64(duration / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// formatDecimal returns the given number rounded to two decimal places without trailing zeros.
+func formatDecimal(number float64) string {
+	return strconv.FormatFloat(math.Round(number*100)/100, 'f', -1, 64)
+}
+
+// durationMatches returns true if the given durations differ by no more than the durationTolerance.
+func durationMatches(actual, expected time.Duration) bool {
+	difference := actual - expected
+	if difference < 0 {
+		difference = -difference
+	}
+
+	return difference <= durationTolerance
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_parseDuration_ValidValues_DurationIsReturned(t *testing.T) {
+	// arrange
+	inputs := map[string]time.Duration{
+		"1:30":    90 * time.Minute,
+		"0:05:30": 5*time.Minute + 30*time.Second,
+		"90m":     90 * time.Minute,
+		"1.5h":    90 * time.Minute,
+		"1h30m":   90 * time.Minute,
+	}
+
+	for value, expected := range inputs {
+		// act
+		duration, err := parseDuration(value)
+
+		// assert
+		if err != nil || duration != expected {
+			t.Fail()
+			t.Logf("parseDuration(%q) should have returned %s but returned %s (%v)", value, expected, duration, err)
+		}
+	}
+}
+
+func Test_parseDuration_InvalidValues_ErrorIsReturned(t *testing.T) {
+	// arrange
+	inputs := []string{"1:5", "1:60", "1:30:00:00", "-1h", "1.5", "an hour"}
+
+	for _, value := range inputs {
+		// act
+		_, err := parseDuration(value)
+
+		// assert
+		if err == nil {
+			t.Fail()
+			t.Logf("parseDuration(%q) should have returned an error", value)
+		}
+	}
+}
+
+func Test_formatDuration_AllFormats_DurationIsFormatted(t *testing.T) {
+	// arrange
+	duration := time.Hour + 20*time.Minute + 30*time.Second
+	expected := map[string]string{
+		durationFormatClock:   "1:20:30",
+		durationFormatHours:   "1.34h",
+		durationFormatMinutes: "80.5m",
+	}
+
+	for format, expectedValue := range expected {
+		// act
+		value := formatDuration(duration, format)
+
+		// assert
+		if value != expectedValue {
+			t.Fail()
+			t.Logf("formatDuration(%s, %q) should have returned %q but returned %q", duration, format, expectedValue, value)
+		}
+	}
+}
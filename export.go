@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/csv"
 	"fmt"
 	"io"
 	"time"
@@ -19,29 +18,179 @@ type CSVExporter interface {
 type TogglCSVExporter struct {
 	csvMapper            TimeRecordMapper
 	timeRecordRepository toggl.TimeRecorder
+
+	// format selects the output format (csv, json, ndjson, xlsx or ics; default: csv)
+	// and jsonMapper converts the time records for the JSON formats
+	format     string
+	jsonMapper *JSONTimeRecordMapper
+
+	// messageOutput receives warnings and summaries (optional);
+	// it must differ from the CSV output so that the CSV stays valid
+	messageOutput io.Writer
+
+	// includeRunning exports running time records with an empty stop date
+	includeRunning bool
+
+	// transformers modify the time records before they are exported
+	transformers []TimeRecordTransformer
+
+	// splitPeriod cuts time records at the boundaries of the given period (optional)
+	splitPeriod string
+
+	// location defines the time zone of the split periods (optional)
+	location *time.Location
+
+	// merge combines consecutive time records with identical attributes
+	// whose gap is not larger than maxMergeGap
+	merge       bool
+	maxMergeGap time.Duration
+
+	// filter selects the exported time records (optional)
+	filter *ExportFilter
+}
+
+// ExportOptions contains the settings of an export.
+type ExportOptions struct {
+	// Format selects the output format (csv, json, ndjson, xlsx or ics; default: csv).
+	Format string
+
+	// IncludeRunning exports the running time record with an empty stop date
+	IncludeRunning bool
+
+	// DurationFormat adds a "Duration" column in the given format (clock, hours or minutes; empty for none)
+	DurationFormat string
+
+	// Billable adds a "Billable" column to the CSV and xlsx output
+	Billable bool
+
+	// Location defines the time zone the dates are written in (optional)
+	Location *time.Location
+
+	// Transformers modify the time records in the given order before they are exported.
+	Transformers []TimeRecordTransformer
+
+	// SplitAt cuts time records at the boundaries of the given period (day, week or month; empty for none).
+	SplitAt string
+
+	// Merge combines consecutive time records with identical attributes
+	// whose gap is not larger than MaxMergeGap.
+	Merge       bool
+	MaxMergeGap time.Duration
+
+	// Filter selects the exported time records (optional).
+	Filter *ExportFilter
 }
 
-// Export prints all time records from the given start date as CSV.
+// Export prints all time records from the given start date as CSV, JSON or NDJSON.
 func (exporter *TogglCSVExporter) Export(startDate, endDate time.Time, writer io.Writer) error {
 
-	csvWriter := csv.NewWriter(writer)
-	defer csvWriter.Flush()
+	recordWriter := exporter.newTimeRecordWriter(writer)
 
-	// write the header
-	csvWriter.Write(exporter.csvMapper.GetColumnNames())
-	csvWriter.Flush()
+	// also fetch the day before the start date so that time records
+	// reaching into the first day are split and exported as well.
+	// The padding depends on how long a time record can be, not on the split period:
+	// a time record crossing the start date started at most its own duration before it,
+	// and one day covers every time record shorter than 24 hours.
+	fetchStartDate := startDate
+	if exporter.splitPeriod != "" {
+		fetchStartDate = startDate.AddDate(0, 0, -1)
+	}
 
-	records, timeRecordsError := exporter.timeRecordRepository.GetTimeRecords(startDate, endDate)
+	records, timeRecordsError := exporter.timeRecordRepository.GetTimeRecords(fetchStartDate, endDate)
 	if timeRecordsError != nil {
 		return fmt.Errorf("Failed to retrieve time records between %q and %q: %s", startDate, endDate, timeRecordsError.Error())
 	}
 
-	// write the records one-by-one
+	var exportRecords []toggl.TimeRecord
 	for _, record := range records {
-		row := exporter.csvMapper.GetRow(record)
-		csvWriter.Write(row)
-		csvWriter.Flush()
+		if record.IsRunning() && !exporter.includeRunning {
+			continue
+		}
+
+		if exporter.filter != nil && !exporter.filter.Includes(record) {
+			continue
+		}
+
+		exportRecords = append(exportRecords, record)
+	}
+
+	if exporter.merge {
+		var merged int
+		total := len(exportRecords)
+		exportRecords, merged = mergeTimeRecords(exportRecords, exporter.maxMergeGap)
+		printMergeSummary(exporter.messageOutput, merged, total)
+	}
+
+	// write the records one-by-one
+	recordsWithoutProject := 0
+	exported := 0
+	for _, record := range exportRecords {
+		for _, transformer := range exporter.transformers {
+			record = transformer.Transform(record)
+		}
+
+		for _, segment := range splitTimeRecord(record, exporter.splitPeriod, exporter.location) {
+
+			// skip the parts of split time records that are outside the export range
+			if exporter.splitPeriod != "" && !isInDateRange(segment.Start, startDate, endDate) {
+				continue
+			}
+
+			if writeError := recordWriter.Write(segment); writeError != nil {
+				return fmt.Errorf("Failed to write the time records: %s", writeError.Error())
+			}
+
+			exported++
+
+			if segment.ProjectName == "" {
+				recordsWithoutProject++
+			}
+		}
+	}
+
+	if closeError := recordWriter.Close(); closeError != nil {
+		return fmt.Errorf("Failed to write the time records: %s", closeError.Error())
+	}
+
+	if recordsWithoutProject > 0 && exporter.messageOutput != nil {
+		fmt.Fprintf(exporter.messageOutput, "Warning: %d of %d exported time records have no project. Previous versions of %s skipped these time records.\n", recordsWithoutProject, exported, applicationName)
+	}
+
+	if exporter.messageOutput != nil {
+		for _, transformer := range exporter.transformers {
+			if reporter, isReporter := transformer.(TransformationReporter); isReporter {
+				reporter.Report(exporter.messageOutput)
+			}
+		}
 	}
 
 	return nil
 }
+
+// isInDateRange returns true if the given date is between the beginning of the start date's day
+// and the end of the end date's day.
+func isInDateRange(date, startDate, endDate time.Time) bool {
+	rangeStart := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location())
+	rangeEnd := time.Date(endDate.Year(), endDate.Month(), endDate.Day()+1, 0, 0, 0, 0, endDate.Location())
+
+	return !date.Before(rangeStart) && date.Before(rangeEnd)
+}
+
+// newTimeRecordWriter creates a writer for the output format of the exporter.
+func (exporter *TogglCSVExporter) newTimeRecordWriter(writer io.Writer) TimeRecordWriter {
+	switch exporter.format {
+	case formatJSON:
+		return newJSONTimeRecordWriter(writer, exporter.jsonMapper)
+
+	case formatNDJSON:
+		return newNDJSONTimeRecordWriter(writer, exporter.jsonMapper)
+
+	case formatXLSX:
+		return newXLSXTimeRecordWriter(writer, exporter.csvMapper, exporter.location)
+
+	case formatICS:
+		return newICSTimeRecordWriter(writer, time.Now())
+	}
+
+	return newCSVTimeRecordWriter(writer, exporter.csvMapper)
+}
@@ -4,25 +4,28 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/andreaskoch/togglapi/date"
 	"github.com/andreaskoch/togglcsv/toggl"
 )
 
 type mockCSVTimeRecordMapper struct {
-	getTimeRecords func(rows [][]string) ([]toggl.TimeRecord, error)
+	getTimeRecords func(rows [][]string, lines []int) ([]toggl.TimeRecord, error)
 	getTimeRecord  func(row []string) (toggl.TimeRecord, error)
 	columnNames    []string
 	getRow         func(timeRecord toggl.TimeRecord) []string
+	withHeadline   func(headline []string) (TimeRecordMapper, error)
 }
 
-func (mapper *mockCSVTimeRecordMapper) GetTimeRecords(rows [][]string) ([]toggl.TimeRecord, error) {
-	return mapper.getTimeRecords(rows)
+func (mapper *mockCSVTimeRecordMapper) GetTimeRecords(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+	return mapper.getTimeRecords(rows, lines)
 }
 
 func (mapper *mockCSVTimeRecordMapper) GetTimeRecord(row []string) (toggl.TimeRecord, error) {
-	return mapper.GetTimeRecord(row)
+	return mapper.getTimeRecord(row)
 }
 
 func (mapper *mockCSVTimeRecordMapper) GetColumnNames() []string {
@@ -33,12 +36,24 @@ func (mapper *mockCSVTimeRecordMapper) GetRow(timeRecord toggl.TimeRecord) []str
 	return mapper.getRow(timeRecord)
 }
 
+func (mapper *mockCSVTimeRecordMapper) WithHeadline(headline []string) (TimeRecordMapper, error) {
+	if mapper.withHeadline == nil {
+		return mapper, nil
+	}
+
+	return mapper.withHeadline(headline)
+}
+
+func (mapper *mockCSVTimeRecordMapper) WithBillableColumn() TimeRecordMapper {
+	return mapper
+}
+
 type mockTimeRecordRepository struct {
-	createTimeRecord func(timeRecord toggl.TimeRecord) error
+	createTimeRecord func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error)
 	getTimeRecords   func(start, stop time.Time) ([]toggl.TimeRecord, error)
 }
 
-func (repository *mockTimeRecordRepository) CreateTimeRecord(timeRecord toggl.TimeRecord) error {
+func (repository *mockTimeRecordRepository) CreateTimeRecord(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
 	return repository.createTimeRecord(timeRecord)
 }
 
@@ -46,6 +61,14 @@ func (repository *mockTimeRecordRepository) GetTimeRecords(start, stop time.Time
 	return repository.getTimeRecords(start, stop)
 }
 
+// getStoppedTestTimeRecord returns a time record that is not running.
+func getStoppedTestTimeRecord() toggl.TimeRecord {
+	return toggl.TimeRecord{
+		Start: time.Date(2016, 8, 2, 9, 0, 0, 0, time.UTC),
+		Stop:  time.Date(2016, 8, 2, 10, 0, 0, 0, time.UTC),
+	}
+}
+
 func Test_Export_NoTimeRecordsReturned_OnlyTheCSVHeaderIsWritten(t *testing.T) {
 	// arrange
 	timeRecordMapper := &mockCSVTimeRecordMapper{
@@ -126,7 +149,7 @@ func Test_Export_OneTimeRecordReturned_HeaderAndRowIsWritten(t *testing.T) {
 	}
 
 	timeRecords := []toggl.TimeRecord{
-		toggl.TimeRecord{},
+		getStoppedTestTimeRecord(),
 	}
 
 	timeRecordRepository := &mockTimeRecordRepository{
@@ -169,9 +192,9 @@ func Test_Export_MultipleTimeRecordReturned_MultipleRowsAreWritten(t *testing.T)
 	}
 
 	timeRecords := []toggl.TimeRecord{
-		toggl.TimeRecord{},
-		toggl.TimeRecord{},
-		toggl.TimeRecord{},
+		getStoppedTestTimeRecord(),
+		getStoppedTestTimeRecord(),
+		getStoppedTestTimeRecord(),
 	}
 
 	timeRecordRepository := &mockTimeRecordRepository{
@@ -201,3 +224,350 @@ func Test_Export_MultipleTimeRecordReturned_MultipleRowsAreWritten(t *testing.T)
 		t.Logf("The output of the Export function should have been '%s' but was '%s' instead", expected, result)
 	}
 }
+
+func Test_Export_TimeRecordsWithoutProject_RecordsAreWrittenAndWarningIsPrinted(t *testing.T) {
+	// arrange
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Col 1", "Col 2", "Col 3"},
+		getRow: func(timeRecord toggl.TimeRecord) []string {
+			return []string{timeRecord.Description}
+		},
+	}
+
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{Stop: time.Date(2016, 8, 3, 0, 0, 0, 0, time.UTC), ProjectName: "Project", Description: "With project"},
+		toggl.TimeRecord{Stop: time.Date(2016, 8, 3, 0, 0, 0, 0, time.UTC), Description: "Without project"},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return timeRecords, nil
+		},
+	}
+
+	var messageBuffer bytes.Buffer
+	exporter := TogglCSVExporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		messageOutput:        &messageBuffer,
+	}
+
+	startDate := time.Date(2016, 5, 3, 0, 0, 1, 0, time.UTC)
+	endDate := time.Date(2016, 8, 3, 0, 0, 1, 0, time.UTC)
+	var outputBuffer bytes.Buffer
+
+	// act
+	exporter.Export(startDate, endDate, &outputBuffer)
+
+	// assert
+	if !strings.Contains(outputBuffer.String(), "Without project") {
+		t.Fail()
+		t.Logf("Export should have written the time record without project but wrote: %s", outputBuffer.String())
+	}
+
+	if !strings.Contains(messageBuffer.String(), "1 of 2 exported time records have no project") {
+		t.Fail()
+		t.Logf("Export should have printed a warning about the time records without project but printed: %s", messageBuffer.String())
+	}
+}
+
+func Test_Export_RunningTimeRecord_RecordIsSkipped(t *testing.T) {
+	// arrange
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Col 1", "Col 2", "Col 3"},
+		getRow: func(timeRecord toggl.TimeRecord) []string {
+			return []string{timeRecord.Description}
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return []toggl.TimeRecord{
+				toggl.TimeRecord{Start: time.Date(2016, 8, 2, 9, 0, 0, 0, time.UTC), Description: "Running"},
+			}, nil
+		},
+	}
+
+	exporter := TogglCSVExporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+	}
+
+	startDate := time.Date(2016, 5, 3, 0, 0, 1, 0, time.UTC)
+	endDate := time.Date(2016, 8, 3, 0, 0, 1, 0, time.UTC)
+	var outputBuffer bytes.Buffer
+
+	// act
+	exporter.Export(startDate, endDate, &outputBuffer)
+
+	// assert
+	if strings.Contains(outputBuffer.String(), "Running") {
+		t.Fail()
+		t.Logf("Export should not have written the running time record but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_Export_RunningTimeRecordAndIncludeRunning_RecordIsWritten(t *testing.T) {
+	// arrange
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Col 1", "Col 2", "Col 3"},
+		getRow: func(timeRecord toggl.TimeRecord) []string {
+			return []string{timeRecord.Description}
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return []toggl.TimeRecord{
+				toggl.TimeRecord{Start: time.Date(2016, 8, 2, 9, 0, 0, 0, time.UTC), Description: "Running"},
+			}, nil
+		},
+	}
+
+	exporter := TogglCSVExporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		includeRunning:       true,
+	}
+
+	startDate := time.Date(2016, 5, 3, 0, 0, 1, 0, time.UTC)
+	endDate := time.Date(2016, 8, 3, 0, 0, 1, 0, time.UTC)
+	var outputBuffer bytes.Buffer
+
+	// act
+	exporter.Export(startDate, endDate, &outputBuffer)
+
+	// assert
+	if !strings.Contains(outputBuffer.String(), "Running") {
+		t.Fail()
+		t.Logf("Export should have written the running time record but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_Export_Rounding_RoundedRecordIsWrittenAndReportIsPrinted(t *testing.T) {
+	// arrange
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Col 1", "Col 2", "Col 3"},
+		getRow: func(timeRecord toggl.TimeRecord) []string {
+			return []string{timeRecord.Stop.Format("15:04")}
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return []toggl.TimeRecord{
+				toggl.TimeRecord{
+					Start: time.Date(2016, 8, 2, 9, 0, 0, 0, time.UTC),
+					Stop:  time.Date(2016, 8, 2, 9, 10, 0, 0, time.UTC),
+				},
+			}, nil
+		},
+	}
+
+	rounder, _ := readTimeRecordRounder(strings.NewReader(`,,15m,up`))
+
+	var messageBuffer bytes.Buffer
+	exporter := TogglCSVExporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		messageOutput:        &messageBuffer,
+		transformers:         []TimeRecordTransformer{rounder},
+	}
+
+	startDate := time.Date(2016, 5, 3, 0, 0, 1, 0, time.UTC)
+	endDate := time.Date(2016, 8, 3, 0, 0, 1, 0, time.UTC)
+	var outputBuffer bytes.Buffer
+
+	// act
+	exporter.Export(startDate, endDate, &outputBuffer)
+
+	// assert
+	if !strings.Contains(outputBuffer.String(), "09:15") {
+		t.Fail()
+		t.Logf("Export should have written the rounded stop date but wrote: %s", outputBuffer.String())
+	}
+
+	if !strings.Contains(messageBuffer.String(), "Rounding added 5m0s") {
+		t.Fail()
+		t.Logf("Export should have printed the rounding report but printed: %s", messageBuffer.String())
+	}
+}
+
+func Test_Export_SplitAtDay_SegmentsInsideTheRangeAreWritten(t *testing.T) {
+	// arrange
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Col 1", "Col 2", "Col 3"},
+		getRow: func(timeRecord toggl.TimeRecord) []string {
+			return []string{timeRecord.Start.Format("2006-01-02 15:04")}
+		},
+	}
+
+	var fetchStartDate time.Time
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			fetchStartDate = start
+			return []toggl.TimeRecord{
+				toggl.TimeRecord{
+					Start: time.Date(2016, 8, 1, 22, 0, 0, 0, time.UTC),
+					Stop:  time.Date(2016, 8, 2, 2, 0, 0, 0, time.UTC),
+				},
+				toggl.TimeRecord{
+					Start: time.Date(2016, 8, 2, 22, 0, 0, 0, time.UTC),
+					Stop:  time.Date(2016, 8, 3, 2, 0, 0, 0, time.UTC),
+				},
+			}, nil
+		},
+	}
+
+	exporter := TogglCSVExporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		splitPeriod:          splitPeriodDay,
+	}
+
+	startDate := time.Date(2016, 8, 2, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2016, 8, 2, 0, 0, 0, 0, time.UTC)
+	var outputBuffer bytes.Buffer
+
+	// act
+	exporter.Export(startDate, endDate, &outputBuffer)
+
+	// assert
+	expected := "Col 1,Col 2,Col 3\n2016-08-02 00:00\n2016-08-02 22:00\n"
+	if outputBuffer.String() != expected {
+		t.Fail()
+		t.Logf("Export should have written the segments of the 2nd of August (%q) but wrote %q", expected, outputBuffer.String())
+	}
+
+	if !fetchStartDate.Equal(startDate.AddDate(0, 0, -1)) {
+		t.Fail()
+		t.Logf("Export should have fetched the time records from the day before the start date but fetched from %s", fetchStartDate)
+	}
+}
+
+func Test_Export_Merge_AdjacentRecordsAreMergedAndSummaryIsPrinted(t *testing.T) {
+	// arrange
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Col 1", "Col 2", "Col 3"},
+		getRow: func(timeRecord toggl.TimeRecord) []string {
+			return []string{timeRecord.Start.Format("15:04") + "-" + timeRecord.Stop.Format("15:04")}
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return []toggl.TimeRecord{
+				getAdjacentTestTimeRecord(9, 0, 9, 30, "Coding"),
+				getAdjacentTestTimeRecord(9, 30, 10, 0, "Coding"),
+			}, nil
+		},
+	}
+
+	var messageBuffer bytes.Buffer
+	exporter := TogglCSVExporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		messageOutput:        &messageBuffer,
+		merge:                true,
+		maxMergeGap:          time.Minute,
+	}
+
+	startDate := time.Date(2016, 5, 3, 0, 0, 1, 0, time.UTC)
+	endDate := time.Date(2016, 8, 3, 0, 0, 1, 0, time.UTC)
+	var outputBuffer bytes.Buffer
+
+	// act
+	exporter.Export(startDate, endDate, &outputBuffer)
+
+	// assert
+	if outputBuffer.String() != "Col 1,Col 2,Col 3\n09:00-10:00\n" {
+		t.Fail()
+		t.Logf("Export should have written one merged time record but wrote: %q", outputBuffer.String())
+	}
+
+	if !strings.Contains(messageBuffer.String(), "Merged 1 of 2 time records") {
+		t.Fail()
+		t.Logf("Export should have printed the number of merged time records but printed: %s", messageBuffer.String())
+	}
+}
+
+func Test_Export_Filter_OnlyIncludedRecordsAreWritten(t *testing.T) {
+	// arrange
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Col 1", "Col 2", "Col 3"},
+		getRow: func(timeRecord toggl.TimeRecord) []string {
+			return []string{timeRecord.ProjectName}
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			included := getStoppedTestTimeRecord()
+			included.ProjectName = "Website"
+
+			excluded := getStoppedTestTimeRecord()
+			excluded.ProjectName = "Marketing"
+
+			return []toggl.TimeRecord{included, excluded}, nil
+		},
+	}
+
+	filter, _ := compileExportFilter(ExportFilterPatterns{
+		Projects: []string{"Web*"},
+	})
+
+	exporter := TogglCSVExporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		filter:               filter,
+	}
+
+	startDate := time.Date(2016, 5, 3, 0, 0, 1, 0, time.UTC)
+	endDate := time.Date(2016, 8, 3, 0, 0, 1, 0, time.UTC)
+	var outputBuffer bytes.Buffer
+
+	// act
+	exporter.Export(startDate, endDate, &outputBuffer)
+
+	// assert
+	if !strings.Contains(outputBuffer.String(), "Website") || strings.Contains(outputBuffer.String(), "Marketing") {
+		t.Fail()
+		t.Logf("Export should only have written the time record of the project Website but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_Export_FormatNDJSON_OneJSONObjectPerLineIsWritten(t *testing.T) {
+	// arrange
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			first := getStoppedTestTimeRecord()
+			first.Description = "Record 1"
+
+			second := getStoppedTestTimeRecord()
+			second.Description = "Record 2"
+
+			return []toggl.TimeRecord{first, second}, nil
+		},
+	}
+
+	exporter := TogglCSVExporter{
+		csvMapper:            &mockCSVTimeRecordMapper{},
+		timeRecordRepository: timeRecordRepository,
+		format:               formatNDJSON,
+		jsonMapper:           NewJSONTimeRecordMapper(date.NewISO8601Formatter()),
+	}
+
+	startDate := time.Date(2016, 5, 3, 0, 0, 1, 0, time.UTC)
+	endDate := time.Date(2016, 8, 3, 0, 0, 1, 0, time.UTC)
+	var outputBuffer bytes.Buffer
+
+	// act
+	err := exporter.Export(startDate, endDate, &outputBuffer)
+
+	// assert
+	lines := strings.Split(strings.TrimSpace(outputBuffer.String()), "\n")
+	if err != nil || len(lines) != 2 || !strings.Contains(lines[1], `"description":"Record 2"`) {
+		t.Fail()
+		t.Logf("Export should have written two lines of JSON but wrote: %s (%v)", outputBuffer.String(), err)
+	}
+}
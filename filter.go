@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// ExportFilterPatterns contains the patterns that select the exported time records.
+// Names are matched by glob patterns ("Customer *") or by regular expressions enclosed in slashes ("/^Customer [AB]$/").
+type ExportFilterPatterns struct {
+	Workspaces        []string
+	ExcludeWorkspaces []string
+
+	Clients        []string
+	ExcludeClients []string
+
+	Projects        []string
+	ExcludeProjects []string
+
+	Tags        []string
+	ExcludeTags []string
+
+	// Descriptions and ExcludeDescriptions contain regular expressions
+	// that must match a part of the description.
+	Descriptions        []string
+	ExcludeDescriptions []string
+}
+
+// isSet returns true if any pattern is given.
+func (patterns ExportFilterPatterns) isSet() bool {
+	return len(patterns.Workspaces)+len(patterns.ExcludeWorkspaces)+
+		len(patterns.Clients)+len(patterns.ExcludeClients)+
+		len(patterns.Projects)+len(patterns.ExcludeProjects)+
+		len(patterns.Tags)+len(patterns.ExcludeTags)+
+		len(patterns.Descriptions)+len(patterns.ExcludeDescriptions) > 0
+}
+
+// ExportFilter selects the time records that are exported.
+type ExportFilter struct {
+	workspaces   nameFilter
+	clients      nameFilter
+	projects     nameFilter
+	tags         nameFilter
+	descriptions nameFilter
+}
+
+// IncludesWorkspace returns true if the time records of the workspace with the given name are exported.
+func (filter *ExportFilter) IncludesWorkspace(workspaceName string) bool {
+	return filter.workspaces.includes(workspaceName)
+}
+
+// IncludesProject returns true if the time records of the project with the given name are exported.
+func (filter *ExportFilter) IncludesProject(projectName string) bool {
+	return filter.projects.includes(projectName)
+}
+
+// Includes returns true if the given time record is exported.
+func (filter *ExportFilter) Includes(timeRecord toggl.TimeRecord) bool {
+	return filter.IncludesWorkspace(timeRecord.WorkspaceName) &&
+		filter.IncludesProject(timeRecord.ProjectName) &&
+		filter.clients.includes(timeRecord.ClientName) &&
+		filter.tags.includesAny(timeRecord.Tags) &&
+		filter.descriptions.includes(timeRecord.Description)
+}
+
+// compileExportFilter compiles the given patterns into an export filter.
+// Returns an error if any of the patterns is invalid.
+func compileExportFilter(patterns ExportFilterPatterns) (*ExportFilter, error) {
+	filter := &ExportFilter{}
+
+	namePatterns := []struct {
+		target   *nameFilter
+		included []string
+		excluded []string
+	}{
+		{&filter.workspaces, patterns.Workspaces, patterns.ExcludeWorkspaces},
+		{&filter.clients, patterns.Clients, patterns.ExcludeClients},
+		{&filter.projects, patterns.Projects, patterns.ExcludeProjects},
+		{&filter.tags, patterns.Tags, patterns.ExcludeTags},
+	}
+
+	for _, namePattern := range namePatterns {
+		compiled, compileError := newNameFilter(namePattern.included, namePattern.excluded, compileNamePattern)
+		if compileError != nil {
+			return nil, compileError
+		}
+
+		*namePattern.target = compiled
+	}
+
+	descriptions, descriptionsError := newNameFilter(patterns.Descriptions, patterns.ExcludeDescriptions, compileRegularExpression)
+	if descriptionsError != nil {
+		return nil, descriptionsError
+	}
+
+	filter.descriptions = descriptions
+
+	return filter, nil
+}
+
+// nameFilter selects names that match any of the included patterns (or all names if there are none)
+// and none of the excluded patterns.
+type nameFilter struct {
+	included []*regexp.Regexp
+	excluded []*regexp.Regexp
+}
+
+// newNameFilter compiles the given included and excluded patterns with the given compile function.
+func newNameFilter(included, excluded []string, compile func(pattern string) (*regexp.Regexp, error)) (nameFilter, error) {
+	var filter nameFilter
+
+	for _, pattern := range included {
+		expression, compileError := compile(pattern)
+		if compileError != nil {
+			return nameFilter{}, compileError
+		}
+
+		filter.included = append(filter.included, expression)
+	}
+
+	for _, pattern := range excluded {
+		expression, compileError := compile(pattern)
+		if compileError != nil {
+			return nameFilter{}, compileError
+		}
+
+		filter.excluded = append(filter.excluded, expression)
+	}
+
+	return filter, nil
+}
+
+// includes returns true if the given name is selected by the filter.
+func (filter nameFilter) includes(name string) bool {
+	if matchesAny(filter.excluded, name) {
+		return false
+	}
+
+	return len(filter.included) == 0 || matchesAny(filter.included, name)
+}
+
+// includesAny returns true if at least one of the given names matches the included patterns
+// and none of them matches the excluded patterns.
+func (filter nameFilter) includesAny(names []string) bool {
+	for _, name := range names {
+		if matchesAny(filter.excluded, name) {
+			return false
+		}
+	}
+
+	if len(filter.included) == 0 {
+		return true
+	}
+
+	for _, name := range names {
+		if matchesAny(filter.included, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesAny returns true if the given name matches any of the given expressions.
+func matchesAny(expressions []*regexp.Regexp, name string) bool {
+	for _, expression := range expressions {
+		if expression.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compileNamePattern compiles a name pattern. Patterns enclosed in slashes are regular expressions,
+// all other patterns are glob patterns that must match the whole name ("*" matches any text, "?" a single character).
+func compileNamePattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return compileRegularExpression(pattern[1 : len(pattern)-1])
+	}
+
+	var expression strings.Builder
+	expression.WriteString("^")
+	for _, character := range pattern {
+		switch character {
+		case '*':
+			expression.WriteString(".*")
+
+		case '?':
+			expression.WriteString(".")
+
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(character)))
+		}
+	}
+
+	expression.WriteString("$")
+
+	return regexp.Compile(expression.String())
+}
+
+// compileRegularExpression compiles the given regular expression.
+func compileRegularExpression(pattern string) (*regexp.Regexp, error) {
+	expression, compileError := regexp.Compile(pattern)
+	if compileError != nil {
+		return nil, fmt.Errorf("Invalid pattern %q: %s", pattern, compileError.Error())
+	}
+
+	return expression, nil
+}
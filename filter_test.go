@@ -0,0 +1,168 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func Test_compileNamePattern_GlobPattern_WholeNameIsMatched(t *testing.T) {
+	// arrange
+	inputs := map[string]bool{
+		"Customer A":        true,
+		"Customer AB":       true,
+		"Customer":          false,
+		"Old Customer A":    false,
+		"Customer (Intern)": true,
+	}
+
+	// act
+	expression, err := compileNamePattern("Customer *")
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("compileNamePattern returned an error: %s", err)
+		return
+	}
+
+	for name, expected := range inputs {
+		if expression.MatchString(name) != expected {
+			t.Fail()
+			t.Logf("The pattern %q should match %q: %t", "Customer *", name, expected)
+		}
+	}
+}
+
+func Test_compileNamePattern_QuestionMarkAndSpecialCharacters_SingleCharacterIsMatched(t *testing.T) {
+	// act
+	expression, err := compileNamePattern("Project (?)")
+
+	// assert
+	if err != nil || !expression.MatchString("Project (1)") || expression.MatchString("Project (12)") || expression.MatchString("Project 1") {
+		t.Fail()
+		t.Logf("The pattern %q should match exactly one character between the parentheses", "Project (?)")
+	}
+}
+
+func Test_compileNamePattern_PatternInSlashes_RegularExpressionIsUsed(t *testing.T) {
+	// act
+	expression, err := compileNamePattern("/^Customer [AB]$/")
+
+	// assert
+	if err != nil || !expression.MatchString("Customer B") || expression.MatchString("Customer C") {
+		t.Fail()
+		t.Logf("The pattern %q should have been used as a regular expression", "/^Customer [AB]$/")
+	}
+}
+
+func Test_compileExportFilter_InvalidRegularExpression_ErrorIsReturned(t *testing.T) {
+	// arrange
+	patterns := ExportFilterPatterns{
+		Descriptions: []string{"(unclosed"},
+	}
+
+	// act
+	_, err := compileExportFilter(patterns)
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("compileExportFilter(%#v) should have returned an error", patterns)
+	}
+}
+
+func Test_ExportFilter_Includes_RecordsAreSelectedByAllFilters(t *testing.T) {
+	// arrange
+	filter, err := compileExportFilter(ExportFilterPatterns{
+		Workspaces:          []string{"Work*"},
+		ExcludeClients:      []string{"Internal"},
+		Projects:            []string{"Website", "/^App/"},
+		ExcludeTags:         []string{"private"},
+		Descriptions:        []string{"(?i)meeting"},
+		ExcludeDescriptions: []string{"cancelled"},
+	})
+	if err != nil {
+		t.Fatalf("compileExportFilter returned an error: %s", err)
+	}
+
+	included := toggl.TimeRecord{
+		WorkspaceName: "Work",
+		ClientName:    "Customer A",
+		ProjectName:   "App Relaunch",
+		Tags:          []string{"billable"},
+		Description:   "Weekly Meeting",
+	}
+
+	inputs := map[string]bool{
+		"included":             true,
+		"other workspace":      false,
+		"excluded client":      false,
+		"other project":        false,
+		"excluded tag":         false,
+		"other description":    false,
+		"excluded description": false,
+	}
+
+	records := map[string]toggl.TimeRecord{
+		"included": included,
+	}
+
+	record := included
+	record.WorkspaceName = "Private"
+	records["other workspace"] = record
+
+	record = included
+	record.ClientName = "Internal"
+	records["excluded client"] = record
+
+	record = included
+	record.ProjectName = "Marketing"
+	records["other project"] = record
+
+	record = included
+	record.Tags = []string{"billable", "private"}
+	records["excluded tag"] = record
+
+	record = included
+	record.Description = "Coding"
+	records["other description"] = record
+
+	record = included
+	record.Description = "Meeting (cancelled)"
+	records["excluded description"] = record
+
+	for name, expected := range inputs {
+		// act
+		result := filter.Includes(records[name])
+
+		// assert
+		if result != expected {
+			t.Fail()
+			t.Logf("Includes should have returned %t for the %s time record but returned %t", expected, name, result)
+		}
+	}
+}
+
+func Test_ExportFilter_Includes_TagFilterGiven_RecordWithoutMatchingTagIsExcluded(t *testing.T) {
+	// arrange
+	filter, _ := compileExportFilter(ExportFilterPatterns{
+		Tags: []string{"billable"},
+	})
+
+	inputs := map[bool][]string{
+		true:  []string{"internal", "billable"},
+		false: []string{"internal"},
+	}
+
+	for expected, tags := range inputs {
+		// act
+		result := filter.Includes(toggl.TimeRecord{Tags: tags})
+
+		// assert
+		if result != expected {
+			t.Fail()
+			t.Logf("Includes should have returned %t for the tags %v but returned %t", expected, tags, result)
+		}
+	}
+}
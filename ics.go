@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// icsDateLayout defines the format of the UTC date-times of an iCalendar file.
+const icsDateLayout = "20060102T150405Z"
+
+// icsMaxLineLength defines the number of octets after which iCalendar content lines are folded.
+const icsMaxLineLength = 75
+
+// icsProductID identifies togglcsv as the creator of an iCalendar file.
+const icsProductID = "-//andreaskoch//togglcsv//EN"
+
+// icsUIDDomain is appended to the UIDs of the exported events to make them globally unique.
+const icsUIDDomain = "togglcsv"
+
+// newICSTimeRecordWriter creates a TimeRecordWriter that writes an iCalendar file with one event per time record.
+// The given stamp is written as the creation date (DTSTAMP) of all events.
+func newICSTimeRecordWriter(writer io.Writer, stamp time.Time) TimeRecordWriter {
+	icsWriter := &icsTimeRecordWriter{
+		writer: writer,
+		stamp:  stamp,
+		uids:   make(map[string]bool),
+	}
+
+	icsWriter.err = icsWriter.writeLines(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:"+icsProductID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+	)
+
+	return icsWriter
+}
+
+// icsTimeRecordWriter writes time records as the events (VEVENT) of an iCalendar file.
+type icsTimeRecordWriter struct {
+	writer io.Writer
+	stamp  time.Time
+
+	// uids contains the written UIDs so that the segments
+	// of a split time entry get UIDs of their own
+	uids map[string]bool
+
+	// err contains the first error; no further data is written after an error
+	err error
+}
+
+// Write writes the given time record as an event.
+// The summary is the description, the location contains the project and the client
+// and the tags are written as categories. A running time record has no end date.
+func (writer *icsTimeRecordWriter) Write(timeRecord toggl.TimeRecord) error {
+	if writer.err != nil {
+		return writer.err
+	}
+
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + escapeICSText(writer.getUID(timeRecord)),
+		"DTSTAMP:" + writer.stamp.UTC().Format(icsDateLayout),
+		"DTSTART:" + timeRecord.Start.UTC().Format(icsDateLayout),
+	}
+
+	if !timeRecord.IsRunning() {
+		lines = append(lines, "DTEND:"+timeRecord.Stop.UTC().Format(icsDateLayout))
+	}
+
+	lines = append(lines, "SUMMARY:"+escapeICSText(getICSSummary(timeRecord)))
+
+	if location := getICSLocation(timeRecord); location != "" {
+		lines = append(lines, "LOCATION:"+escapeICSText(location))
+	}
+
+	if len(timeRecord.Tags) > 0 {
+		var categories []string
+		for _, tag := range timeRecord.Tags {
+			categories = append(categories, escapeICSText(tag))
+		}
+
+		lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+	}
+
+	details := fmt.Sprintf("Workspace: %s\nProject: %s\nClient: %s\nBillable: %t", timeRecord.WorkspaceName, timeRecord.ProjectName, timeRecord.ClientName, timeRecord.Billable)
+	lines = append(lines, "DESCRIPTION:"+escapeICSText(details), "END:VEVENT")
+
+	writer.err = writer.writeLines(lines...)
+	return writer.err
+}
+
+// Close writes the end of the calendar.
+func (writer *icsTimeRecordWriter) Close() error {
+	if writer.err != nil {
+		return writer.err
+	}
+
+	return writer.writeLines("END:VCALENDAR")
+}
+
+// getUID returns a UID that stays the same when the time record is exported again.
+// Time records from Toggl are identified by the ID of their time entry, all other time records
+// by their start date, workspace, project, client and description.
+// Repeated UIDs (e.g. the later segments of a split time entry) get the start date of the time record
+// as a suffix so that they don't change if a different split produces more or fewer segments.
+func (writer *icsTimeRecordWriter) getUID(timeRecord toggl.TimeRecord) string {
+	uid := "toggl-" + strconv.Itoa(timeRecord.ID)
+	if timeRecord.ID == 0 {
+		identity := getTimeRecordIdentity(timeRecord)
+		hash := sha1.Sum([]byte(fmt.Sprintf("%d|%s|%s|%s|%s", identity.start, identity.workspaceName, identity.projectName, identity.clientName, identity.description)))
+		uid = fmt.Sprintf("togglcsv-%x", hash)
+	}
+
+	if writer.uids[uid] {
+		uid = uid + "-" + timeRecord.Start.UTC().Format(icsDateLayout)
+
+		// number time records that are repeated with the same start date
+		repeatedUID := uid
+		for count := 2; writer.uids[uid]; count++ {
+			uid = fmt.Sprintf("%s-%d", repeatedUID, count)
+		}
+	}
+
+	writer.uids[uid] = true
+
+	return uid + "@" + icsUIDDomain
+}
+
+// writeLines writes the given content lines folded and terminated by CRLF.
+func (writer *icsTimeRecordWriter) writeLines(lines ...string) error {
+	for _, line := range lines {
+		if _, writeError := io.WriteString(writer.writer, foldICSLine(line)+"\r\n"); writeError != nil {
+			return writeError
+		}
+	}
+
+	return nil
+}
+
+// getICSSummary returns the description of the given time record
+// or the project name if the description is empty.
+func getICSSummary(timeRecord toggl.TimeRecord) string {
+	if timeRecord.Description != "" {
+		return timeRecord.Description
+	}
+
+	return timeRecord.ProjectName
+}
+
+// getICSLocation returns the project and the client of the given time record (e.g. "Website (Customer A)").
+func getICSLocation(timeRecord toggl.TimeRecord) string {
+	switch {
+	case timeRecord.ProjectName != "" && timeRecord.ClientName != "":
+		return fmt.Sprintf("%s (%s)", timeRecord.ProjectName, timeRecord.ClientName)
+
+	case timeRecord.ProjectName != "":
+		return timeRecord.ProjectName
+	}
+
+	return timeRecord.ClientName
+}
+
+// escapeICSText escapes backslashes, semicolons, commas and line breaks of the given text value (RFC 5545, 3.3.11).
+func escapeICSText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+
+	return replacer.Replace(text)
+}
+
+// foldICSLine splits the given content line into lines of at most 75 octets (RFC 5545, 3.1).
+// The continuation lines start with a space and multi-byte characters are never split.
+func foldICSLine(line string) string {
+	if len(line) <= icsMaxLineLength {
+		return line
+	}
+
+	var folded strings.Builder
+	length := 0
+	for _, character := range line {
+		size := utf8.RuneLen(character)
+		if length+size > icsMaxLineLength {
+			folded.WriteString("\r\n ")
+
+			// the leading space counts towards the length of the continuation line
+			length = 1
+		}
+
+		folded.WriteRune(character)
+		length += size
+	}
+
+	return folded.String()
+}
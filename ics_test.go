@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func Test_icsTimeRecordWriter_TimeRecordIsWritten_EventIsWritten(t *testing.T) {
+	// arrange
+	var output bytes.Buffer
+	writer := newICSTimeRecordWriter(&output, time.Date(2016, 8, 2, 12, 0, 0, 0, time.UTC))
+
+	location, _ := time.LoadLocation("Europe/Berlin")
+	timeRecord := toggl.TimeRecord{
+		ID:            436694100,
+		Start:         time.Date(2016, 8, 1, 11, 0, 0, 0, location),
+		Stop:          time.Date(2016, 8, 1, 12, 30, 0, 0, location),
+		WorkspaceName: "Work",
+		ProjectName:   "Website",
+		ClientName:    "Customer A",
+		Description:   "Design; review, fixes",
+		Tags:          []string{"design", "a,b"},
+		Billable:      true,
+	}
+
+	// act
+	writer.Write(timeRecord)
+	writer.Close()
+
+	// assert
+	expected := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//andreaskoch//togglcsv//EN\r\n" +
+		"CALSCALE:GREGORIAN\r\n" +
+		"METHOD:PUBLISH\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:toggl-436694100@togglcsv\r\n" +
+		"DTSTAMP:20160802T120000Z\r\n" +
+		"DTSTART:20160801T090000Z\r\n" +
+		"DTEND:20160801T103000Z\r\n" +
+		"SUMMARY:Design\\; review\\, fixes\r\n" +
+		"LOCATION:Website (Customer A)\r\n" +
+		"CATEGORIES:design,a\\,b\r\n" +
+		"DESCRIPTION:Workspace: Work\\nProject: Website\\nClient: Customer A\\nBillable\r\n" +
+		" : true\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	if output.String() != expected {
+		t.Fail()
+		t.Logf("The iCalendar writer should have written\n%q\nbut wrote\n%q", expected, output.String())
+	}
+}
+
+func Test_icsTimeRecordWriter_RunningTimeRecord_EventHasNoEndDate(t *testing.T) {
+	// arrange
+	var output bytes.Buffer
+	writer := newICSTimeRecordWriter(&output, time.Date(2016, 8, 2, 12, 0, 0, 0, time.UTC))
+
+	timeRecord := getJSONTestTimeRecord("Record 1")
+	timeRecord.Stop = time.Time{}
+
+	// act
+	writer.Write(timeRecord)
+	writer.Close()
+
+	// assert
+	if strings.Contains(output.String(), "DTEND") {
+		t.Fail()
+		t.Logf("The event of a running time record should not have an end date: %s", output.String())
+	}
+}
+
+func Test_icsTimeRecordWriter_SameTimeRecordIsExportedTwice_UIDsAreStable(t *testing.T) {
+	// arrange
+	timeRecord := getJSONTestTimeRecord("Record 1")
+	getUIDs := func() []string {
+		var output bytes.Buffer
+		writer := newICSTimeRecordWriter(&output, time.Now())
+		writer.Write(timeRecord)
+		writer.Write(timeRecord)
+		writer.Close()
+
+		var uids []string
+		for _, line := range strings.Split(output.String(), "\r\n") {
+			if strings.HasPrefix(line, "UID:") {
+				uids = append(uids, line)
+			}
+		}
+
+		return uids
+	}
+
+	// act
+	first := getUIDs()
+	second := getUIDs()
+
+	// assert
+	if len(first) != 2 || first[0] == first[1] {
+		t.Fail()
+		t.Logf("Every event of an export should have its own UID but the UIDs were %v", first)
+	}
+
+	if strings.Join(first, " ") != strings.Join(second, " ") {
+		t.Fail()
+		t.Logf("A re-export should write the same UIDs but wrote %v and %v", first, second)
+	}
+}
+
+func Test_icsTimeRecordWriter_SplitTimeEntry_SegmentUIDsDependOnTheirStartDate(t *testing.T) {
+	// arrange
+	timeRecord := getJSONTestTimeRecord("Record 1")
+	timeRecord.ID = 436694100
+	timeRecord.Start = time.Date(2016, 8, 30, 22, 0, 0, 0, time.UTC)
+	timeRecord.Stop = time.Date(2016, 9, 1, 2, 0, 0, 0, time.UTC)
+
+	getUIDs := func(period string) map[string]string {
+		var output bytes.Buffer
+		writer := newICSTimeRecordWriter(&output, time.Now())
+		for _, segment := range splitTimeRecord(timeRecord, period, time.UTC) {
+			writer.Write(segment)
+		}
+
+		writer.Close()
+
+		uids := make(map[string]string)
+		var uid string
+		for _, line := range strings.Split(output.String(), "\r\n") {
+			switch {
+			case strings.HasPrefix(line, "UID:"):
+				uid = line
+
+			case strings.HasPrefix(line, "DTSTART:"):
+				uids[line] = uid
+			}
+		}
+
+		return uids
+	}
+
+	// act
+	days := getUIDs(splitPeriodDay)
+	months := getUIDs(splitPeriodMonth)
+
+	// assert
+	for _, start := range []string{"DTSTART:20160830T220000Z", "DTSTART:20160901T000000Z"} {
+		if days[start] == "" || days[start] != months[start] {
+			t.Fail()
+			t.Logf("The segment with %s should have the same UID in both exports but had %q and %q", start, days[start], months[start])
+		}
+	}
+
+	if months["DTSTART:20160901T000000Z"] != "UID:toggl-436694100-20160901T000000Z@togglcsv" {
+		t.Fail()
+		t.Logf("The second segment should have the start date as a UID suffix but had %q", months["DTSTART:20160901T000000Z"])
+	}
+}
+
+func Test_foldICSLine_LongLineWithMultiByteCharacters_LinesAreAtMost75Octets(t *testing.T) {
+	// arrange
+	line := "SUMMARY:" + strings.Repeat("Überstunden ", 10)
+
+	// act
+	folded := foldICSLine(line)
+
+	// assert
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > icsMaxLineLength {
+			t.Fail()
+			t.Logf("The folded line %q should not be longer than %d octets", part, icsMaxLineLength)
+		}
+	}
+
+	if strings.Replace(folded, "\r\n ", "", -1) != line {
+		t.Fail()
+		t.Logf("Unfolding %q should return the original line %q", folded, line)
+	}
+}
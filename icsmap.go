@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+	"github.com/pkg/errors"
+)
+
+// calendarMapColumnNames contains the column names of a calendar map file.
+var calendarMapColumnNames = []string{"Match", "Pattern", columnWorkspaceName, columnProjectName, columnClientName, columnTags}
+
+// The attributes of a calendar event a calendar map rule can match.
+const (
+	calendarMatchCalendar  = "calendar"
+	calendarMatchOrganizer = "organizer"
+	calendarMatchSummary   = "summary"
+)
+
+// calendarRule assigns a workspace, project, client and tags to the calendar events it matches.
+type calendarRule struct {
+	match   string
+	pattern *regexp.Regexp
+
+	workspaceName string
+	projectName   string
+	clientName    string
+	tags          []string
+}
+
+// matches returns true if the rule applies to the given calendar event.
+// Organizer rules match the e-mail address or the name of the organizer.
+func (rule calendarRule) matches(event icsEvent) bool {
+	switch rule.match {
+	case calendarMatchCalendar:
+		return rule.pattern.MatchString(event.calendarName)
+
+	case calendarMatchOrganizer:
+		return (event.organizerEmail != "" && rule.pattern.MatchString(event.organizerEmail)) ||
+			(event.organizerName != "" && rule.pattern.MatchString(event.organizerName))
+	}
+
+	return rule.pattern.MatchString(event.summary)
+}
+
+// loadCalendarMapper reads the rules for calendar events from the CSV calendar map file with the given path.
+func loadCalendarMapper(path string) (*CalendarMapper, error) {
+	file, openError := os.Open(path)
+	if openError != nil {
+		return nil, errors.Wrap(openError, fmt.Sprintf("Failed to open the calendar map file %q", path))
+	}
+
+	defer file.Close()
+
+	mapper, readError := readCalendarMapper(file)
+	if readError != nil {
+		return nil, errors.Wrap(readError, fmt.Sprintf("Failed to read the calendar map file %q", path))
+	}
+
+	return mapper, nil
+}
+
+// readCalendarMapper reads the rules for calendar events from the given CSV input.
+// Every row contains what is matched (calendar, organizer or summary), the pattern, the workspace name
+// and optionally the project name, the client name and the tags. Calendar and organizer patterns are
+// glob patterns or regular expressions enclosed in slashes; summary patterns are regular expressions
+// that must match a part of the summary.
+func readCalendarMapper(input io.Reader) (*CalendarMapper, error) {
+	rows, lines, csvError := readCSVRows(input)
+	if csvError != nil {
+		return nil, csvError
+	}
+
+	mapper := &CalendarMapper{}
+	for index, row := range rows {
+
+		// skip the headline
+		if index == 0 && isHeadline(row, calendarMapColumnNames) {
+			continue
+		}
+
+		if len(row) < 3 || len(row) > len(calendarMapColumnNames) {
+			return nil, fmt.Errorf("Line %d: Wrong number of values. Expected 3 to %d but got %d", lines[index], len(calendarMapColumnNames), len(row))
+		}
+
+		for len(row) < len(calendarMapColumnNames) {
+			row = append(row, "")
+		}
+
+		rule := calendarRule{
+			match:         strings.ToLower(strings.TrimSpace(row[0])),
+			workspaceName: strings.TrimSpace(row[2]),
+			projectName:   strings.TrimSpace(row[3]),
+			clientName:    strings.TrimSpace(row[4]),
+		}
+
+		if rule.workspaceName == "" {
+			return nil, fmt.Errorf("Line %d: The workspace name must not be empty", lines[index])
+		}
+
+		for _, tag := range strings.Split(row[5], ",") {
+			if strings.TrimSpace(tag) != "" {
+				rule.tags = append(rule.tags, strings.TrimSpace(tag))
+			}
+		}
+
+		compile := compileNamePattern
+		switch rule.match {
+		case calendarMatchCalendar, calendarMatchOrganizer:
+		case calendarMatchSummary:
+			compile = compileRegularExpression
+		default:
+			return nil, fmt.Errorf("Line %d: Unknown match %q. Use calendar, organizer or summary", lines[index], row[0])
+		}
+
+		pattern, patternError := compile(strings.TrimSpace(row[1]))
+		if patternError != nil {
+			return nil, fmt.Errorf("Line %d: %s", lines[index], patternError.Error())
+		}
+
+		rule.pattern = pattern
+		mapper.rules = append(mapper.rules, rule)
+	}
+
+	return mapper, nil
+}
+
+// CalendarMapper assigns the workspace, project, client and tags of imported calendar events.
+// The first matching rule wins.
+type CalendarMapper struct {
+	rules []calendarRule
+}
+
+// getTimeRecord returns a time record with the summary of the given calendar event as description
+// and the names of the first matching rule. Returns false if no rule matches.
+func (mapper *CalendarMapper) getTimeRecord(event icsEvent) (toggl.TimeRecord, bool) {
+	if mapper == nil {
+		return toggl.TimeRecord{}, false
+	}
+
+	for _, rule := range mapper.rules {
+		if !rule.matches(event) {
+			continue
+		}
+
+		return toggl.TimeRecord{
+			WorkspaceName: rule.workspaceName,
+			ProjectName:   rule.projectName,
+			ClientName:    rule.clientName,
+			Tags:          append([]string(nil), rule.tags...),
+			Description:   event.summary,
+		}, true
+	}
+
+	return toggl.TimeRecord{}, false
+}
+
+// NewICSTimeRecordMapper creates a mapper that converts the calendar events of iCalendar files into TimeRecord models
+// using the given calendar map. Only occurrences within the given window (zero dates for none) are returned.
+// Dates without a time zone are read in the given location (optional; default: UTC).
+func NewICSTimeRecordMapper(calendarMapper *CalendarMapper, from, until time.Time, location *time.Location) *ICSTimeRecordMapper {
+	return &ICSTimeRecordMapper{
+		calendarMapper: calendarMapper,
+		window:         icsWindow{start: from, end: until},
+		location:       location,
+	}
+}
+
+// ICSTimeRecordMapper converts calendar events into TimeRecord models.
+type ICSTimeRecordMapper struct {
+	calendarMapper *CalendarMapper
+	window         icsWindow
+	location       *time.Location
+}
+
+// GetTimeRecords returns a time record for every occurrence of the calendar events of the given iCalendar input
+// ordered by start date, the line of the event of every time record and all events that are not imported
+// because they cannot be read or no rule matches them.
+func (mapper *ICSTimeRecordMapper) GetTimeRecords(input io.Reader) ([]toggl.TimeRecord, []int, []icsSkippedEvent, error) {
+	events, eventsError := readICSEvents(input, mapper.location)
+	if eventsError != nil {
+		return nil, nil, nil, eventsError
+	}
+
+	occurrences, skipped := expandICSEvents(events, mapper.window)
+
+	var timeRecords []toggl.TimeRecord
+	var lines []int
+	for _, occurrence := range occurrences {
+		timeRecord, isMapped := mapper.calendarMapper.getTimeRecord(occurrence.event)
+		if !isMapped {
+			skipped = append(skipped, icsSkippedEvent{event: occurrence.event, start: occurrence.start, reason: "No rule of the calendar map matches"})
+			continue
+		}
+
+		timeRecord.Start = occurrence.start
+		timeRecord.Stop = occurrence.end
+		timeRecords = append(timeRecords, timeRecord)
+		lines = append(lines, occurrence.event.line)
+	}
+
+	return timeRecords, lines, skipped, nil
+}
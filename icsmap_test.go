@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_readCalendarMapper_ValidRules_FirstMatchingRuleIsApplied(t *testing.T) {
+	// arrange
+	input := `Match,Pattern,Workspace Name,Project Name,Client Name,Tag(s)
+summary,(?i)standup,Work,Scrum,Customer A,"meeting,daily"
+organizer,*@example.com,Work,Customer Meetings,Customer A
+calendar,Private,Personal
+`
+
+	events := []icsEvent{
+		{summary: "Daily Standup", organizerEmail: "jane@example.com", calendarName: "Work"},
+		{summary: "Review", organizerEmail: "jane@example.com", calendarName: "Work"},
+		{summary: "Dentist", calendarName: "Private"},
+		{summary: "Lunch", calendarName: "Work"},
+	}
+
+	// act
+	mapper, err := readCalendarMapper(strings.NewReader(input))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("readCalendarMapper should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	var result []string
+	for _, event := range events {
+		timeRecord, isMapped := mapper.getTimeRecord(event)
+		if !isMapped {
+			result = append(result, "unmapped")
+			continue
+		}
+
+		result = append(result, fmt.Sprintf("%s/%s/%s/%v", timeRecord.WorkspaceName, timeRecord.ProjectName, timeRecord.Description, timeRecord.Tags))
+	}
+
+	expected := "[Work/Scrum/Daily Standup/[meeting daily] Work/Customer Meetings/Review/[] Personal//Dentist/[] unmapped]"
+	if fmt.Sprintf("%v", result) != expected {
+		t.Fail()
+		t.Logf("The calendar map should have returned %s but returned %v", expected, result)
+	}
+}
+
+func Test_readCalendarMapper_InvalidRules_ErrorIsReturned(t *testing.T) {
+	inputs := []string{
+		"attendee,*,Work",
+		"summary,Standup",
+		"summary,Standup,",
+		"summary,(,Work",
+	}
+
+	for _, input := range inputs {
+		// act
+		_, err := readCalendarMapper(strings.NewReader(input))
+
+		// assert
+		if err == nil {
+			t.Fail()
+			t.Logf("readCalendarMapper(%q) should return an error", input)
+		}
+	}
+}
+
+func Test_ICSTimeRecordMapper_GetTimeRecords_UnmappedEventsAreSkipped(t *testing.T) {
+	// arrange
+	calendarMapper, _ := readCalendarMapper(strings.NewReader("summary,Standup,Work,Scrum"))
+	mapper := NewICSTimeRecordMapper(calendarMapper, time.Time{}, time.Date(2016, 9, 1, 0, 0, 0, 0, time.UTC), nil)
+
+	input := getICSTestCalendar("Work",
+		"UID:1\r\nSUMMARY:Standup\r\nDTSTART:20160801T090000Z\r\nDTEND:20160801T091500Z",
+		"UID:2\r\nSUMMARY:Lunch\r\nDTSTART:20160801T120000Z\r\nDTEND:20160801T130000Z",
+		"UID:3\r\nSUMMARY:Standup\r\nDTSTART:20160901T090000Z\r\nDTEND:20160901T091500Z",
+	)
+
+	// act
+	timeRecords, _, skipped, err := mapper.GetTimeRecords(strings.NewReader(input))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("GetTimeRecords should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	if len(timeRecords) != 1 || timeRecords[0].Description != "Standup" || timeRecords[0].ProjectName != "Scrum" || timeRecords[0].Stop.Sub(timeRecords[0].Start) != 15*time.Minute {
+		t.Fail()
+		t.Logf("GetTimeRecords should have returned the first standup but returned %#v", timeRecords)
+	}
+
+	if len(skipped) != 1 || skipped[0].event.summary != "Lunch" || skipped[0].reason != "No rule of the calendar map matches" {
+		t.Fail()
+		t.Logf("GetTimeRecords should have skipped the unmapped lunch but skipped %#v", skipped)
+	}
+}
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The date formats of iCalendar dates and date-times.
+const (
+	icsDateOnlyLayout      = "20060102"
+	icsLocalDateTimeLayout = "20060102T150405"
+)
+
+// maxICSLineLength defines the maximum number of bytes of an unfolded iCalendar line.
+const maxICSLineLength = 1024 * 1024
+
+// maxICSOccurrences limits the number of occurrences of a single recurring event.
+const maxICSOccurrences = 10000
+
+// icsDurationPattern matches iCalendar durations (e.g. "PT1H30M" or "P1DT2H").
+var icsDurationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// icsEvent contains the properties of an iCalendar event (VEVENT) that are imported.
+type icsEvent struct {
+	// line contains the line of the BEGIN:VEVENT
+	line int
+
+	uid          string
+	summary      string
+	status       string
+	calendarName string
+
+	organizerEmail string
+	organizerName  string
+
+	start  time.Time
+	end    time.Time
+	allDay bool
+
+	// duration replaces the end date of events without DTEND
+	duration time.Duration
+
+	// recurrenceRule contains the RRULE of a recurring event
+	recurrenceRule string
+
+	// exceptions contains the starts of the occurrences that are left out (EXDATE)
+	exceptions []time.Time
+
+	// recurrenceID contains the start of the occurrence that is replaced by this event (optional)
+	recurrenceID time.Time
+
+	// err contains the problem that prevents the import of the event
+	err error
+}
+
+// icsOccurrence is an occurrence of a calendar event.
+type icsOccurrence struct {
+	event icsEvent
+	start time.Time
+	end   time.Time
+}
+
+// icsSkippedEvent contains a calendar event that is not imported and the reason why.
+type icsSkippedEvent struct {
+	event  icsEvent
+	start  time.Time
+	reason string
+}
+
+// icsWindow contains the date range of the imported calendar events.
+// Zero dates leave the window open at that end.
+type icsWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// contains returns true if the time from the given start until the given end lies completely within the window.
+func (window icsWindow) contains(start, end time.Time) bool {
+	if !window.start.IsZero() && start.Before(window.start) {
+		return false
+	}
+
+	if !window.end.IsZero() && end.After(window.end) {
+		return false
+	}
+
+	return true
+}
+
+// mayContain returns true if the given event or any of its recurrences may lie within the window.
+func (window icsWindow) mayContain(event icsEvent) bool {
+	if event.start.IsZero() {
+		return true
+	}
+
+	if !window.end.IsZero() && !event.start.Before(window.end) {
+		return false
+	}
+
+	return event.recurrenceRule != "" || window.start.IsZero() || !event.start.Before(window.start)
+}
+
+// readICSEvents reads all events from the given iCalendar input.
+// Dates without a time zone are read in the given location (default: UTC).
+// Problems of a single event are stored in the event; an error is only returned if the input is no calendar.
+func readICSEvents(input io.Reader, location *time.Location) ([]icsEvent, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	lines, linesError := readICSLines(input)
+	if linesError != nil {
+		return nil, linesError
+	}
+
+	var events []icsEvent
+	var components []string
+	var event *icsEvent
+	calendarStart := 0
+	calendarName := ""
+	calendars := 0
+
+	for _, line := range lines {
+		name, parameters, value := parseICSProperty(line.content)
+
+		switch name {
+		case "BEGIN":
+			components = append(components, strings.ToUpper(value))
+			if len(components) == 1 && components[0] == "VCALENDAR" {
+				calendarStart = len(events)
+				calendarName = ""
+				calendars++
+			}
+
+			if len(components) == 2 && components[1] == "VEVENT" {
+				event = &icsEvent{line: line.number}
+			}
+
+			continue
+
+		case "END":
+			if len(components) == 0 || components[len(components)-1] != strings.ToUpper(value) {
+				return nil, fmt.Errorf("Line %d: Unexpected END:%s", line.number, value)
+			}
+
+			if len(components) == 2 && event != nil {
+				events = append(events, *event)
+				event = nil
+			}
+
+			if len(components) == 1 {
+				for index := calendarStart; index < len(events); index++ {
+					events[index].calendarName = calendarName
+				}
+			}
+
+			components = components[:len(components)-1]
+			continue
+		}
+
+		if len(components) == 1 && name == "X-WR-CALNAME" {
+			calendarName = unescapeICSText(value)
+		}
+
+		// skip the properties of the calendar and of nested components such as alarms
+		if event == nil || len(components) != 2 {
+			continue
+		}
+
+		if propertyError := event.setProperty(name, parameters, value, location); propertyError != nil && event.err == nil {
+			event.err = fmt.Errorf("%s: %s", name, propertyError.Error())
+		}
+	}
+
+	if calendars == 0 {
+		return nil, fmt.Errorf("The input is not an iCalendar file")
+	}
+
+	if len(components) > 0 {
+		return nil, fmt.Errorf("The iCalendar input ends before END:%s", components[len(components)-1])
+	}
+
+	for index := range events {
+		events[index].complete()
+	}
+
+	return events, nil
+}
+
+// setProperty stores the given property of the event.
+func (event *icsEvent) setProperty(name string, parameters map[string]string, value string, location *time.Location) error {
+	switch name {
+	case "UID":
+		event.uid = value
+
+	case "SUMMARY":
+		event.summary = unescapeICSText(value)
+
+	case "STATUS":
+		event.status = strings.ToUpper(value)
+
+	case "ORGANIZER":
+		event.organizerName = parameters["CN"]
+		if strings.HasPrefix(strings.ToLower(value), "mailto:") {
+			value = value[len("mailto:"):]
+		}
+
+		event.organizerEmail = value
+
+	case "DTSTART":
+		start, allDay, dateError := parseICSDate(value, parameters, location)
+		if dateError != nil {
+			return dateError
+		}
+
+		event.start = start
+		event.allDay = allDay
+
+	case "DTEND":
+		end, _, dateError := parseICSDate(value, parameters, location)
+		if dateError != nil {
+			return dateError
+		}
+
+		event.end = end
+
+	case "DURATION":
+		duration, durationError := parseICSDuration(value)
+		if durationError != nil {
+			return durationError
+		}
+
+		event.duration = duration
+
+	case "RRULE":
+		event.recurrenceRule = value
+
+	case "EXDATE":
+		for _, exception := range strings.Split(value, ",") {
+			date, _, dateError := parseICSDate(exception, parameters, location)
+			if dateError != nil {
+				return dateError
+			}
+
+			event.exceptions = append(event.exceptions, date)
+		}
+
+	case "RECURRENCE-ID":
+		recurrenceID, _, dateError := parseICSDate(value, parameters, location)
+		if dateError != nil {
+			return dateError
+		}
+
+		event.recurrenceID = recurrenceID
+	}
+
+	return nil
+}
+
+// complete calculates the end date of an event that has a duration instead of an end date
+// and checks the required properties.
+func (event *icsEvent) complete() {
+	if event.end.IsZero() && event.duration > 0 {
+		event.end = event.start.Add(event.duration)
+	}
+
+	if event.err == nil && event.start.IsZero() {
+		event.err = fmt.Errorf("The event has no start date (DTSTART)")
+	}
+}
+
+// expandICSEvents returns the occurrences of the given events that lie within the given window
+// and all events that cannot be imported. Recurring events are expanded, cancelled events and
+// occurrences that are replaced by another event (RECURRENCE-ID) or excluded (EXDATE) are left out.
+func expandICSEvents(events []icsEvent, window icsWindow) ([]icsOccurrence, []icsSkippedEvent) {
+
+	// collect the occurrences that are replaced by other events
+	replaced := make(map[string][]time.Time)
+	for _, event := range events {
+		if !event.recurrenceID.IsZero() {
+			replaced[event.uid] = append(replaced[event.uid], event.recurrenceID)
+		}
+	}
+
+	var occurrences []icsOccurrence
+	var skipped []icsSkippedEvent
+	for _, event := range events {
+		if event.status == "CANCELLED" {
+			continue
+		}
+
+		skip := func(reason string) {
+			if window.mayContain(event) {
+				skipped = append(skipped, icsSkippedEvent{event: event, start: event.start, reason: reason})
+			}
+		}
+
+		switch {
+		case event.err != nil:
+			skip(event.err.Error())
+			continue
+
+		case event.allDay:
+			skip("All-day events are not imported")
+			continue
+
+		case !event.end.After(event.start):
+			skip("The event has no duration")
+			continue
+		}
+
+		starts := []time.Time{event.start}
+		if event.recurrenceRule != "" && event.recurrenceID.IsZero() {
+			rule, ruleError := parseICSRecurrenceRule(event.recurrenceRule, event.start.Location())
+			if ruleError != nil {
+				skip(fmt.Sprintf("RRULE: %s", ruleError.Error()))
+				continue
+			}
+
+			expanded, expandError := rule.expand(event.start, window.end)
+			if expandError != nil {
+				skip(fmt.Sprintf("RRULE: %s", expandError.Error()))
+				continue
+			}
+
+			starts = expanded
+		}
+
+		duration := event.end.Sub(event.start)
+		for _, start := range starts {
+			if event.recurrenceID.IsZero() && (containsDate(event.exceptions, start) || containsDate(replaced[event.uid], start)) {
+				continue
+			}
+
+			if !window.contains(start, start.Add(duration)) {
+				continue
+			}
+
+			occurrences = append(occurrences, icsOccurrence{event: event, start: start, end: start.Add(duration)})
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].start.Before(occurrences[j].start)
+	})
+
+	return occurrences, skipped
+}
+
+// containsDate returns true if the given dates contain the given date.
+func containsDate(dates []time.Time, date time.Time) bool {
+	for _, candidate := range dates {
+		if candidate.Equal(date) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// icsLine is an unfolded content line of an iCalendar input.
+type icsLine struct {
+	number  int
+	content string
+}
+
+// readICSLines returns the unfolded, non-empty content lines of the given iCalendar input.
+func readICSLines(input io.Reader) ([]icsLine, error) {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), maxICSLineLength)
+
+	var lines []icsLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		content := strings.TrimRight(scanner.Text(), "\r")
+
+		// continuation lines start with a space or a tab
+		if len(lines) > 0 && (strings.HasPrefix(content, " ") || strings.HasPrefix(content, "\t")) {
+			lines[len(lines)-1].content += content[1:]
+			continue
+		}
+
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		lines = append(lines, icsLine{number: number, content: content})
+	}
+
+	if scanError := scanner.Err(); scanError != nil {
+		return nil, fmt.Errorf("Failed to read the iCalendar input: %s", scanError.Error())
+	}
+
+	return lines, nil
+}
+
+// parseICSProperty splits the given content line into the upper-case property name,
+// the parameters (with upper-case names) and the value.
+func parseICSProperty(line string) (string, map[string]string, string) {
+	parameters := make(map[string]string)
+
+	// find the colon before the value that is not part of a quoted parameter value
+	quoted := false
+	separators := []int{}
+	valueStart := len(line)
+	for index, character := range line {
+		if character == '"' {
+			quoted = !quoted
+			continue
+		}
+
+		if quoted {
+			continue
+		}
+
+		if character == ';' {
+			separators = append(separators, index)
+		}
+
+		if character == ':' {
+			valueStart = index
+			break
+		}
+	}
+
+	nameEnd := valueStart
+	if len(separators) > 0 {
+		nameEnd = separators[0]
+	}
+
+	separators = append(separators, valueStart)
+	for index := 0; index < len(separators)-1; index++ {
+		parameter := line[separators[index]+1 : separators[index+1]]
+		if equals := strings.Index(parameter, "="); equals > 0 {
+			parameters[strings.ToUpper(parameter[:equals])] = strings.Trim(parameter[equals+1:], `"`)
+		}
+	}
+
+	value := ""
+	if valueStart < len(line) {
+		value = line[valueStart+1:]
+	}
+
+	return strings.ToUpper(line[:nameEnd]), parameters, value
+}
+
+// parseICSDate parses the given iCalendar date or date-time. Date-times with a TZID parameter are read
+// in the given time zone, date-times ending with "Z" in UTC and all other date-times in the given location.
+// Returns true if the value is a date without a time.
+func parseICSDate(value string, parameters map[string]string, location *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+
+	if tzid := parameters["TZID"]; tzid != "" {
+		timezone, timezoneError := time.LoadLocation(tzid)
+		if timezoneError != nil {
+			return time.Time{}, false, fmt.Errorf("Unknown time zone %q", tzid)
+		}
+
+		location = timezone
+	}
+
+	if parameters["VALUE"] == "DATE" || len(value) == len(icsDateOnlyLayout) {
+		date, parseError := time.ParseInLocation(icsDateOnlyLayout, value, location)
+		if parseError != nil {
+			return time.Time{}, false, fmt.Errorf("Invalid date %q", value)
+		}
+
+		return date, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		date, parseError := time.Parse(icsDateLayout, value)
+		if parseError != nil {
+			return time.Time{}, false, fmt.Errorf("Invalid date %q", value)
+		}
+
+		return date, false, nil
+	}
+
+	date, parseError := time.ParseInLocation(icsLocalDateTimeLayout, value, location)
+	if parseError != nil {
+		return time.Time{}, false, fmt.Errorf("Invalid date %q", value)
+	}
+
+	return date, false, nil
+}
+
+// parseICSDuration parses the given iCalendar duration (e.g. "PT1H30M").
+// Days and weeks count as 24 hours and 7 days.
+func parseICSDuration(value string) (time.Duration, error) {
+	matches := icsDurationPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("Invalid duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+
+	var duration time.Duration
+	for index, unit := range units {
+		if matches[index+2] == "" {
+			continue
+		}
+
+		count, _ := strconv.Atoi(matches[index+2])
+		duration += time.Duration(count) * unit
+	}
+
+	if matches[1] == "-" {
+		return 0, fmt.Errorf("The duration %q is negative", value)
+	}
+
+	return duration, nil
+}
+
+// unescapeICSText reverts escapeICSText.
+func unescapeICSText(text string) string {
+	replacer := strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, `;`,
+		`\,`, `,`,
+		`\n`, "\n",
+		`\N`, "\n",
+	)
+
+	return replacer.Replace(text)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getICSTestCalendar returns an iCalendar file with the given name and the given event properties.
+func getICSTestCalendar(calendarName string, events ...string) string {
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "X-WR-CALNAME:" + calendarName}
+	for _, event := range events {
+		lines = append(lines, "BEGIN:VEVENT", event, "END:VEVENT")
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func Test_readICSEvents_ValidCalendar_EventsAreReturned(t *testing.T) {
+	// arrange
+	input := getICSTestCalendar("Work",
+		"UID:1\r\n"+
+			"SUMMARY:Sprint Planning\\, Team A\r\n"+
+			"ORGANIZER;CN=\"Doe; Jane\":mailto:jane@example.com\r\n"+
+			"DTSTART;TZID=Europe/Berlin:20160801T100000\r\n"+
+			"DTEND;TZID=Europe/Berlin:20160801T113000\r\n"+
+			"BEGIN:VALARM\r\nTRIGGER:-PT15M\r\nDESCRIPTION:Reminder\r\nEND:VALARM",
+		"UID:2\r\n"+
+			"SUMMARY:A very long summary that is folded\r\n"+
+			"  into two lines\r\n"+
+			"DTSTART:20160802T090000Z\r\n"+
+			"DURATION:PT45M",
+	)
+
+	// act
+	events, err := readICSEvents(strings.NewReader(input), nil)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("readICSEvents should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	if len(events) != 2 {
+		t.Fail()
+		t.Logf("readICSEvents should have returned 2 events but returned %d", len(events))
+		return
+	}
+
+	first := events[0]
+	if first.summary != "Sprint Planning, Team A" || first.calendarName != "Work" || first.organizerEmail != "jane@example.com" || first.organizerName != "Doe; Jane" {
+		t.Fail()
+		t.Logf("readICSEvents returned the wrong properties for the first event: %#v", first)
+	}
+
+	if first.start.UTC() != time.Date(2016, 8, 1, 8, 0, 0, 0, time.UTC) || first.end.Sub(first.start) != 90*time.Minute {
+		t.Fail()
+		t.Logf("The first event should start at 08:00 UTC and last 90 minutes but starts at %s and ends at %s", first.start.UTC(), first.end.UTC())
+	}
+
+	second := events[1]
+	if second.summary != "A very long summary that is folded into two lines" || second.end.Sub(second.start) != 45*time.Minute {
+		t.Fail()
+		t.Logf("readICSEvents returned the wrong properties for the second event: %#v", second)
+	}
+}
+
+func Test_readICSEvents_InputIsNoCalendar_ErrorIsReturned(t *testing.T) {
+	// arrange
+	input := "Start,Stop\n2016-08-01T10:00:00Z,2016-08-01T11:00:00Z\n"
+
+	// act
+	_, err := readICSEvents(strings.NewReader(input), nil)
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("readICSEvents should return an error if the input is not an iCalendar file")
+	}
+}
+
+func Test_expandICSEvents_RecurringEvent_OccurrencesWithinTheWindowAreReturned(t *testing.T) {
+	// arrange
+	input := getICSTestCalendar("Work",
+		"UID:standup\r\n"+
+			"SUMMARY:Standup\r\n"+
+			"DTSTART:20160801T090000Z\r\n"+
+			"DTEND:20160801T091500Z\r\n"+
+			"RRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR\r\n"+
+			"EXDATE:20160803T090000Z",
+		"UID:standup\r\n"+
+			"RECURRENCE-ID:20160804T090000Z\r\n"+
+			"SUMMARY:Standup (moved)\r\n"+
+			"DTSTART:20160804T100000Z\r\n"+
+			"DTEND:20160804T101500Z",
+		"UID:cancelled\r\n"+
+			"SUMMARY:Cancelled\r\n"+
+			"STATUS:CANCELLED\r\n"+
+			"DTSTART:20160802T120000Z\r\n"+
+			"DTEND:20160802T130000Z",
+	)
+
+	events, _ := readICSEvents(strings.NewReader(input), nil)
+	window := icsWindow{start: time.Date(2016, 8, 2, 0, 0, 0, 0, time.UTC), end: time.Date(2016, 8, 9, 0, 0, 0, 0, time.UTC)}
+
+	// act
+	occurrences, skipped := expandICSEvents(events, window)
+
+	// assert
+	var result []string
+	for _, occurrence := range occurrences {
+		result = append(result, fmt.Sprintf("%s %s", occurrence.start.Format("01-02 15:04"), occurrence.event.summary))
+	}
+
+	expected := "[08-02 09:00 Standup 08-04 10:00 Standup (moved) 08-05 09:00 Standup 08-08 09:00 Standup]"
+	if fmt.Sprintf("%v", result) != expected {
+		t.Fail()
+		t.Logf("expandICSEvents should have returned %s but returned %v", expected, result)
+	}
+
+	if len(skipped) != 0 {
+		t.Fail()
+		t.Logf("expandICSEvents should not skip any events but skipped %v", skipped)
+	}
+}
+
+func Test_expandICSEvents_AllDayAndInvalidEvents_EventsAreSkipped(t *testing.T) {
+	// arrange
+	input := getICSTestCalendar("Work",
+		"UID:1\r\nSUMMARY:Holiday\r\nDTSTART;VALUE=DATE:20160801\r\nDTEND;VALUE=DATE:20160802",
+		"UID:2\r\nSUMMARY:Unknown zone\r\nDTSTART;TZID=Mars/Olympus:20160801T090000\r\nDTEND;TZID=Mars/Olympus:20160801T100000",
+		"UID:3\r\nSUMMARY:Monthly\r\nDTSTART:20160801T090000Z\r\nDTEND:20160801T100000Z\r\nRRULE:FREQ=MONTHLY;BYMONTHDAY=1",
+	)
+
+	events, _ := readICSEvents(strings.NewReader(input), nil)
+
+	// act
+	occurrences, skipped := expandICSEvents(events, icsWindow{})
+
+	// assert
+	if len(occurrences) != 0 {
+		t.Fail()
+		t.Logf("expandICSEvents should not return any occurrences but returned %d", len(occurrences))
+	}
+
+	var reasons []string
+	for _, skippedEvent := range skipped {
+		reasons = append(reasons, skippedEvent.event.summary+": "+skippedEvent.reason)
+	}
+
+	expected := []string{
+		"Holiday: All-day events are not imported",
+		`Unknown zone: DTSTART: Unknown time zone "Mars/Olympus"`,
+		"Monthly: RRULE: The rule part BYMONTHDAY is not supported",
+	}
+
+	if strings.Join(reasons, "\n") != strings.Join(expected, "\n") {
+		t.Fail()
+		t.Logf("expandICSEvents should have skipped\n%s\nbut skipped\n%s", strings.Join(expected, "\n"), strings.Join(reasons, "\n"))
+	}
+}
+
+func Test_parseICSDuration_ValidDurations_DurationsAreReturned(t *testing.T) {
+	inputs := map[string]time.Duration{
+		"PT45M":     45 * time.Minute,
+		"PT1H30M":   90 * time.Minute,
+		"P1DT2H":    26 * time.Hour,
+		"P1W":       7 * 24 * time.Hour,
+		"PT1H0M15S": time.Hour + 15*time.Second,
+	}
+
+	for input, expected := range inputs {
+		// act
+		duration, err := parseICSDuration(input)
+
+		// assert
+		if err != nil || duration != expected {
+			t.Fail()
+			t.Logf("parseICSDuration(%q) should return %s but returned %s (%v)", input, expected, duration, err)
+		}
+	}
+}
+
+func Test_parseICSDuration_InvalidDurations_ErrorIsReturned(t *testing.T) {
+	inputs := []string{"", "P", "PT", "1H", "-PT1H", "PT1.5H"}
+
+	for _, input := range inputs {
+		// act
+		_, err := parseICSDuration(input)
+
+		// assert
+		if err == nil {
+			t.Fail()
+			t.Logf("parseICSDuration(%q) should return an error", input)
+		}
+	}
+}
+
+func Test_unescapeICSText_EscapedText_EscapeICSTextIsReverted(t *testing.T) {
+	// arrange
+	text := "Review; part 1, 2\nC:\\temp"
+
+	// act
+	result := unescapeICSText(escapeICSText(text))
+
+	// assert
+	if result != text {
+		t.Fail()
+		t.Logf("unescapeICSText should have returned %q but returned %q", text, result)
+	}
+}
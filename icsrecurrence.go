@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The supported frequencies of recurring calendar events.
+const (
+	icsFrequencyDaily   = "DAILY"
+	icsFrequencyWeekly  = "WEEKLY"
+	icsFrequencyMonthly = "MONTHLY"
+	icsFrequencyYearly  = "YEARLY"
+)
+
+// icsWeekdays maps the weekdays of the BYDAY rule part to time.Weekday.
+var icsWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// icsRecurrenceRule is a simple recurrence rule (RRULE) of a calendar event: a frequency with an interval,
+// limited by a count or an end date and optionally restricted to weekdays (daily and weekly rules only).
+type icsRecurrenceRule struct {
+	frequency string
+	interval  int
+	count     int
+	until     time.Time
+	weekdays  []time.Weekday
+}
+
+// parseICSRecurrenceRule parses the given RRULE value. A date without a time ("UNTIL=20160831") includes
+// the whole day in the given location. Returns an error for rule parts that are not supported.
+func parseICSRecurrenceRule(value string, location *time.Location) (icsRecurrenceRule, error) {
+	rule := icsRecurrenceRule{interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		keyValue := strings.SplitN(part, "=", 2)
+		if len(keyValue) != 2 {
+			return icsRecurrenceRule{}, fmt.Errorf("Invalid rule part %q", part)
+		}
+
+		key, partValue := strings.ToUpper(keyValue[0]), strings.ToUpper(keyValue[1])
+		switch key {
+		case "FREQ":
+			rule.frequency = partValue
+
+		case "INTERVAL", "COUNT":
+			number, numberError := strconv.Atoi(partValue)
+			if numberError != nil || number < 1 {
+				return icsRecurrenceRule{}, fmt.Errorf("The %s %q must be a positive number", key, partValue)
+			}
+
+			if key == "INTERVAL" {
+				rule.interval = number
+			} else {
+				rule.count = number
+			}
+
+		case "UNTIL":
+			until, allDay, dateError := parseICSDate(partValue, nil, location)
+			if dateError != nil {
+				return icsRecurrenceRule{}, dateError
+			}
+
+			if allDay {
+				until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+
+			rule.until = until
+
+		case "BYDAY":
+			for _, day := range strings.Split(partValue, ",") {
+				weekday, isWeekday := icsWeekdays[day]
+				if !isWeekday {
+					return icsRecurrenceRule{}, fmt.Errorf("Unsupported weekday %q", day)
+				}
+
+				rule.weekdays = append(rule.weekdays, weekday)
+			}
+
+		case "WKST":
+			// weeks always start on Monday
+
+		default:
+			return icsRecurrenceRule{}, fmt.Errorf("The rule part %s is not supported", key)
+		}
+	}
+
+	switch rule.frequency {
+	case icsFrequencyDaily, icsFrequencyWeekly, icsFrequencyMonthly, icsFrequencyYearly:
+	case "":
+		return icsRecurrenceRule{}, fmt.Errorf("The rule has no frequency")
+	default:
+		return icsRecurrenceRule{}, fmt.Errorf("The frequency %s is not supported", rule.frequency)
+	}
+
+	if rule.count > 0 && !rule.until.IsZero() {
+		return icsRecurrenceRule{}, fmt.Errorf("The rule must not have both COUNT and UNTIL")
+	}
+
+	if len(rule.weekdays) > 0 && rule.frequency != icsFrequencyDaily && rule.frequency != icsFrequencyWeekly {
+		return icsRecurrenceRule{}, fmt.Errorf("BYDAY is only supported for daily and weekly rules")
+	}
+
+	return rule, nil
+}
+
+// expand returns the starts of all occurrences of the rule for an event with the given start,
+// beginning with the first occurrence and ending at the given end date (optional).
+// The occurrences keep the wall clock time of the start in its time zone.
+// Returns an error if neither the rule nor the given end date limit the occurrences.
+func (rule icsRecurrenceRule) expand(start, end time.Time) ([]time.Time, error) {
+	if rule.count == 0 && rule.until.IsZero() && end.IsZero() {
+		return nil, fmt.Errorf("The event recurs forever. Limit the import with an end date")
+	}
+
+	// the last date of an occurrence
+	limit := rule.until
+	if !end.IsZero() && (limit.IsZero() || end.Before(limit)) {
+		limit = end
+	}
+
+	var occurrences []time.Time
+	for period := 0; ; period++ {
+		periodStart, candidates := rule.getPeriod(start, period)
+		if !limit.IsZero() && periodStart.After(limit) {
+			return occurrences, nil
+		}
+
+		for _, candidate := range candidates {
+			if candidate.Before(start) {
+				continue
+			}
+
+			if !limit.IsZero() && candidate.After(limit) {
+				return occurrences, nil
+			}
+
+			occurrences = append(occurrences, candidate)
+			if rule.count > 0 && len(occurrences) == rule.count {
+				return occurrences, nil
+			}
+
+			if len(occurrences) > maxICSOccurrences {
+				return nil, fmt.Errorf("The event has more than %d occurrences", maxICSOccurrences)
+			}
+		}
+	}
+}
+
+// getPeriod returns the beginning of the period with the given number (a day, week, month or year)
+// and the starts of the occurrences within that period.
+func (rule icsRecurrenceRule) getPeriod(start time.Time, period int) (time.Time, []time.Time) {
+	year, month, day := start.Date()
+	hour, minute, second := start.Clock()
+	location := start.Location()
+
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, start.Nanosecond(), location)
+	}
+
+	switch rule.frequency {
+	case icsFrequencyDaily:
+		date := at(year, month, day+period*rule.interval)
+		if len(rule.weekdays) > 0 && !containsWeekday(rule.weekdays, date.Weekday()) {
+			return date, nil
+		}
+
+		return date, []time.Time{date}
+
+	case icsFrequencyWeekly:
+		weekdays := rule.weekdays
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{start.Weekday()}
+		}
+
+		// weeks start on Monday
+		monday := day - (int(start.Weekday())+6)%7 + period*rule.interval*7
+
+		var dates []time.Time
+		for _, weekday := range weekdays {
+			dates = append(dates, at(year, month, monday+(int(weekday)+6)%7))
+		}
+
+		sort.Slice(dates, func(i, j int) bool {
+			return dates[i].Before(dates[j])
+		})
+
+		return at(year, month, monday), dates
+
+	case icsFrequencyMonthly:
+		date := at(year, month+time.Month(period*rule.interval), day)
+
+		// months without the day of the start are skipped
+		if date.Day() != day {
+			return at(year, month+time.Month(period*rule.interval), 1), nil
+		}
+
+		return date, []time.Time{date}
+	}
+
+	date := at(year+period*rule.interval, month, day)
+
+	// years without the day of the start (February 29) are skipped
+	if date.Month() != month {
+		return at(year+period*rule.interval, month, 1), nil
+	}
+
+	return date, []time.Time{date}
+}
+
+// containsWeekday returns true if the given weekdays contain the given weekday.
+func containsWeekday(weekdays []time.Weekday, weekday time.Weekday) bool {
+	for _, candidate := range weekdays {
+		if candidate == weekday {
+			return true
+		}
+	}
+
+	return false
+}
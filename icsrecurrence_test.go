@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// formatICSOccurrences returns the given dates in a short, comparable format.
+func formatICSOccurrences(dates []time.Time) string {
+	var result []string
+	for _, date := range dates {
+		result = append(result, date.Format("2006-01-02 15:04 MST"))
+	}
+
+	return fmt.Sprintf("%v", result)
+}
+
+func Test_icsRecurrenceRule_expand_ValidRules_OccurrencesAreReturned(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	inputs := []struct {
+		rule     string
+		start    time.Time
+		end      time.Time
+		expected string
+	}{
+		{
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    time.Date(2016, 8, 1, 9, 0, 0, 0, time.UTC),
+			expected: "[2016-08-01 09:00 UTC 2016-08-02 09:00 UTC 2016-08-03 09:00 UTC]",
+		},
+		{
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR,MO;UNTIL=20160819",
+			start:    time.Date(2016, 8, 1, 9, 0, 0, 0, time.UTC),
+			expected: "[2016-08-01 09:00 UTC 2016-08-05 09:00 UTC 2016-08-15 09:00 UTC 2016-08-19 09:00 UTC]",
+		},
+		{
+			rule:     "FREQ=MONTHLY;COUNT=3",
+			start:    time.Date(2016, 1, 31, 9, 0, 0, 0, time.UTC),
+			expected: "[2016-01-31 09:00 UTC 2016-03-31 09:00 UTC 2016-05-31 09:00 UTC]",
+		},
+		{
+			rule:     "FREQ=YEARLY",
+			start:    time.Date(2016, 2, 29, 9, 0, 0, 0, time.UTC),
+			end:      time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			expected: "[2016-02-29 09:00 UTC 2020-02-29 09:00 UTC]",
+		},
+		{
+			// the wall clock time is kept across the change to daylight saving time
+			rule:     "FREQ=WEEKLY;COUNT=2",
+			start:    time.Date(2016, 3, 21, 9, 0, 0, 0, berlin),
+			expected: "[2016-03-21 09:00 CET 2016-03-28 09:00 CEST]",
+		},
+	}
+
+	for _, input := range inputs {
+		// arrange
+		rule, parseError := parseICSRecurrenceRule(input.rule, time.UTC)
+		if parseError != nil {
+			t.Fail()
+			t.Logf("parseICSRecurrenceRule(%q) should not return an error but returned: %s", input.rule, parseError.Error())
+			continue
+		}
+
+		// act
+		occurrences, err := rule.expand(input.start, input.end)
+
+		// assert
+		if err != nil || formatICSOccurrences(occurrences) != input.expected {
+			t.Fail()
+			t.Logf("The rule %q should have returned %s but returned %s (%v)", input.rule, input.expected, formatICSOccurrences(occurrences), err)
+		}
+	}
+}
+
+func Test_icsRecurrenceRule_expand_RuleWithoutEnd_ErrorIsReturned(t *testing.T) {
+	// arrange
+	rule, _ := parseICSRecurrenceRule("FREQ=WEEKLY", time.UTC)
+
+	// act
+	_, err := rule.expand(time.Date(2016, 8, 1, 9, 0, 0, 0, time.UTC), time.Time{})
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("expand should return an error if the occurrences are not limited")
+	}
+}
+
+func Test_parseICSRecurrenceRule_UnsupportedRules_ErrorIsReturned(t *testing.T) {
+	inputs := []string{
+		"",
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;COUNT=2;UNTIL=20160801",
+		"FREQ=DAILY;INTERVAL=0",
+	}
+
+	for _, input := range inputs {
+		// act
+		_, err := parseICSRecurrenceRule(input, time.UTC)
+
+		// assert
+		if err == nil {
+			t.Fail()
+			t.Logf("parseICSRecurrenceRule(%q) should return an error", input)
+		}
+	}
+}
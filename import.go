@@ -3,9 +3,11 @@
 package main
 
 import (
-	"encoding/csv"
 	"fmt"
 	"io"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/andreaskoch/togglcsv/toggl"
 	"github.com/pkg/errors"
@@ -18,24 +20,173 @@ type CSVImporter interface {
 	Import(input io.Reader) error
 }
 
+// ImportSummary contains the number of time records of an import by outcome.
+type ImportSummary struct {
+	// Created contains the number of time records that have been created.
+	Created int
+
+	// Skipped contains the number of time records that have not been created because they exist already,
+	// have been created by a previous run or overlap other time records.
+	Skipped int
+
+	// Failed contains the number of time records that could not be created.
+	Failed int
+}
+
+// The ImportSummarizer interface is implemented by importers that count the time records of their last import.
+type ImportSummarizer interface {
+	// Summary returns the number of created, skipped and failed time records of the last import.
+	Summary() ImportSummary
+}
+
+// ImportOptions contains the settings for an import.
+type ImportOptions struct {
+	// DryRun prints the changes the import would apply instead of modifying the Toggl account.
+	DryRun bool
+
+	// JournalPath contains the path of the file that records all created time records (optional).
+	JournalPath string
+
+	// Resume continues a previous import by skipping all time records contained in the journal.
+	Resume bool
+
+	// Workers contains the number of time records that are created in parallel.
+	Workers int
+
+	// RequestsPerSecond limits the number of requests that are sent to the Toggl API.
+	RequestsPerSecond float64
+
+	// Stream reads, validates and creates the time records row by row instead of reading the whole input first.
+	Stream bool
+
+	// Atomic deletes all time entries, projects and clients created by the import if any time record fails.
+	Atomic bool
+
+	// OverlapPolicy defines how time records of the same workspace that overlap each other
+	// are handled: "error", "warn" (default), "skip-later" or "trim".
+	OverlapPolicy string
+
+	// Transformers modify the time records in the given order before they are imported.
+	Transformers []TimeRecordTransformer
+
+	// Location defines the time zone of dates without an offset
+	// and of the periods for SplitAt (optional; default: UTC).
+	Location *time.Location
+
+	// SplitAt cuts time records at the boundaries of the given period (day, week or month; empty for none).
+	SplitAt string
+
+	// Format selects the input format (csv, json, ndjson, xlsx or ics; default: csv).
+	Format string
+
+	// Sheet contains the name of the worksheet that is read from an xlsx input (default: the first worksheet).
+	Sheet string
+
+	// CalendarMapper assigns the workspace, project, client and tags of the events of an ics input.
+	CalendarMapper *CalendarMapper
+
+	// CalendarFrom and CalendarUntil limit the imported events of an ics input (zero dates for no limit).
+	CalendarFrom  time.Time
+	CalendarUntil time.Time
+}
+
 // TogglCSVImporter provides import and export functionality Toggl accounts.
 type TogglCSVImporter struct {
 	csvMapper            TimeRecordMapper
 	timeRecordRepository toggl.TimeRecorder
+	changePlanner        toggl.ChangePlanner
 	output               io.Writer
+
+	// format selects the input format (csv, json, ndjson, xlsx or ics; default: csv),
+	// jsonMapper reads the time records of the JSON formats and icsMapper the events of iCalendar files
+	format     string
+	jsonMapper *JSONTimeRecordMapper
+	icsMapper  *ICSTimeRecordMapper
+
+	// sheet contains the name of the worksheet of an xlsx input (optional)
+	sheet string
+
+	// dryRun disables all write operations
+	dryRun bool
+
+	// journalPath contains the path of the import journal (optional)
+	journalPath string
+
+	// resume continues the import recorded in the journal
+	resume bool
+
+	// workers contains the number of time records that are created in parallel
+	workers int
+
+	// stream creates the time records while the input is read
+	stream bool
+
+	// transformers modify the time records before they are imported
+	transformers []TimeRecordTransformer
+
+	// overlapPolicy defines how overlapping time records are handled
+	overlapPolicy string
+
+	// splitPeriod cuts time records at the boundaries of the given period (optional)
+	splitPeriod string
+
+	// location defines the time zone of the split periods (optional)
+	location *time.Location
+
+	// transaction rolls back all changes if the import fails (optional)
+	transaction toggl.Rollbacker
+
+	// created, skipped and failed count the time records of the last import
+	created int64
+	skipped int64
+	failed  int64
+}
+
+// Summary returns the number of created, skipped and failed time records of the last import.
+func (togglCSVImporter *TogglCSVImporter) Summary() ImportSummary {
+	return ImportSummary{
+		Created: int(atomic.LoadInt64(&togglCSVImporter.created)),
+		Skipped: int(atomic.LoadInt64(&togglCSVImporter.skipped)),
+		Failed:  int(atomic.LoadInt64(&togglCSVImporter.failed)),
+	}
 }
 
 // Import reads time records supplied via Stdin and imports them into a Toggl account.
 func (togglCSVImporter *TogglCSVImporter) Import(input io.Reader) error {
+	atomic.StoreInt64(&togglCSVImporter.created, 0)
+	atomic.StoreInt64(&togglCSVImporter.skipped, 0)
+	atomic.StoreInt64(&togglCSVImporter.failed, 0)
+
+	importInput := togglCSVImporter.importBatch
+	if togglCSVImporter.stream {
+		importInput = togglCSVImporter.importStream
+	}
+
+	// identify the input so that a journal is only resumed with the input it was created for
+	inputFingerprint := ""
+	if togglCSVImporter.journalPath != "" {
+		fingerprint, fingerprintInput, fingerprintError := getInputFingerprint(input, togglCSVImporter.stream)
+		if fingerprintError != nil {
+			return fingerprintError
+		}
 
-	// read the CSV data
-	csvReader := csv.NewReader(input)
-	rows, csvError := csvReader.ReadAll()
-	if csvError != nil {
-		return fmt.Errorf("Failed to read time records from CSV: %s", csvError.Error())
+		inputFingerprint = fingerprint
+		input = fingerprintInput
 	}
 
-	timeRecords, timeRecordsError := togglCSVImporter.csvMapper.GetTimeRecords(rows)
+	if err := importInput(input, inputFingerprint); err != nil {
+		return err
+	}
+
+	togglCSVImporter.reportTransformations()
+	return nil
+}
+
+// importBatch reads and validates all time records before any of them is created.
+// The input fingerprint identifies the input in the journal (optional).
+func (togglCSVImporter *TogglCSVImporter) importBatch(input io.Reader, inputFingerprint string) error {
+
+	timeRecords, positions, timeRecordsError := togglCSVImporter.readTimeRecords(input)
 	if timeRecordsError != nil {
 		return timeRecordsError
 	}
@@ -45,27 +196,227 @@ func (togglCSVImporter *TogglCSVImporter) Import(input io.Reader) error {
 		return nil
 	}
 
-	// upload the time entries to toggl
-	progressbar := pb.New(len(timeRecords))
-	progressbar.ShowTimeLeft = true
-	if togglCSVImporter.output != nil {
-		progressbar.Output = togglCSVImporter.output
-		progressbar.Start()
+	// handle time records that overlap each other as they are read,
+	// because rounding adjacent time records would create overlaps that are not in the input
+	readRecords := len(timeRecords)
+	timeRecords, overlapError := resolveOverlaps(timeRecords, positions, togglCSVImporter.overlapPolicy, togglCSVImporter.output)
+	if overlapError != nil {
+		return overlapError
 	}
 
-	// create the records
-	for recordIndex, record := range timeRecords {
+	atomic.AddInt64(&togglCSVImporter.skipped, int64(readRecords-len(timeRecords)))
+
+	for index, timeRecord := range timeRecords {
+		timeRecords[index] = togglCSVImporter.transform(timeRecord)
+	}
+
+	// split after the overlap detection so that the reported line numbers match the input
+	timeRecords = splitTimeRecords(timeRecords, togglCSVImporter.splitPeriod, togglCSVImporter.location)
+
+	// skip all time records that already exist in Toggl
+	newRecords, duplicatesError := togglCSVImporter.removeExistingTimeRecords(timeRecords)
+	if duplicatesError != nil {
+		return duplicatesError
+	}
+
+	atomic.AddInt64(&togglCSVImporter.skipped, int64(len(timeRecords)-len(newRecords)))
+	timeRecords = newRecords
 
-		if err := togglCSVImporter.timeRecordRepository.CreateTimeRecord(record); err != nil {
-			return errors.Wrap(err, fmt.Sprintf("Failed to create time record %d of %d", recordIndex+1, len(timeRecords)))
+	// only print the changes in dry-run mode
+	if togglCSVImporter.dryRun {
+		plan, planError := togglCSVImporter.changePlanner.GetChangePlan(timeRecords)
+		if planError != nil {
+			return errors.Wrap(planError, "Failed to calculate the changes of the import")
 		}
 
 		if togglCSVImporter.output != nil {
-			progressbar.Increment()
+			writeChangePlan(togglCSVImporter.output, plan)
+		}
+
+		return nil
+	}
+
+	// abort if all time records exist already
+	if len(timeRecords) == 0 {
+		return nil
+	}
+
+	journal, journalError := togglCSVImporter.openJournal(inputFingerprint)
+	if journalError != nil {
+		return journalError
+	}
+
+	if journal != nil {
+		defer journal.Close()
+	}
+
+	// upload the time entries to toggl
+	progressbar := togglCSVImporter.startProgressBar(int64(len(timeRecords)))
+
+	// create the records
+	recordIndex := 0
+	nextJob := func() (timeRecordJob, bool, error) {
+		if recordIndex >= len(timeRecords) {
+			return timeRecordJob{}, false, nil
+		}
+
+		job := timeRecordJob{
+			name:       fmt.Sprintf("time record %d of %d", recordIndex+1, len(timeRecords)),
+			timeRecord: timeRecords[recordIndex],
+		}
+
+		recordIndex++
+		return job, true, nil
+	}
+
+	if createError := togglCSVImporter.createTimeRecords(nextJob, journal, func() { progressbar.Increment() }); createError != nil {
+		return togglCSVImporter.rollback(createError)
+	}
+
+	togglCSVImporter.finishProgressBar(progressbar)
+
+	return nil
+}
+
+// readTimeRecords reads and validates all time records of the given input in the configured format.
+// Returns the time records and their positions in the input (e.g. "line 12" or "record 3" for JSON arrays).
+func (togglCSVImporter *TogglCSVImporter) readTimeRecords(input io.Reader) ([]toggl.TimeRecord, []string, error) {
+	if togglCSVImporter.format == formatJSON || togglCSVImporter.format == formatNDJSON {
+		timeRecords, lines, timeRecordsError := togglCSVImporter.jsonMapper.GetTimeRecords(input, togglCSVImporter.format)
+		if timeRecordsError != nil {
+			return nil, nil, timeRecordsError
+		}
+
+		// the records of a JSON array are counted instead of the lines
+		positionFormat := "line %d"
+		if togglCSVImporter.format == formatJSON {
+			positionFormat = "record %d"
+		}
+
+		return timeRecords, getPositions(positionFormat, lines), nil
+	}
+
+	if togglCSVImporter.format == formatICS {
+		timeRecords, lines, skipped, timeRecordsError := togglCSVImporter.icsMapper.GetTimeRecords(input)
+		if timeRecordsError != nil {
+			return nil, nil, fmt.Errorf("Failed to read time records from iCalendar: %s", timeRecordsError.Error())
+		}
+
+		togglCSVImporter.reportSkippedEvents(skipped)
+		return timeRecords, getPositions("line %d", lines), nil
+	}
+
+	// read the CSV data or the rows of the worksheet
+	var rows [][]string
+	var lines []int
+	positionFormat := "line %d"
+	if togglCSVImporter.format == formatXLSX {
+		xlsxRows, xlsxLines, xlsxError := readXLSXRows(input, togglCSVImporter.sheet, togglCSVImporter.location, togglCSVImporter.csvMapper.GetColumnNames())
+		if xlsxError != nil {
+			return nil, nil, fmt.Errorf("Failed to read time records from the workbook: %s", xlsxError.Error())
+		}
+
+		rows, lines = xlsxRows, xlsxLines
+		positionFormat = "row %d"
+	} else {
+		csvRows, csvLines, csvError := readCSVRows(input)
+		if csvError != nil {
+			return nil, nil, fmt.Errorf("Failed to read time records from CSV: %s", csvError.Error())
+		}
+
+		rows, lines = csvRows, csvLines
+	}
+
+	timeRecords, timeRecordsError := togglCSVImporter.csvMapper.GetTimeRecords(rows, lines)
+	if timeRecordsError != nil {
+		return nil, nil, timeRecordsError
+	}
+
+	// every row after the headline is a time record
+	if len(rows) > 0 && isTimeRecordHeadline(rows[0]) {
+		lines = lines[1:]
+	}
+
+	return timeRecords, getPositions(positionFormat, lines), nil
+}
+
+// getPositions returns the positions of time records in the input
+// for the given format (e.g. "line %d") and line numbers.
+func getPositions(format string, lines []int) []string {
+	positions := make([]string, len(lines))
+	for index, line := range lines {
+		positions[index] = fmt.Sprintf(format, line)
+	}
+
+	return positions
+}
+
+// reportSkippedEvents prints the calendar events that are not imported.
+func (togglCSVImporter *TogglCSVImporter) reportSkippedEvents(skipped []icsSkippedEvent) {
+	if togglCSVImporter.output == nil {
+		return
+	}
+
+	for _, skippedEvent := range skipped {
+		fmt.Fprintf(togglCSVImporter.output, "Skipping event %q (line %d) at %s: %s\n", skippedEvent.event.summary, skippedEvent.event.line, skippedEvent.start.Format(overlapTimeFormat), skippedEvent.reason)
+	}
+}
+
+// transform applies all transformers to the given time record.
+func (togglCSVImporter *TogglCSVImporter) transform(timeRecord toggl.TimeRecord) toggl.TimeRecord {
+	for _, transformer := range togglCSVImporter.transformers {
+		timeRecord = transformer.Transform(timeRecord)
+	}
+
+	return timeRecord
+}
+
+// reportTransformations prints the summaries of all transformers that report their changes.
+func (togglCSVImporter *TogglCSVImporter) reportTransformations() {
+	if togglCSVImporter.output == nil {
+		return
+	}
+
+	for _, transformer := range togglCSVImporter.transformers {
+		if reporter, isReporter := transformer.(TransformationReporter); isReporter {
+			reporter.Report(togglCSVImporter.output)
 		}
+	}
+}
+
+// timeRecordJob contains a time record that is waiting to be created.
+type timeRecordJob struct {
+	// name identifies the time record in error messages
+	name string
+
+	timeRecord toggl.TimeRecord
+}
+
+// openJournal opens the configured import journal for the input with the given fingerprint.
+// Returns nil if no journal is used.
+func (togglCSVImporter *TogglCSVImporter) openJournal(inputFingerprint string) (*importJournal, error) {
+	if togglCSVImporter.journalPath == "" {
+		return nil, nil
+	}
+
+	return openImportJournal(togglCSVImporter.journalPath, togglCSVImporter.resume, inputFingerprint)
+}
 
+// startProgressBar creates a progress bar with the given total and prints it if an output is configured.
+// A total of 0 displays the progress without a percentage.
+func (togglCSVImporter *TogglCSVImporter) startProgressBar(total int64) *pb.ProgressBar {
+	progressbar := pb.New64(total)
+	progressbar.ShowTimeLeft = true
+	if togglCSVImporter.output != nil {
+		progressbar.Output = togglCSVImporter.output
+		progressbar.Start()
 	}
 
+	return progressbar
+}
+
+// finishProgressBar stops the given progress bar.
+func (togglCSVImporter *TogglCSVImporter) finishProgressBar(progressbar *pb.ProgressBar) {
 	if togglCSVImporter.output != nil {
 		// FinishPrint writes to os.Stdout
 		// see:
@@ -74,6 +425,147 @@ func (togglCSVImporter *TogglCSVImporter) Import(input io.Reader) error {
 		// progressbar.FinishPrint("Import complete.")
 		progressbar.Finish()
 	}
+}
+
+// createTimeRecords creates the time records returned by the given nextJob function
+// using the configured number of parallel workers. nextJob returns false once all jobs have been returned.
+// No further time records are created after the first error and the first error is returned.
+func (togglCSVImporter *TogglCSVImporter) createTimeRecords(nextJob func() (timeRecordJob, bool, error), journal *importJournal, progress func()) error {
+
+	workers := togglCSVImporter.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan timeRecordJob)
+	abort := make(chan struct{})
+
+	var firstError error
+	var abortOnce sync.Once
+	var waitGroup sync.WaitGroup
+
+	fail := func(err error) {
+		abortOnce.Do(func() {
+			firstError = err
+			close(abort)
+		})
+	}
+
+	for worker := 0; worker < workers; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+
+			for job := range jobs {
+
+				// don't start new records after an error
+				select {
+				case <-abort:
+					continue
+				default:
+				}
+
+				if err := togglCSVImporter.createTimeRecord(job, journal); err != nil {
+					fail(err)
+					continue
+				}
+
+				if togglCSVImporter.output != nil {
+					progress()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for {
+		job, ok, jobError := nextJob()
+		if jobError != nil {
+			fail(jobError)
+			break
+		}
+
+		if !ok {
+			break
+		}
+
+		select {
+		case jobs <- job:
+		case <-abort:
+			break dispatch
+		}
+	}
+
+	close(jobs)
+	waitGroup.Wait()
+
+	return firstError
+}
+
+// createTimeRecord creates the time record of the given job unless it is recorded in the journal.
+func (togglCSVImporter *TogglCSVImporter) createTimeRecord(job timeRecordJob, journal *importJournal) error {
+	record := job.timeRecord
+
+	// skip records that have been created by a previous run
+	if journal != nil && journal.IsCommitted(record) {
+		atomic.AddInt64(&togglCSVImporter.skipped, 1)
+		return nil
+	}
+
+	createdRecord, err := togglCSVImporter.timeRecordRepository.CreateTimeRecord(record)
+	if err != nil {
+		atomic.AddInt64(&togglCSVImporter.failed, 1)
+
+		if journal != nil {
+			return errors.Wrap(err, fmt.Sprintf("Failed to create %s (use --resume to continue the import)", job.name))
+		}
+
+		return errors.Wrap(err, fmt.Sprintf("Failed to create %s", job.name))
+	}
+
+	atomic.AddInt64(&togglCSVImporter.created, 1)
+
+	if journal != nil {
+		if journalError := journal.Commit(record, createdRecord.ID); journalError != nil {
+			return errors.Wrap(journalError, fmt.Sprintf("Created %s as time entry %d but could not record it in the journal", job.name, createdRecord.ID))
+		}
+	}
 
 	return nil
 }
+
+// rollback undoes all changes of the import if it runs in a transaction
+// and returns the given import error with the result of the rollback.
+func (togglCSVImporter *TogglCSVImporter) rollback(importError error) error {
+	if togglCSVImporter.transaction == nil {
+		return importError
+	}
+
+	if rollbackError := togglCSVImporter.transaction.Rollback(); rollbackError != nil {
+		return fmt.Errorf("%s. The rollback failed and the account must be cleaned up manually: %s", importError.Error(), rollbackError.Error())
+	}
+
+	return errors.Wrap(importError, "All changes have been rolled back")
+}
+
+// removeExistingTimeRecords returns all given time records that don't exist in Toggl yet.
+func (togglCSVImporter *TogglCSVImporter) removeExistingTimeRecords(timeRecords []toggl.TimeRecord) ([]toggl.TimeRecord, error) {
+
+	// extend the span by one day in each direction because
+	// the time ranges are normalized to full days in UTC
+	start, stop := getTimeSpan(timeRecords)
+	start = start.UTC().AddDate(0, 0, -1)
+	stop = stop.UTC().AddDate(0, 0, 1)
+
+	existingTimeRecords, existingTimeRecordsError := togglCSVImporter.timeRecordRepository.GetTimeRecords(start, stop)
+	if existingTimeRecordsError != nil {
+		return nil, errors.Wrap(existingTimeRecordsError, "Failed to retrieve the existing time records")
+	}
+
+	newTimeRecords, duplicates := removeDuplicates(timeRecords, existingTimeRecords)
+	if duplicates > 0 && togglCSVImporter.output != nil {
+		fmt.Fprintf(togglCSVImporter.output, "Skipping %d of %d time records because they already exist.\n", duplicates, len(timeRecords))
+	}
+
+	return newTimeRecords, nil
+}
@@ -5,18 +5,37 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/andreaskoch/togglapi/date"
 	"github.com/andreaskoch/togglcsv/toggl"
 )
 
+type mockChangePlanner struct {
+	getChangePlan func(timeRecords []toggl.TimeRecord) (toggl.ChangePlan, error)
+}
+
+func (planner *mockChangePlanner) GetChangePlan(timeRecords []toggl.TimeRecord) (toggl.ChangePlan, error) {
+	return planner.getChangePlan(timeRecords)
+}
+
+type mockRollbacker struct {
+	rollback func() error
+}
+
+func (rollbacker *mockRollbacker) Rollback() error {
+	return rollbacker.rollback()
+}
+
 func Test_Import_NoInput_NoErrorIsReturned(t *testing.T) {
 	// arrange
 	timeRecords := []toggl.TimeRecord{}
 
 	timeRecordMapper := &mockCSVTimeRecordMapper{
 		columnNames: []string{"Start", "Stop", "..."},
-		getTimeRecords: func(rows [][]string) ([]toggl.TimeRecord, error) {
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
 			return timeRecords, nil
 		},
 	}
@@ -47,7 +66,7 @@ func Test_Import_InvalidCSV_ErrorIsReturned(t *testing.T) {
 
 	timeRecordMapper := &mockCSVTimeRecordMapper{
 		columnNames: []string{"Start", "Stop", "..."},
-		getTimeRecords: func(rows [][]string) ([]toggl.TimeRecord, error) {
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
 			return timeRecords, nil
 		},
 	}
@@ -76,7 +95,7 @@ func Test_Import_TimeRecordMapperReturnsError_ErrorIsReturned(t *testing.T) {
 	// arrange
 	timeRecordMapper := &mockCSVTimeRecordMapper{
 		columnNames: []string{"Start", "Stop", "..."},
-		getTimeRecords: func(rows [][]string) ([]toggl.TimeRecord, error) {
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
 			return nil, fmt.Errorf("Some error")
 		},
 	}
@@ -109,14 +128,17 @@ func Test_Import_CreateTimeRecordReturnsError_ErrorIsReturned(t *testing.T) {
 
 	timeRecordMapper := &mockCSVTimeRecordMapper{
 		columnNames: []string{"Start", "Stop", "..."},
-		getTimeRecords: func(rows [][]string) ([]toggl.TimeRecord, error) {
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
 			return timeRecords, nil
 		},
 	}
 
 	timeRecordRepository := &mockTimeRecordRepository{
-		createTimeRecord: func(timeRecord toggl.TimeRecord) error {
-			return fmt.Errorf("Some error")
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			return toggl.TimeRecord{}, fmt.Errorf("Some error")
 		},
 	}
 
@@ -146,14 +168,17 @@ func Test_Import_CreateTimeRecordNoError_NoErrorIsReturned(t *testing.T) {
 
 	timeRecordMapper := &mockCSVTimeRecordMapper{
 		columnNames: []string{"Start", "Stop", "..."},
-		getTimeRecords: func(rows [][]string) ([]toggl.TimeRecord, error) {
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
 			return timeRecords, nil
 		},
 	}
 
 	timeRecordRepository := &mockTimeRecordRepository{
-		createTimeRecord: func(timeRecord toggl.TimeRecord) error {
-			return nil
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			return timeRecord, nil
 		},
 	}
 
@@ -183,7 +208,7 @@ func Test_Import_CreateTimeRecordNoError_ProgressBarIsWritten(t *testing.T) {
 
 	timeRecordMapper := &mockCSVTimeRecordMapper{
 		columnNames: []string{"Start", "Stop", "..."},
-		getTimeRecords: func(rows [][]string) ([]toggl.TimeRecord, error) {
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
 			return timeRecords, nil
 		},
 	}
@@ -192,8 +217,11 @@ func Test_Import_CreateTimeRecordNoError_ProgressBarIsWritten(t *testing.T) {
 	output := bufio.NewWriter(&outputBuffer)
 
 	timeRecordRepository := &mockTimeRecordRepository{
-		createTimeRecord: func(timeRecord toggl.TimeRecord) error {
-			return nil
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			return timeRecord, nil
 		},
 	}
 
@@ -216,3 +244,817 @@ func Test_Import_CreateTimeRecordNoError_ProgressBarIsWritten(t *testing.T) {
 		t.Logf("Import should write a progress bar to the output but didn't")
 	}
 }
+
+func Test_Import_DryRun_NoTimeRecordsAreCreated_PlanIsWritten(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{},
+		toggl.TimeRecord{},
+	}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return timeRecords, nil
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			t.Fail()
+			t.Logf("Import should not create time records in dry-run mode")
+			return timeRecord, nil
+		},
+	}
+
+	changePlanner := &mockChangePlanner{
+		getChangePlan: func(timeRecords []toggl.TimeRecord) (toggl.ChangePlan, error) {
+			return toggl.ChangePlan{TimeRecords: len(timeRecords)}, nil
+		},
+	}
+
+	var outputBuffer bytes.Buffer
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		changePlanner:        changePlanner,
+		output:               &outputBuffer,
+		dryRun:               true,
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Import should not return an error in dry-run mode: %s", err)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "Time entries to create: 2") {
+		t.Fail()
+		t.Logf("Import should have written the change plan but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_Import_DryRun_PlannerReturnsError_ErrorIsReturned(t *testing.T) {
+	// arrange
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return []toggl.TimeRecord{toggl.TimeRecord{}}, nil
+		},
+	}
+
+	changePlanner := &mockChangePlanner{
+		getChangePlan: func(timeRecords []toggl.TimeRecord) (toggl.ChangePlan, error) {
+			return toggl.ChangePlan{}, fmt.Errorf("Some error")
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		changePlanner:        changePlanner,
+		dryRun:               true,
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("Import should return an error if the change plan cannot be calculated")
+	}
+}
+
+func Test_Import_TimeRecordsExistAlready_DuplicatesAreSkipped(t *testing.T) {
+	// arrange
+	existingTimeRecord := toggl.TimeRecord{
+		Start:         time.Date(2016, 8, 12, 7, 0, 0, 0, time.UTC),
+		Stop:          time.Date(2016, 8, 12, 8, 0, 0, 0, time.UTC),
+		WorkspaceName: "Workspace",
+		ProjectName:   "Project",
+		Description:   "Retrospective",
+	}
+
+	newTimeRecord := toggl.TimeRecord{
+		Start:         time.Date(2016, 8, 12, 8, 0, 0, 0, time.UTC),
+		Stop:          time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+		WorkspaceName: "Workspace",
+		ProjectName:   "Project",
+		Description:   "Sprint Review",
+	}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return []toggl.TimeRecord{existingTimeRecord, newTimeRecord}, nil
+		},
+	}
+
+	var createdTimeRecords []toggl.TimeRecord
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			if start.After(existingTimeRecord.Start) || stop.Before(newTimeRecord.Stop) {
+				t.Fail()
+				t.Logf("Import should fetch the existing time records for the whole time span of the input but requested %s - %s", start, stop)
+			}
+
+			return []toggl.TimeRecord{existingTimeRecord}, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdTimeRecords = append(createdTimeRecords, timeRecord)
+			return timeRecord, nil
+		},
+	}
+
+	var outputBuffer bytes.Buffer
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		output:               &outputBuffer,
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if err != nil {
+		t.Fatalf("Import should not return an error: %s", err)
+	}
+
+	if len(createdTimeRecords) != 1 || createdTimeRecords[0].Description != "Sprint Review" {
+		t.Fail()
+		t.Logf("Import should only create the new time record but created: %#v", createdTimeRecords)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "Skipping 1 of 2 time records") {
+		t.Fail()
+		t.Logf("Import should report the number of skipped duplicates but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_Import_Summary_CreatedSkippedAndFailedRecordsAreCounted(t *testing.T) {
+	// arrange
+	start := time.Date(2016, 8, 12, 7, 0, 0, 0, time.UTC)
+	existingTimeRecord := toggl.TimeRecord{Start: start, Stop: start.Add(time.Hour), WorkspaceName: "Workspace", Description: "Retrospective"}
+	newTimeRecord := toggl.TimeRecord{Start: start.Add(time.Hour), Stop: start.Add(2 * time.Hour), WorkspaceName: "Workspace", Description: "Sprint Review"}
+	invalidTimeRecord := toggl.TimeRecord{Start: start.Add(2 * time.Hour), Stop: start.Add(3 * time.Hour), WorkspaceName: "Unknown", Description: "Planning"}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return []toggl.TimeRecord{existingTimeRecord, newTimeRecord, invalidTimeRecord}, nil
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return []toggl.TimeRecord{existingTimeRecord}, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			if timeRecord.WorkspaceName == "Unknown" {
+				return toggl.TimeRecord{}, fmt.Errorf("Workspace not found")
+			}
+
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("Import should return the error of the failed time record")
+	}
+
+	expected := ImportSummary{Created: 1, Skipped: 1, Failed: 1}
+	if importer.Summary() != expected {
+		t.Fail()
+		t.Logf("Summary should have returned %#v but returned %#v", expected, importer.Summary())
+	}
+}
+
+func Test_Import_ExistingTimeRecordsCannotBeRetrieved_ErrorIsReturned(t *testing.T) {
+	// arrange
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return []toggl.TimeRecord{toggl.TimeRecord{}}, nil
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, fmt.Errorf("Some error")
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("Import should return an error if the existing time records cannot be retrieved")
+	}
+}
+
+func Test_Import_Resume_JournaledRecordsAreSkipped(t *testing.T) {
+	// arrange
+	journalPath, cleanup := getTestJournalPath(t)
+	defer cleanup()
+
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{Description: "First"},
+		toggl.TimeRecord{Description: "Second"},
+	}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return timeRecords, nil
+		},
+	}
+
+	var createdTimeRecords []toggl.TimeRecord
+	failSecondRecord := true
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			if timeRecord.Description == "Second" && failSecondRecord {
+				failSecondRecord = false
+				return toggl.TimeRecord{}, fmt.Errorf("Some error")
+			}
+
+			createdTimeRecords = append(createdTimeRecords, timeRecord)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		journalPath:          journalPath,
+	}
+
+	// act
+	firstError := importer.Import(strings.NewReader(``))
+
+	importer.resume = true
+	secondError := importer.Import(strings.NewReader(``))
+
+	// assert
+	if firstError == nil {
+		t.Fail()
+		t.Logf("The first import should have failed")
+	}
+
+	if secondError != nil {
+		t.Fail()
+		t.Logf("The resumed import should have succeeded but returned an error: %s", secondError)
+	}
+
+	if len(createdTimeRecords) != 2 || createdTimeRecords[1].Description != "Second" {
+		t.Fail()
+		t.Logf("The resumed import should only have created the second time record: %#v", createdTimeRecords)
+	}
+}
+
+func Test_Import_MultipleWorkers_AllTimeRecordsAreCreated(t *testing.T) {
+	// arrange
+	var timeRecords []toggl.TimeRecord
+	for i := 0; i < 50; i++ {
+		timeRecords = append(timeRecords, toggl.TimeRecord{Description: fmt.Sprintf("Record %d", i)})
+	}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return timeRecords, nil
+		},
+	}
+
+	var mutex sync.Mutex
+	createdTimeRecords := make(map[string]int)
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			createdTimeRecords[timeRecord.Description]++
+			return timeRecord, nil
+		},
+	}
+
+	var outputBuffer bytes.Buffer
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		output:               &outputBuffer,
+		workers:              8,
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if err != nil {
+		t.Fatalf("Import should not return an error: %s", err)
+	}
+
+	if len(createdTimeRecords) != len(timeRecords) {
+		t.Fail()
+		t.Logf("Import should have created %d time records but created %d", len(timeRecords), len(createdTimeRecords))
+	}
+
+	for description, count := range createdTimeRecords {
+		if count != 1 {
+			t.Fail()
+			t.Logf("Import should have created %q once but created it %d times", description, count)
+		}
+	}
+}
+
+func Test_Import_MultipleWorkers_CreateFails_ErrorIsReturned(t *testing.T) {
+	// arrange
+	var timeRecords []toggl.TimeRecord
+	for i := 0; i < 50; i++ {
+		timeRecords = append(timeRecords, toggl.TimeRecord{Description: fmt.Sprintf("Record %d", i)})
+	}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return timeRecords, nil
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			if timeRecord.Description == "Record 10" {
+				return toggl.TimeRecord{}, fmt.Errorf("Some error")
+			}
+
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		workers:              4,
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "Failed to create time record 11 of 50") {
+		t.Fail()
+		t.Logf("Import should return the error of the failed time record but returned: %v", err)
+	}
+}
+
+func Test_Import_Atomic_CreateFails_ChangesAreRolledBack(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{Description: "Record 1"},
+		toggl.TimeRecord{Description: "Record 2"},
+	}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return timeRecords, nil
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			if timeRecord.Description == "Record 2" {
+				return toggl.TimeRecord{}, fmt.Errorf("Some error")
+			}
+
+			return timeRecord, nil
+		},
+	}
+
+	rollbacks := 0
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		transaction: &mockRollbacker{
+			rollback: func() error {
+				rollbacks++
+				return nil
+			},
+		},
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if rollbacks != 1 {
+		t.Fail()
+		t.Logf("Import should have rolled back the changes once but rolled back %d times", rollbacks)
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "All changes have been rolled back") {
+		t.Fail()
+		t.Logf("Import should report that the changes have been rolled back but returned: %v", err)
+	}
+}
+
+func Test_Import_Atomic_RollbackFails_ErrorIsReturned(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{Description: "Record 1"},
+	}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return timeRecords, nil
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			return toggl.TimeRecord{}, fmt.Errorf("Some error")
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		transaction: &mockRollbacker{
+			rollback: func() error {
+				return fmt.Errorf("Failed to delete project 1")
+			},
+		},
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "The rollback failed") || !strings.Contains(err.Error(), "project 1") {
+		t.Fail()
+		t.Logf("Import should report the failed rollback but returned: %v", err)
+	}
+}
+
+func Test_Import_Atomic_NoErrors_NothingIsRolledBack(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{Description: "Record 1"},
+	}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return timeRecords, nil
+		},
+	}
+
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			return timeRecord, nil
+		},
+	}
+
+	rollbacks := 0
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		transaction: &mockRollbacker{
+			rollback: func() error {
+				rollbacks++
+				return nil
+			},
+		},
+	}
+
+	// act
+	err := importer.Import(strings.NewReader(``))
+
+	// assert
+	if err != nil || rollbacks != 0 {
+		t.Fail()
+		t.Logf("Import should neither fail nor roll back but returned %v after %d rollbacks", err, rollbacks)
+	}
+}
+
+type mockTimeRecordTransformer struct {
+	transform func(timeRecord toggl.TimeRecord) toggl.TimeRecord
+}
+
+func (transformer *mockTimeRecordTransformer) Transform(timeRecord toggl.TimeRecord) toggl.TimeRecord {
+	return transformer.transform(timeRecord)
+}
+
+func Test_Import_Transformers_TransformedRecordsAreCreated(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{ProjectName: "Website"},
+	}
+
+	timeRecordMapper := &mockCSVTimeRecordMapper{
+		columnNames: []string{"Start", "Stop", "..."},
+		getTimeRecords: func(rows [][]string, lines []int) ([]toggl.TimeRecord, error) {
+			return timeRecords, nil
+		},
+	}
+
+	var createdTimeRecords []toggl.TimeRecord
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdTimeRecords = append(createdTimeRecords, timeRecord)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            timeRecordMapper,
+		timeRecordRepository: timeRecordRepository,
+		transformers: []TimeRecordTransformer{
+			&mockTimeRecordTransformer{
+				transform: func(timeRecord toggl.TimeRecord) toggl.TimeRecord {
+					timeRecord.ProjectName = "ACME Web Relaunch"
+					return timeRecord
+				},
+			},
+		},
+	}
+
+	// act
+	importer.Import(strings.NewReader(``))
+
+	// assert
+	if len(createdTimeRecords) != 1 || createdTimeRecords[0].ProjectName != "ACME Web Relaunch" {
+		t.Fail()
+		t.Logf("Import should have created the transformed time record but created: %#v", createdTimeRecords)
+	}
+}
+
+func Test_Import_OverlapPolicyError_NoTimeRecordsAreCreated(t *testing.T) {
+	// arrange
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			t.Fail()
+			t.Logf("Import should not create time records if they overlap and the policy is %q", overlapPolicyError)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		overlapPolicy:        overlapPolicyError,
+	}
+
+	input := `Start,Stop,Workspace Name,Project Name,Client Name,Tag(s),Description
+2016-08-12T09:00:00+00:00,2016-08-12T10:00:00+00:00,Workspace,,,,"Earlier
+
+with a blank line"
+
+2016-08-12T09:30:00+00:00,2016-08-12T11:00:00+00:00,Workspace,,,,Later`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "line 2 ") || !strings.Contains(err.Error(), "line 6 ") {
+		t.Fail()
+		t.Logf("Import should have returned the overlapping lines 2 and 6 but returned: %v", err)
+	}
+}
+
+func Test_Import_RoundingCreatesOverlap_OverlapPolicyError_TimeRecordsAreCreated(t *testing.T) {
+	// arrange
+	var createdTimeRecords []toggl.TimeRecord
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdTimeRecords = append(createdTimeRecords, timeRecord)
+			return timeRecord, nil
+		},
+	}
+
+	rounder, _ := readTimeRecordRounder(strings.NewReader(`,,15m,up,duration`))
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		overlapPolicy:        overlapPolicyError,
+		transformers:         []TimeRecordTransformer{rounder},
+	}
+
+	input := `2016-08-12T09:00:00+00:00,2016-08-12T09:50:00+00:00,Workspace,,,,Earlier
+2016-08-12T09:50:00+00:00,2016-08-12T10:30:00+00:00,Workspace,,,,Later`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Import should not report overlaps that only the rounding created but returned: %s", err.Error())
+	}
+
+	stops := make(map[string]string)
+	for _, timeRecord := range createdTimeRecords {
+		stops[timeRecord.Description] = timeRecord.Stop.Format("15:04")
+	}
+
+	if len(createdTimeRecords) != 2 || stops["Earlier"] != "10:00" || stops["Later"] != "10:35" {
+		t.Fail()
+		t.Logf("Import should have created both rounded time records but created: %#v", createdTimeRecords)
+	}
+}
+
+func Test_Import_FormatJSON_TimeRecordsAreCreated(t *testing.T) {
+	// arrange
+	var createdDescriptions []string
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdDescriptions = append(createdDescriptions, timeRecord.Description)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		format:               formatJSON,
+		jsonMapper:           NewJSONTimeRecordMapper(date.NewISO8601Formatter()),
+	}
+
+	input := `[
+  {"start": "2015-03-26T08:00:00+01:00", "stop": "2015-03-26T11:30:00+01:00", "workspace": "Workspace", "description": "Record 1", "tags": ["a", "b,c"]},
+  {"start": "2015-03-27T08:00:00+01:00", "stop": "2015-03-27T11:30:00+01:00", "workspace": "Workspace", "description": "Record 2"}
+]`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Import should not return an error but returned: %s", err.Error())
+	}
+
+	if fmt.Sprintf("%v", createdDescriptions) != "[Record 1 Record 2]" {
+		t.Fail()
+		t.Logf("Import should have created both time records but created: %v", createdDescriptions)
+	}
+}
+
+func Test_Import_FormatXLSX_TimeRecordsAreCreated(t *testing.T) {
+	// arrange
+	var createdDescriptions []string
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdDescriptions = append(createdDescriptions, timeRecord.Description)
+			return timeRecord, nil
+		},
+	}
+
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+
+	var workbook bytes.Buffer
+	writer := newXLSXTimeRecordWriter(&workbook, csvMapper, time.UTC)
+	writer.Write(getJSONTestTimeRecord("Record 1"))
+	writer.Write(getJSONTestTimeRecord("Record 2"))
+	writer.Close()
+
+	importer := TogglCSVImporter{
+		csvMapper:            csvMapper,
+		timeRecordRepository: timeRecordRepository,
+		format:               formatXLSX,
+		sheet:                xlsxSheetName,
+	}
+
+	// act
+	err := importer.Import(bytes.NewReader(workbook.Bytes()))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Import should not return an error but returned: %s", err.Error())
+	}
+
+	if fmt.Sprintf("%v", createdDescriptions) != "[Record 1 Record 2]" {
+		t.Fail()
+		t.Logf("Import should have created both time records but created: %v", createdDescriptions)
+	}
+}
+
+func Test_Import_FormatICS_MappedEventsAreCreatedAndUnmappedEventsAreReported(t *testing.T) {
+	// arrange
+	var createdDescriptions []string
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdDescriptions = append(createdDescriptions, timeRecord.Description)
+			return timeRecord, nil
+		},
+	}
+
+	calendarMapper, _ := readCalendarMapper(strings.NewReader("summary,Standup,Work,Scrum"))
+
+	var output bytes.Buffer
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		output:               &output,
+		format:               formatICS,
+		icsMapper:            NewICSTimeRecordMapper(calendarMapper, time.Time{}, time.Date(2016, 9, 1, 0, 0, 0, 0, time.UTC), nil),
+	}
+
+	input := getICSTestCalendar("Work",
+		"UID:1\r\nSUMMARY:Standup\r\nDTSTART:20160801T090000Z\r\nDTEND:20160801T091500Z\r\nRRULE:FREQ=DAILY;COUNT=2",
+		"UID:2\r\nSUMMARY:Lunch\r\nDTSTART:20160801T120000Z\r\nDTEND:20160801T130000Z",
+	)
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Import should not return an error but returned: %s", err.Error())
+	}
+
+	if fmt.Sprintf("%v", createdDescriptions) != "[Standup Standup]" {
+		t.Fail()
+		t.Logf("Import should have created both standups but created: %v", createdDescriptions)
+	}
+
+	expectedReport := `Skipping event "Lunch" (line 11) at 2016-08-01 12:00: No rule of the calendar map matches`
+	if !strings.Contains(output.String(), expectedReport) {
+		t.Fail()
+		t.Logf("Import should have reported %q but wrote: %s", expectedReport, output.String())
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stdinFileName is the file name that reads the CSV input from stdin.
+const stdinFileName = "-"
+
+// expandImportFiles returns the names of all files matching the given file names and glob patterns.
+// The order of the given arguments is kept; the matches of a glob pattern are sorted by name.
+// Returns an error if a glob pattern is invalid or doesn't match any file.
+func expandImportFiles(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+
+		if pattern == stdinFileName || !strings.ContainsAny(pattern, "*?[") {
+			files = append(files, pattern)
+			continue
+		}
+
+		matches, globError := filepath.Glob(pattern)
+		if globError != nil {
+			return nil, fmt.Errorf("Invalid file pattern %q: %s", pattern, globError.Error())
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No files match the pattern %q", pattern)
+		}
+
+		files = append(files, matches...)
+	}
+
+	return files, nil
+}
+
+// importFiles imports the given files one after another with a new importer for each file
+// and prints the number of created, skipped and failed time records of every file.
+// A failed file does not stop the import of the remaining files.
+// Returns false if any of the files could not be imported.
+func importFiles(newImporter func() CSVImporter, files []string, stdin io.Reader, output, errorOutput io.Writer) bool {
+	var failedFiles []string
+
+	for _, file := range files {
+		fmt.Fprintf(output, "Importing %s\n", file)
+
+		importer := newImporter()
+		if importError := importFile(importer, file, stdin); importError != nil {
+			fmt.Fprintf(errorOutput, "Error: %s: %s\n", file, importError.Error())
+			fmt.Fprintf(output, "Failed to import %s%s\n", file, getImportSummaryText(importer))
+			failedFiles = append(failedFiles, file)
+			continue
+		}
+
+		fmt.Fprintf(output, "Imported %s%s\n", file, getImportSummaryText(importer))
+	}
+
+	fmt.Fprintf(output, "Imported %d of %d files.\n", len(files)-len(failedFiles), len(files))
+	if len(failedFiles) > 0 {
+		fmt.Fprintf(errorOutput, "Failed files: %s\n", strings.Join(failedFiles, ", "))
+		return false
+	}
+
+	return true
+}
+
+// getImportSummaryText returns the number of created, skipped and failed time records of the last import
+// of the given importer (e.g. ": 12 created, 3 skipped, 0 failed") or an empty string if the importer doesn't count them.
+func getImportSummaryText(importer CSVImporter) string {
+	summarizer, isSummarizer := importer.(ImportSummarizer)
+	if !isSummarizer {
+		return ""
+	}
+
+	summary := summarizer.Summary()
+	return fmt.Sprintf(": %d created, %d skipped, %d failed", summary.Created, summary.Skipped, summary.Failed)
+}
+
+// importFile imports the file with the given name using the given importer.
+// The file name "-" imports the given stdin.
+func importFile(importer CSVImporter, file string, stdin io.Reader) error {
+	if file == stdinFileName {
+		return importer.Import(stdin)
+	}
+
+	input, openError := os.Open(file)
+	if openError != nil {
+		return openError
+	}
+
+	defer input.Close()
+
+	return importer.Import(input)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func getTestImportDirectory(t *testing.T, files map[string]string) (string, func()) {
+	directory, directoryError := ioutil.TempDir("", "togglcsv-import")
+	if directoryError != nil {
+		t.Fatalf("Failed to create a temporary directory: %s", directoryError)
+	}
+
+	for name, content := range files {
+		if writeError := ioutil.WriteFile(filepath.Join(directory, name), []byte(content), 0644); writeError != nil {
+			t.Fatalf("Failed to create the test file %q: %s", name, writeError)
+		}
+	}
+
+	return directory, func() { os.RemoveAll(directory) }
+}
+
+func Test_expandImportFiles_GlobPattern_MatchesAreReturnedInOrder(t *testing.T) {
+	// arrange
+	directory, cleanup := getTestImportDirectory(t, map[string]string{
+		"2016-02.csv": "",
+		"2016-01.csv": "",
+		"2015-12.csv": "",
+	})
+	defer cleanup()
+
+	// act
+	files, err := expandImportFiles([]string{filepath.Join(directory, "2016-*.csv"), stdinFileName, "other.csv"})
+
+	// assert
+	expected := []string{filepath.Join(directory, "2016-01.csv"), filepath.Join(directory, "2016-02.csv"), stdinFileName, "other.csv"}
+	if err != nil || fmt.Sprintf("%v", files) != fmt.Sprintf("%v", expected) {
+		t.Fail()
+		t.Logf("expandImportFiles should have returned %v but returned %v (%v)", expected, files, err)
+	}
+}
+
+func Test_expandImportFiles_PatternDoesNotMatch_ErrorIsReturned(t *testing.T) {
+	// arrange
+	directory, cleanup := getTestImportDirectory(t, nil)
+	defer cleanup()
+
+	// act
+	_, err := expandImportFiles([]string{filepath.Join(directory, "*.csv")})
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("expandImportFiles should return an error if a pattern doesn't match any file")
+	}
+}
+
+func Test_importFiles_OneFileFails_RemainingFilesAreImported(t *testing.T) {
+	// arrange
+	directory, cleanup := getTestImportDirectory(t, map[string]string{
+		"2016-01.csv": "January",
+		"2016-02.csv": "February",
+		"2016-03.csv": "March",
+	})
+	defer cleanup()
+
+	var importedContents []string
+	newImporter := func() CSVImporter {
+		return &MockCSVImporter{
+			importFunc: func(input io.Reader) error {
+				content, _ := ioutil.ReadAll(input)
+				if string(content) == "February" {
+					return fmt.Errorf("Invalid CSV")
+				}
+
+				importedContents = append(importedContents, string(content))
+				return nil
+			},
+		}
+	}
+
+	files := []string{
+		filepath.Join(directory, "2016-01.csv"),
+		filepath.Join(directory, "2016-02.csv"),
+		filepath.Join(directory, "2016-03.csv"),
+	}
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	// act
+	success := importFiles(newImporter, files, strings.NewReader(""), &outputBuffer, &errorBuffer)
+
+	// assert
+	if success {
+		t.Fail()
+		t.Logf("importFiles should report a failure if one of the files could not be imported")
+	}
+
+	if fmt.Sprintf("%v", importedContents) != "[January March]" {
+		t.Fail()
+		t.Logf("importFiles should have imported the other files but imported: %v", importedContents)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "Imported 2 of 3 files.") {
+		t.Fail()
+		t.Logf("importFiles should have printed a summary but printed: %s", outputBuffer.String())
+	}
+
+	if !strings.Contains(errorBuffer.String(), "2016-02.csv: Invalid CSV") {
+		t.Fail()
+		t.Logf("importFiles should have printed the error of the failed file but printed: %s", errorBuffer.String())
+	}
+}
+
+type mockSummarizingCSVImporter struct {
+	MockCSVImporter
+	summary ImportSummary
+}
+
+func (importer *mockSummarizingCSVImporter) Summary() ImportSummary {
+	return importer.summary
+}
+
+func Test_importFiles_ImporterCountsTimeRecords_SummaryIsPrintedPerFile(t *testing.T) {
+	// arrange
+	directory, cleanup := getTestImportDirectory(t, map[string]string{
+		"2016-01.csv": "January",
+		"2016-02.csv": "February",
+	})
+	defer cleanup()
+
+	newImporter := func() CSVImporter {
+		importer := &mockSummarizingCSVImporter{}
+		importer.importFunc = func(input io.Reader) error {
+			content, _ := ioutil.ReadAll(input)
+			if string(content) == "February" {
+				importer.summary = ImportSummary{Created: 2, Failed: 1}
+				return fmt.Errorf("Workspace not found")
+			}
+
+			importer.summary = ImportSummary{Created: 10, Skipped: 3}
+			return nil
+		}
+
+		return importer
+	}
+
+	files := []string{filepath.Join(directory, "2016-01.csv"), filepath.Join(directory, "2016-02.csv")}
+
+	var outputBuffer bytes.Buffer
+	var errorBuffer bytes.Buffer
+
+	// act
+	importFiles(newImporter, files, strings.NewReader(""), &outputBuffer, &errorBuffer)
+
+	// assert
+	expectedLines := []string{
+		fmt.Sprintf("Imported %s: 10 created, 3 skipped, 0 failed", files[0]),
+		fmt.Sprintf("Failed to import %s: 2 created, 0 skipped, 1 failed", files[1]),
+	}
+
+	for _, expectedLine := range expectedLines {
+		if !strings.Contains(outputBuffer.String(), expectedLine) {
+			t.Fail()
+			t.Logf("importFiles should have printed %q but printed: %s", expectedLine, outputBuffer.String())
+		}
+	}
+}
+
+func Test_importFile_Dash_StdinIsImported(t *testing.T) {
+	// arrange
+	var importedContent string
+	importer := &MockCSVImporter{
+		importFunc: func(input io.Reader) error {
+			content, _ := ioutil.ReadAll(input)
+			importedContent = string(content)
+			return nil
+		},
+	}
+
+	// act
+	importFile(importer, stdinFileName, strings.NewReader("stdin"))
+
+	// assert
+	if importedContent != "stdin" {
+		t.Fail()
+		t.Logf("importFile should have imported stdin but imported %q", importedContent)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+	"github.com/pkg/errors"
+)
+
+// journalColumnNames contains the column names of the import journal.
+var journalColumnNames = []string{"Time Entry ID", "SHA-1"}
+
+// journalInputKey identifies the journal row that contains the SHA-1 fingerprint of the imported input.
+const journalInputKey = "Input"
+
+// openImportJournal opens the import journal at the given path for the input with the given fingerprint.
+// If resume is set, the entries of an existing journal are loaded; otherwise a new journal is created.
+// Returns an error if resume is set but the journal does not exist or belongs to a different input
+// or if a new journal should be created but a non-empty journal exists already.
+func openImportJournal(path string, resume bool, inputFingerprint string) (*importJournal, error) {
+	journal := &importJournal{
+		committed: make(map[string]int),
+	}
+
+	if resume {
+		existingJournal, openError := os.Open(path)
+		if openError != nil {
+			return nil, errors.Wrap(openError, fmt.Sprintf("Failed to open the import journal %q", path))
+		}
+
+		defer existingJournal.Close()
+
+		if readError := journal.read(existingJournal); readError != nil {
+			return nil, errors.Wrap(readError, fmt.Sprintf("Failed to read the import journal %q", path))
+		}
+
+		if journal.inputFingerprint != inputFingerprint {
+			return nil, fmt.Errorf("The import journal %q belongs to a different input. Resume the import with the input of the previous run or remove the journal", path)
+		}
+
+	} else if fileInfo, statError := os.Stat(path); statError == nil && fileInfo.Size() > 0 {
+		return nil, fmt.Errorf("The import journal %q exists already. Use --resume to continue the previous import or remove the journal", path)
+	}
+
+	file, fileError := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if fileError != nil {
+		return nil, errors.Wrap(fileError, fmt.Sprintf("Failed to open the import journal %q", path))
+	}
+
+	journal.file = file
+	journal.writer = csv.NewWriter(file)
+
+	if !resume {
+		if writeError := journal.write(journalColumnNames); writeError != nil {
+			file.Close()
+			return nil, writeError
+		}
+
+		if writeError := journal.write([]string{journalInputKey, inputFingerprint}); writeError != nil {
+			file.Close()
+			return nil, writeError
+		}
+	}
+
+	return journal, nil
+}
+
+// importJournal keeps track of the time records that have been created
+// so that an aborted import can be resumed.
+type importJournal struct {
+	// mutex makes the journal safe for concurrent use
+	mutex sync.Mutex
+
+	file   *os.File
+	writer *csv.Writer
+
+	// inputFingerprint contains the SHA-1 hash of the input the journal belongs to
+	inputFingerprint string
+
+	// committed contains the number of created time records by hash
+	committed map[string]int
+}
+
+// IsCommitted returns true if the given time record has been created by a previous import.
+// Every journal entry only matches a single time record so that identical records
+// in the same input are imported as often as they occur.
+func (journal *importJournal) IsCommitted(timeRecord toggl.TimeRecord) bool {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	hash := getTimeRecordHash(timeRecord)
+	if journal.committed[hash] == 0 {
+		return false
+	}
+
+	journal.committed[hash]--
+	return true
+}
+
+// Commit records that the given time record has been created as the time entry with the given ID.
+func (journal *importJournal) Commit(timeRecord toggl.TimeRecord, timeEntryID int) error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	return journal.write([]string{strconv.Itoa(timeEntryID), getTimeRecordHash(timeRecord)})
+}
+
+// Close closes the journal file.
+func (journal *importJournal) Close() error {
+	return journal.file.Close()
+}
+
+// write appends the given row to the journal and makes sure it is persisted.
+func (journal *importJournal) write(row []string) error {
+	if writeError := journal.writer.Write(row); writeError != nil {
+		return errors.Wrap(writeError, "Failed to write to the import journal")
+	}
+
+	journal.writer.Flush()
+	if flushError := journal.writer.Error(); flushError != nil {
+		return errors.Wrap(flushError, "Failed to write to the import journal")
+	}
+
+	return journal.file.Sync()
+}
+
+// read loads the entries of an existing journal.
+func (journal *importJournal) read(reader io.Reader) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = len(journalColumnNames)
+
+	rows, csvError := csvReader.ReadAll()
+	if csvError != nil {
+		return csvError
+	}
+
+	for index, row := range rows {
+
+		// skip the header
+		if index == 0 && row[0] == journalColumnNames[0] {
+			continue
+		}
+
+		if row[0] == journalInputKey {
+			journal.inputFingerprint = row[1]
+			continue
+		}
+
+		journal.committed[row[1]]++
+	}
+
+	return nil
+}
+
+// getTimeRecordHash returns a SHA-1 hash of all input attributes of the given time record.
+func getTimeRecordHash(timeRecord toggl.TimeRecord) string {
+	values := []string{
+		timeRecord.Start.UTC().Format(time.RFC3339),
+		timeRecord.Stop.UTC().Format(time.RFC3339),
+		timeRecord.WorkspaceName,
+		timeRecord.ProjectName,
+		timeRecord.ClientName,
+		strings.Join(timeRecord.Tags, ","),
+		timeRecord.Description,
+	}
+
+	hash := sha1.New()
+	csvWriter := csv.NewWriter(hash)
+	csvWriter.Write(values)
+	csvWriter.Flush()
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// getInputFingerprint returns the SHA-1 hash of the given input and a reader that returns the input from the start.
+// Files are read a second time; other inputs are kept in memory unless stream is set,
+// in which case an error is returned because the input could only be read once.
+func getInputFingerprint(input io.Reader, stream bool) (string, io.Reader, error) {
+	hash := sha1.New()
+
+	if seeker, isSeeker := input.(io.ReadSeeker); isSeeker {
+		if start, seekError := seeker.Seek(0, io.SeekCurrent); seekError == nil {
+			if _, readError := io.Copy(hash, seeker); readError != nil {
+				return "", nil, errors.Wrap(readError, "Failed to read the input")
+			}
+
+			if _, seekError := seeker.Seek(start, io.SeekStart); seekError != nil {
+				return "", nil, errors.Wrap(seekError, "Failed to rewind the input")
+			}
+
+			return fmt.Sprintf("%x", hash.Sum(nil)), seeker, nil
+		}
+	}
+
+	if stream {
+		return "", nil, fmt.Errorf("A streaming import with a journal needs an input file that can be read twice (e.g. \"< report.csv\" instead of a pipe)")
+	}
+
+	content, readError := ioutil.ReadAll(input)
+	if readError != nil {
+		return "", nil, errors.Wrap(readError, "Failed to read the input")
+	}
+
+	hash.Write(content)
+	return fmt.Sprintf("%x", hash.Sum(nil)), bytes.NewReader(content), nil
+}
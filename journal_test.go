@@ -0,0 +1,197 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func getTestJournalPath(t *testing.T) (string, func()) {
+	directory, directoryError := ioutil.TempDir("", "togglcsv-journal")
+	if directoryError != nil {
+		t.Fatalf("Failed to create a temporary directory: %s", directoryError)
+	}
+
+	return filepath.Join(directory, "import.journal"), func() { os.RemoveAll(directory) }
+}
+
+func Test_openImportJournal_ResumeButJournalDoesNotExist_ErrorIsReturned(t *testing.T) {
+	// arrange
+	journalPath, cleanup := getTestJournalPath(t)
+	defer cleanup()
+
+	// act
+	_, err := openImportJournal(journalPath, true, "input")
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("openImportJournal should return an error if the journal that should be resumed does not exist")
+	}
+}
+
+func Test_openImportJournal_JournalExistsButNoResume_ErrorIsReturned(t *testing.T) {
+	// arrange
+	journalPath, cleanup := getTestJournalPath(t)
+	defer cleanup()
+
+	journal, _ := openImportJournal(journalPath, false, "input")
+	journal.Close()
+
+	// act
+	_, err := openImportJournal(journalPath, false, "input")
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("openImportJournal should not overwrite an existing journal")
+	}
+}
+
+func Test_importJournal_Resume_CommittedRecordsAreMatchedOnce(t *testing.T) {
+	// arrange
+	journalPath, cleanup := getTestJournalPath(t)
+	defer cleanup()
+
+	timeRecord := toggl.TimeRecord{
+		Start:         time.Date(2016, 8, 12, 7, 0, 0, 0, time.UTC),
+		Stop:          time.Date(2016, 8, 12, 8, 0, 0, 0, time.UTC),
+		WorkspaceName: "Workspace",
+		ProjectName:   "Project",
+		Description:   "Retrospective",
+	}
+
+	otherTimeRecord := timeRecord
+	otherTimeRecord.Description = "Sprint Review"
+
+	journal, _ := openImportJournal(journalPath, false, "input")
+	journal.Commit(timeRecord, 412)
+	journal.Close()
+
+	// act
+	resumedJournal, err := openImportJournal(journalPath, true, "input")
+	if err != nil {
+		t.Fatalf("openImportJournal should resume the existing journal but returned an error: %s", err)
+	}
+	defer resumedJournal.Close()
+
+	// assert
+	if !resumedJournal.IsCommitted(timeRecord) {
+		t.Fail()
+		t.Logf("IsCommitted should return true for a committed time record")
+	}
+
+	if resumedJournal.IsCommitted(timeRecord) {
+		t.Fail()
+		t.Logf("IsCommitted should only match a single time record per journal entry")
+	}
+
+	if resumedJournal.IsCommitted(otherTimeRecord) {
+		t.Fail()
+		t.Logf("IsCommitted should return false for time records that have not been committed")
+	}
+}
+
+func Test_getTimeRecordHash_SameTimeInDifferentZones_HashesAreEqual(t *testing.T) {
+	// arrange
+	berlin := time.FixedZone("CEST", 2*60*60)
+
+	timeRecord := toggl.TimeRecord{
+		Start: time.Date(2016, 8, 12, 7, 0, 0, 0, time.UTC),
+		Stop:  time.Date(2016, 8, 12, 8, 0, 0, 0, time.UTC),
+		Tags:  []string{"Meetings", "Sprint"},
+	}
+
+	sameTimeRecord := toggl.TimeRecord{
+		Start: time.Date(2016, 8, 12, 9, 0, 0, 0, berlin),
+		Stop:  time.Date(2016, 8, 12, 10, 0, 0, 0, berlin),
+		Tags:  []string{"Meetings", "Sprint"},
+	}
+
+	otherTimeRecord := sameTimeRecord
+	otherTimeRecord.Tags = []string{"Meetings"}
+
+	// act
+	hash := getTimeRecordHash(timeRecord)
+
+	// assert
+	if hash != getTimeRecordHash(sameTimeRecord) {
+		t.Fail()
+		t.Logf("getTimeRecordHash should return the same hash for identical time records")
+	}
+
+	if hash == getTimeRecordHash(otherTimeRecord) {
+		t.Fail()
+		t.Logf("getTimeRecordHash should return different hashes for different time records")
+	}
+}
+
+func Test_openImportJournal_ResumeWithDifferentInput_ErrorIsReturned(t *testing.T) {
+	// arrange
+	journalPath, cleanup := getTestJournalPath(t)
+	defer cleanup()
+
+	journal, _ := openImportJournal(journalPath, false, "input")
+	journal.Close()
+
+	// act
+	_, err := openImportJournal(journalPath, true, "edited input")
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("openImportJournal should not resume a journal that belongs to a different input")
+	}
+}
+
+func Test_getInputFingerprint_SeekableInput_InputIsRewound(t *testing.T) {
+	// arrange
+	input := strings.NewReader("Start,Stop\n")
+
+	// act
+	fingerprint, rewoundInput, err := getInputFingerprint(input, true)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("getInputFingerprint should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	content, _ := ioutil.ReadAll(rewoundInput)
+	if string(content) != "Start,Stop\n" {
+		t.Fail()
+		t.Logf("getInputFingerprint should return the whole input again but returned %q", content)
+	}
+
+	otherFingerprint, _, _ := getInputFingerprint(strings.NewReader("Start,Stop\r\n"), true)
+	if fingerprint == "" || fingerprint == otherFingerprint {
+		t.Fail()
+		t.Logf("getInputFingerprint should return different fingerprints for different inputs")
+	}
+}
+
+func Test_getInputFingerprint_StreamFromPipe_ErrorIsReturned(t *testing.T) {
+	// arrange
+	input := ioutil.NopCloser(strings.NewReader("Start,Stop\n"))
+
+	// act
+	_, _, streamError := getInputFingerprint(input, true)
+	fingerprint, bufferedInput, batchError := getInputFingerprint(ioutil.NopCloser(strings.NewReader("Start,Stop\n")), false)
+
+	// assert
+	if streamError == nil {
+		t.Fail()
+		t.Logf("getInputFingerprint should return an error for a streamed input that cannot be read twice")
+	}
+
+	if batchError != nil || fingerprint == "" || bufferedInput == nil {
+		t.Fail()
+		t.Logf("getInputFingerprint should keep a batch input in memory but returned an error: %v", batchError)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreaskoch/togglapi/date"
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// The input and output formats of the time records.
+const (
+	formatCSV    = "csv"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatXLSX   = "xlsx"
+	formatICS    = "ics"
+)
+
+// formats contains all available input and output formats.
+var formats = []string{formatCSV, formatJSON, formatNDJSON, formatXLSX, formatICS}
+
+// maxNDJSONLineLength defines the maximum number of bytes of a single NDJSON line.
+const maxNDJSONLineLength = 1024 * 1024
+
+// jsonFieldNames maps the CSV column names to the names of the JSON fields.
+var jsonFieldNames = map[string]string{
+	columnStart:         "start",
+	columnStop:          "stop",
+	columnDuration:      "duration",
+	columnWorkspaceName: "workspace",
+	columnProjectName:   "project",
+	columnClientName:    "client",
+	columnTags:          "tags",
+	columnDescription:   "description",
+	columnBillable:      "billable",
+}
+
+// jsonTimeRecord is the JSON representation of a time record.
+// A time record without stop date and duration is running.
+type jsonTimeRecord struct {
+	Start string `json:"start"`
+	Stop  string `json:"stop,omitempty"`
+
+	// Duration contains the duration in seconds
+	Duration *int64 `json:"duration,omitempty"`
+
+	Workspace   string   `json:"workspace"`
+	Project     string   `json:"project"`
+	Client      string   `json:"client"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Billable    bool     `json:"billable"`
+}
+
+// NewJSONTimeRecordMapper converts JSON and NDJSON time records to TimeRecord models and vice versa.
+func NewJSONTimeRecordMapper(dateFormatter date.Formatter) *JSONTimeRecordMapper {
+	return &JSONTimeRecordMapper{
+		dateFormatter: dateFormatter,
+	}
+}
+
+// JSONTimeRecordMapper converts JSON time records into TimeRecord models.
+type JSONTimeRecordMapper struct {
+	dateFormatter date.Formatter
+}
+
+// GetTimeRecords reads all time records from the given JSON array or NDJSON input (one time record per line).
+// All time records are validated; if any time record is invalid ValidationErrors with all problems are returned.
+// The line of a problem is the line of the NDJSON input or the number of the time record in the JSON array.
+// Returns the time records and their lines.
+func (mapper *JSONTimeRecordMapper) GetTimeRecords(input io.Reader, format string) ([]toggl.TimeRecord, []int, error) {
+	reader := newJSONTimeRecordReader(input, format)
+
+	var records []jsonTimeRecord
+	var lines []int
+	for {
+		record, line, readError := reader.Read()
+		if readError == io.EOF {
+			break
+		}
+
+		if readError != nil {
+			return nil, nil, readError
+		}
+
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+
+	validate := func(index int) (toggl.TimeRecord, []ValidationError) {
+		return mapper.validateRecord(records[index])
+	}
+
+	getLine := func(index int) int {
+		return lines[index]
+	}
+
+	timeRecords, timeRecordsError := collectTimeRecords(len(records), jsonFieldNames[columnStop], validate, getLine)
+	if timeRecordsError != nil {
+		return nil, nil, timeRecordsError
+	}
+
+	return timeRecords, lines, nil
+}
+
+// validateRecord returns a TimeRecord model for the given JSON time record and all problems of the record.
+// The line numbers of the returned problems are not set.
+func (mapper *JSONTimeRecordMapper) validateRecord(record jsonTimeRecord) (toggl.TimeRecord, []ValidationError) {
+	values := timeRecordValues{
+		start:       record.Start,
+		stop:        record.Stop,
+		workspace:   record.Workspace,
+		project:     record.Project,
+		client:      record.Client,
+		description: record.Description,
+		billable:    strconv.FormatBool(record.Billable),
+	}
+
+	if record.Duration != nil {
+		values.duration = (time.Duration(*record.Duration) * time.Second).String()
+	}
+
+	for _, tag := range record.Tags {
+		if strings.TrimSpace(tag) != "" {
+			values.tags = append(values.tags, tag)
+		}
+	}
+
+	timeRecord, problems := validateTimeRecordValues(values, mapper.dateFormatter)
+	for index := range problems {
+		problems[index].Column = jsonFieldNames[problems[index].Column]
+	}
+
+	return timeRecord, problems
+}
+
+// getJSONTimeRecord returns the JSON representation of the given time record.
+// The stop date and the duration of a running time record are left out.
+func (mapper *JSONTimeRecordMapper) getJSONTimeRecord(timeRecord toggl.TimeRecord) jsonTimeRecord {
+	record := jsonTimeRecord{
+		Start:       mapper.dateFormatter.GetDateString(timeRecord.Start),
+		Workspace:   timeRecord.WorkspaceName,
+		Project:     timeRecord.ProjectName,
+		Client:      timeRecord.ClientName,
+		Description: timeRecord.Description,
+		Tags:        timeRecord.Tags,
+		Billable:    timeRecord.Billable,
+	}
+
+	if record.Tags == nil {
+		record.Tags = []string{}
+	}
+
+	if !timeRecord.IsRunning() {
+		duration := int64(timeRecord.Stop.Sub(timeRecord.Start) / time.Second)
+		record.Stop = mapper.dateFormatter.GetDateString(timeRecord.Stop)
+		record.Duration = &duration
+	}
+
+	return record
+}
+
+// newJSONTimeRecordReader creates a reader for the JSON time records of the given JSON array or NDJSON input.
+func newJSONTimeRecordReader(input io.Reader, format string) *jsonTimeRecordReader {
+	reader := &jsonTimeRecordReader{}
+	if format == formatNDJSON {
+		reader.scanner = bufio.NewScanner(input)
+		reader.scanner.Buffer(make([]byte, 64*1024), maxNDJSONLineLength)
+	} else {
+		reader.decoder = json.NewDecoder(input)
+		reader.decoder.DisallowUnknownFields()
+	}
+
+	return reader
+}
+
+// jsonTimeRecordReader reads JSON time records one by one
+// from either a JSON array (decoder) or an NDJSON input (scanner).
+type jsonTimeRecordReader struct {
+	decoder *json.Decoder
+	scanner *bufio.Scanner
+
+	// line contains the number of the last NDJSON line or JSON array element that was read
+	line int
+
+	// started is true once the opening bracket of the JSON array has been read
+	started bool
+}
+
+// Read returns the next JSON time record and its line.
+// Returns io.EOF after the last time record.
+func (reader *jsonTimeRecordReader) Read() (jsonTimeRecord, int, error) {
+	if reader.scanner != nil {
+		return reader.readLine()
+	}
+
+	return reader.readArrayElement()
+}
+
+// readLine returns the time record of the next non-empty NDJSON line.
+func (reader *jsonTimeRecordReader) readLine() (jsonTimeRecord, int, error) {
+	for reader.scanner.Scan() {
+		reader.line++
+
+		line := bytes.TrimSpace(reader.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var record jsonTimeRecord
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if decodeError := decoder.Decode(&record); decodeError != nil {
+			return jsonTimeRecord{}, reader.line, fmt.Errorf("Failed to read the time record in line %d: %s", reader.line, decodeError.Error())
+		}
+
+		return record, reader.line, nil
+	}
+
+	if scanError := reader.scanner.Err(); scanError != nil {
+		return jsonTimeRecord{}, reader.line, fmt.Errorf("Failed to read time records from NDJSON: %s", scanError.Error())
+	}
+
+	return jsonTimeRecord{}, reader.line, io.EOF
+}
+
+// readArrayElement returns the next time record of the JSON array.
+func (reader *jsonTimeRecordReader) readArrayElement() (jsonTimeRecord, int, error) {
+	if !reader.started {
+		token, tokenError := reader.decoder.Token()
+		if tokenError == io.EOF {
+			return jsonTimeRecord{}, 0, io.EOF
+		}
+
+		if delimiter, isDelimiter := token.(json.Delim); tokenError != nil || !isDelimiter || delimiter != '[' {
+			return jsonTimeRecord{}, 0, fmt.Errorf("Failed to read time records from JSON: The input must be an array of time records")
+		}
+
+		reader.started = true
+	}
+
+	if !reader.decoder.More() {
+		if _, tokenError := reader.decoder.Token(); tokenError != nil {
+			return jsonTimeRecord{}, reader.line, fmt.Errorf("Failed to read time records from JSON: %s", tokenError.Error())
+		}
+
+		return jsonTimeRecord{}, reader.line, io.EOF
+	}
+
+	reader.line++
+
+	var record jsonTimeRecord
+	if decodeError := reader.decoder.Decode(&record); decodeError != nil {
+		return jsonTimeRecord{}, reader.line, fmt.Errorf("Failed to read time record %d from JSON: %s", reader.line, decodeError.Error())
+	}
+
+	return record, reader.line, nil
+}
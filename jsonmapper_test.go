@@ -0,0 +1,169 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglapi/date"
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func Test_JSONTimeRecordMapper_GetTimeRecords_ValidJSONArray_TimeRecordsAreReturned(t *testing.T) {
+	// arrange
+	mapper := NewJSONTimeRecordMapper(date.NewISO8601Formatter())
+	input := `[
+  {"start": "2016-08-01T09:00:00+02:00", "stop": "2016-08-01T10:30:00+02:00", "workspace": "Work", "project": "Website", "client": "Customer A", "description": "Design", "tags": ["design", "billable"], "billable": true},
+  {"start": "2016-08-01T11:00:00+02:00", "duration": 1800, "workspace": "Work", "project": "", "client": "", "description": "Mail", "tags": []}
+]`
+
+	// act
+	timeRecords, _, err := mapper.GetTimeRecords(strings.NewReader(input), formatJSON)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("GetTimeRecords should not have returned an error but returned: %s", err)
+		return
+	}
+
+	if len(timeRecords) != 2 {
+		t.Fail()
+		t.Logf("GetTimeRecords should have returned 2 time records but returned %d", len(timeRecords))
+		return
+	}
+
+	first := timeRecords[0]
+	if first.ProjectName != "Website" || first.ClientName != "Customer A" || !first.Billable || len(first.Tags) != 2 || first.Tags[1] != "billable" {
+		t.Fail()
+		t.Logf("GetTimeRecords returned a wrong first time record: %#v", first)
+	}
+
+	second := timeRecords[1]
+	if second.Stop.Sub(second.Start) != 30*time.Minute || second.IsRunning() {
+		t.Fail()
+		t.Logf("GetTimeRecords should have calculated the stop date from the duration but returned: %#v", second)
+	}
+}
+
+func Test_JSONTimeRecordMapper_GetTimeRecords_NDJSON_EmptyLinesAreSkipped(t *testing.T) {
+	// arrange
+	mapper := NewJSONTimeRecordMapper(date.NewISO8601Formatter())
+	input := `{"start": "2016-08-01T09:00:00+02:00", "stop": "2016-08-01T10:30:00+02:00", "workspace": "Work", "description": "Record 1"}
+
+{"start": "2016-08-02T09:00:00+02:00", "stop": "2016-08-02T10:30:00+02:00", "workspace": "Work", "description": "Record 2"}
+`
+
+	// act
+	timeRecords, _, err := mapper.GetTimeRecords(strings.NewReader(input), formatNDJSON)
+
+	// assert
+	if err != nil || len(timeRecords) != 2 || timeRecords[1].Description != "Record 2" {
+		t.Fail()
+		t.Logf("GetTimeRecords should have returned both time records but returned %#v (%v)", timeRecords, err)
+	}
+}
+
+func Test_JSONTimeRecordMapper_GetTimeRecords_InvalidRecords_ValidationErrorsWithJSONFieldsAreReturned(t *testing.T) {
+	// arrange
+	mapper := NewJSONTimeRecordMapper(date.NewISO8601Formatter())
+	input := `{"start": "2016-08-01T09:00:00+02:00", "stop": "2016-08-01T10:30:00+02:00", "workspace": "Work"}
+{"start": "yesterday", "stop": "2016-08-02T10:30:00+02:00", "workspace": "Work"}
+{"start": "2016-08-03T09:00:00+02:00", "stop": "2016-08-03T10:30:00+02:00", "workspace": "Work", "client": "Customer A"}`
+
+	// act
+	_, _, err := mapper.GetTimeRecords(strings.NewReader(input), formatNDJSON)
+
+	// assert
+	validationErrors, isValidationError := err.(ValidationErrors)
+	if !isValidationError || len(validationErrors) != 2 {
+		t.Fail()
+		t.Logf("GetTimeRecords should have returned two validation errors but returned: %v", err)
+		return
+	}
+
+	if validationErrors[0].Line != 2 || validationErrors[0].Column != "start" || validationErrors[1].Line != 3 || validationErrors[1].Column != "client" {
+		t.Fail()
+		t.Logf("GetTimeRecords should have reported the start date in line 2 and the client in line 3 but returned: %#v", validationErrors)
+	}
+}
+
+func Test_JSONTimeRecordMapper_GetTimeRecords_TwoRunningRecords_ValidationErrorIsReturned(t *testing.T) {
+	// arrange
+	mapper := NewJSONTimeRecordMapper(date.NewISO8601Formatter())
+	input := `[
+  {"start": "2016-08-01T09:00:00+02:00", "workspace": "Work"},
+  {"start": "2016-08-01T10:00:00+02:00", "workspace": "Work"}
+]`
+
+	// act
+	_, _, err := mapper.GetTimeRecords(strings.NewReader(input), formatJSON)
+
+	// assert
+	validationErrors, isValidationError := err.(ValidationErrors)
+	if !isValidationError || len(validationErrors) != 1 || validationErrors[0].Line != 2 || validationErrors[0].Column != "stop" {
+		t.Fail()
+		t.Logf("GetTimeRecords should have reported the second running time record but returned: %v", err)
+	}
+}
+
+func Test_JSONTimeRecordMapper_GetTimeRecords_MalformedInput_ErrorIsReturned(t *testing.T) {
+	// arrange
+	mapper := NewJSONTimeRecordMapper(date.NewISO8601Formatter())
+	inputs := map[string]string{
+		`{"start": "2016-08-01T09:00:00+02:00"}`:                       formatJSON,
+		`[{"start": "2016-08-01T09:00:00+02:00", "unknown": "value"}]`: formatJSON,
+		`[{"start": "2016-08-01T09:00:00+02:00"}`:                      formatJSON,
+		`{"start": "2016-08-01T09:00:00+02:00", "tags": "a,b"}`:        formatNDJSON,
+	}
+
+	for input, format := range inputs {
+		// act
+		_, _, err := mapper.GetTimeRecords(strings.NewReader(input), format)
+
+		// assert
+		if err == nil {
+			t.Fail()
+			t.Logf("GetTimeRecords(%q, %q) should have returned an error", input, format)
+		}
+
+		if _, isValidationError := err.(ValidationErrors); isValidationError {
+			t.Fail()
+			t.Logf("GetTimeRecords(%q, %q) should have returned a read error but returned validation errors: %s", input, format, err)
+		}
+	}
+}
+
+func Test_JSONTimeRecordMapper_GetTimeRecords_EmptyInput_NoTimeRecordsAreReturned(t *testing.T) {
+	// arrange
+	mapper := NewJSONTimeRecordMapper(date.NewISO8601Formatter())
+
+	for _, format := range []string{formatJSON, formatNDJSON} {
+		// act
+		timeRecords, _, err := mapper.GetTimeRecords(strings.NewReader(""), format)
+
+		// assert
+		if err != nil || len(timeRecords) != 0 {
+			t.Fail()
+			t.Logf("GetTimeRecords should not have returned any time records for an empty %s input but returned %v (%v)", format, timeRecords, err)
+		}
+	}
+}
+
+func Test_JSONTimeRecordMapper_getJSONTimeRecord_RunningRecord_StopAndDurationAreLeftOut(t *testing.T) {
+	// arrange
+	mapper := NewJSONTimeRecordMapper(date.NewISO8601Formatter())
+	timeRecord := toggl.TimeRecord{
+		Start:         time.Date(2016, 8, 1, 9, 0, 0, 0, time.UTC),
+		WorkspaceName: "Work",
+	}
+
+	// act
+	record := mapper.getJSONTimeRecord(timeRecord)
+
+	// assert
+	if record.Stop != "" || record.Duration != nil || record.Tags == nil {
+		t.Fail()
+		t.Logf("getJSONTimeRecord should have left out the stop date and the duration and returned empty tags but returned %#v", record)
+	}
+}
@@ -5,9 +5,9 @@ package main
 import (
 	"io"
 	"os"
+	"time"
 
 	"github.com/andreaskoch/togglapi"
-	"github.com/andreaskoch/togglapi/date"
 	"github.com/andreaskoch/togglcsv/toggl"
 	"github.com/jinzhu/now"
 )
@@ -16,6 +16,10 @@ const applicationName = "togglcsv"
 const applicationVersion = "v1.0.0"
 const togglAPIBaseURL = "https://www.toggl.com/api/v8"
 
+// defaultRequestsPerSecond contains the request rate allowed by the Toggl API
+// see: https://github.com/toggl/toggl_api_docs
+const defaultRequestsPerSecond = 1
+
 var out io.Writer
 var err io.Writer
 var in io.Reader
@@ -33,46 +37,160 @@ func init() {
 func main() {
 
 	cli := togglCli{
-		importerFactory: getCSVImporter,
-		exporterFactory: getCSVExporter,
+		importerFactory:  getCSVImporter,
+		exporterFactory:  getCSVExporter,
+		validatorFactory: getCSVValidator,
+		mergerFactory:    getCSVMerger,
+		reporterFactory:  getReporter,
 	}
 
 	cli.Execute(in, out, err, args)
 }
 
-// getCSVExporter creates a new CSVExporter instance for the given API token.
-func getCSVExporter(apiToken string) CSVExporter {
-	dateFormatter := date.NewISO8601Formatter()
+// getCSVExporter creates a new CSVExporter instance for the given API token and export options.
+func getCSVExporter(apiToken string, options ExportOptions) CSVExporter {
+	dateFormatter := newDateFormatter(options.Location)
 	csvTimeRecordMapper := NewCSVTimeRecordMapper(dateFormatter)
+	if options.DurationFormat != "" {
+		csvTimeRecordMapper = NewCSVTimeRecordMapperWithDuration(dateFormatter, options.DurationFormat)
+	}
+
+	if options.Billable {
+		csvTimeRecordMapper = csvTimeRecordMapper.WithBillableColumn()
+	}
+
+	return &TogglCSVExporter{
+		csvMapper:            csvTimeRecordMapper,
+		timeRecordRepository: getFilteredTimeRecordRepository(apiToken, options.Filter, options.IncludeRunning),
+		format:               options.Format,
+		jsonMapper:           NewJSONTimeRecordMapper(dateFormatter),
+		messageOutput:        os.Stderr,
+		includeRunning:       options.IncludeRunning,
+		transformers:         options.Transformers,
+		splitPeriod:          options.SplitAt,
+		location:             options.Location,
+		merge:                options.Merge,
+		maxMergeGap:          options.MaxMergeGap,
+		filter:               options.Filter,
+	}
+}
 
+// getReporter creates a new Reporter instance for the given API token and report options.
+func getReporter(apiToken string, options ReportOptions) Reporter {
+	return &TogglReporter{
+		timeRecordRepository: getFilteredTimeRecordRepository(apiToken, options.Filter, false),
+		groupBy:              options.GroupBy,
+		tagMode:              options.TagMode,
+		format:               options.Format,
+		location:             options.Location,
+		filter:               options.Filter,
+	}
+}
+
+// getFilteredTimeRecordRepository creates a repository for reading the time records of the given API token.
+// The time entries of workspaces and projects that are excluded by the given filter (optional) are skipped
+// before they are converted, and only the projects of the included workspaces are loaded.
+// Time records without project are always read, the running time record only if includeRunning is true.
+func getFilteredTimeRecordRepository(apiToken string, filter *ExportFilter, includeRunning bool) toggl.TimeRecorder {
 	togglAPI := togglapi.NewAPI(togglAPIBaseURL, apiToken)
 	workspaces := toggl.NewWorkspaceRepository(togglAPI)
 	clients := toggl.NewClientRepository(togglAPI, workspaces)
-	projects := toggl.NewProjectRepository(togglAPI, workspaces, clients)
 
-	timeRecords := toggl.NewTimeRecordRepository(togglAPI, workspaces, projects, clients)
+	timeRecordFilter := toggl.TimeRecordFilter{
+		IncludeRunning:        includeRunning,
+		IncludeWithoutProject: true,
+	}
 
-	return &TogglCSVExporter{
-		csvMapper:            csvTimeRecordMapper,
-		timeRecordRepository: timeRecords,
+	if filter == nil {
+		projects := toggl.NewProjectRepository(togglAPI, workspaces, clients)
+		return toggl.NewFilteredTimeRecordRepository(togglAPI, workspaces, projects, clients, timeRecordFilter)
+	}
+
+	timeRecordFilter.IncludeWorkspace = filter.IncludesWorkspace
+	timeRecordFilter.IncludeProject = filter.IncludesProject
+
+	// only load the projects and clients of the included workspaces
+	projects := toggl.NewFilteredProjectRepository(togglAPI, workspaces, clients, filter.IncludesWorkspace)
+
+	return toggl.NewFilteredTimeRecordRepository(togglAPI, workspaces, projects, clients, timeRecordFilter)
+}
+
+// getCSVValidator creates a new CSVValidator instance that prints the problems in the given format
+// and reads dates without an offset in the given time zone (optional).
+func getCSVValidator(format string, location *time.Location) CSVValidator {
+	dateFormatter := newDateFormatter(location)
+
+	return &TogglCSVValidator{
+		csvMapper: NewCSVTimeRecordMapper(dateFormatter),
+		format:    format,
+	}
+}
+
+// getCSVMerger creates a new CSVMerger instance that merges time records whose gap is not larger than the given maximum
+// and reads and writes the dates in the given time zone (optional).
+func getCSVMerger(maxGap time.Duration, location *time.Location) CSVMerger {
+	dateFormatter := newDateFormatter(location)
+
+	return &TogglCSVMerger{
+		csvMapper: NewCSVTimeRecordMapper(dateFormatter),
+		maxGap:    maxGap,
 	}
 }
 
-// getCSVImporter creates a new CSVImporter instance for the given API token.
-func getCSVImporter(apiToken string) CSVImporter {
-	dateFormatter := date.NewISO8601Formatter()
+// getCSVImporter creates a new CSVImporter instance for the given API token and import options.
+func getCSVImporter(apiToken string, options ImportOptions) CSVImporter {
+	dateFormatter := newDateFormatter(options.Location)
 	csvTimeRecordMapper := NewCSVTimeRecordMapper(dateFormatter)
 
-	togglAPI := togglapi.NewAPI(togglAPIBaseURL, apiToken)
+	togglAPI := getRateLimitedAPI(apiToken, options.RequestsPerSecond)
+
+	// record all created objects so they can be deleted if the import fails
+	var transaction toggl.Rollbacker
+	if options.Atomic {
+		togglTransaction := toggl.NewTransaction(togglAPI)
+		togglAPI = togglTransaction
+		transaction = togglTransaction
+	}
+
 	workspaces := toggl.NewWorkspaceRepository(togglAPI)
 	clients := toggl.NewClientRepository(togglAPI, workspaces)
 	projects := toggl.NewProjectRepository(togglAPI, workspaces, clients)
 
-	timeRecords := toggl.NewTimeRecordRepository(togglAPI, workspaces, projects, clients)
+	// the existing time records include the running one and the ones without project
+	// so that importing them again is detected as a duplicate
+	timeRecords := toggl.NewFilteredTimeRecordRepository(togglAPI, workspaces, projects, clients, toggl.TimeRecordFilter{
+		IncludeRunning:        true,
+		IncludeWithoutProject: true,
+	})
 
 	return &TogglCSVImporter{
 		csvMapper:            csvTimeRecordMapper,
+		format:               options.Format,
+		jsonMapper:           NewJSONTimeRecordMapper(dateFormatter),
+		icsMapper:            NewICSTimeRecordMapper(options.CalendarMapper, options.CalendarFrom, options.CalendarUntil, options.Location),
+		sheet:                options.Sheet,
 		timeRecordRepository: timeRecords,
+		changePlanner:        toggl.NewChangePlanner(workspaces, projects, clients),
 		output:               os.Stdout,
+		dryRun:               options.DryRun,
+		journalPath:          options.JournalPath,
+		resume:               options.Resume,
+		workers:              options.Workers,
+		stream:               options.Stream,
+		transformers:         options.Transformers,
+		overlapPolicy:        options.OverlapPolicy,
+		splitPeriod:          options.SplitAt,
+		location:             options.Location,
+		transaction:          transaction,
 	}
 }
+
+// getRateLimitedAPI creates a Toggl API client for the given API token that can be shared by parallel workers.
+// All requests are throttled by a single token bucket.
+func getRateLimitedAPI(apiToken string, requestsPerSecond float64) toggl.API {
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = defaultRequestsPerSecond
+	}
+
+	return toggl.NewRateLimitedAPI(toggl.NewAPI(togglAPIBaseURL, apiToken), toggl.NewTokenBucket(requestsPerSecond, 1))
+}
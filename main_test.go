@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test_getCSVExporter_IntegrationTest_ResultIsNotNull(t *testing.T) {
@@ -12,7 +13,7 @@ func Test_getCSVExporter_IntegrationTest_ResultIsNotNull(t *testing.T) {
 	apiToken := "dkasjdlkjsadkljas3123j12kl"
 
 	// act
-	exporter := getCSVExporter(apiToken)
+	exporter := getCSVExporter(apiToken, ExportOptions{})
 
 	// assert
 	if exporter == nil {
@@ -26,7 +27,7 @@ func Test_getCSVImporter_IntegrationTest_ResultIsNotNull(t *testing.T) {
 	apiToken := "dkasjdlkjsadkljas3123j12kl"
 
 	// act
-	exporter := getCSVImporter(apiToken)
+	exporter := getCSVImporter(apiToken, ImportOptions{})
 
 	// assert
 	if exporter == nil {
@@ -35,6 +36,28 @@ func Test_getCSVImporter_IntegrationTest_ResultIsNotNull(t *testing.T) {
 	}
 }
 
+func Test_getCSVValidator_IntegrationTest_ResultIsNotNull(t *testing.T) {
+	// act
+	validator := getCSVValidator("table", nil)
+
+	// assert
+	if validator == nil {
+		t.Fail()
+		t.Logf("getCSVValidator should not have returned nil")
+	}
+}
+
+func Test_getCSVMerger_IntegrationTest_ResultIsNotNull(t *testing.T) {
+	// act
+	merger := getCSVMerger(time.Minute, nil)
+
+	// assert
+	if merger == nil {
+		t.Fail()
+		t.Logf("getCSVMerger should not have returned nil")
+	}
+}
+
 func Test_IntegrationTest_main_HelpOrInvalidArguments_HelpTextIsPrintedToStderr(t *testing.T) {
 	// arrange
 	argumentInputs := [][]string{
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// defaultMaxMergeGap contains the default of the largest gap between two time records that are merged.
+const defaultMaxMergeGap = "1m"
+
+// The CSVMerger interface merges consecutive CSV time records without sending them to Toggl.
+type CSVMerger interface {
+	// Merge reads the time records from the given input, merges consecutive time records with identical attributes
+	// and writes the result as CSV to the given writer. A summary is written to the given message output.
+	Merge(input io.Reader, writer, messageOutput io.Writer) error
+}
+
+// TogglCSVMerger merges CSV time records using the CSV mapper of the import.
+type TogglCSVMerger struct {
+	csvMapper TimeRecordMapper
+
+	// maxGap contains the largest gap between two time records that are merged
+	maxGap time.Duration
+}
+
+// Merge reads the time records from the given input, merges consecutive time records with identical attributes
+// and writes the result as CSV to the given writer. A summary is written to the given message output.
+func (merger *TogglCSVMerger) Merge(input io.Reader, writer, messageOutput io.Writer) error {
+	rows, lines, csvError := readCSVRows(input)
+	if csvError != nil {
+		return fmt.Errorf("Failed to read time records from CSV: %s", csvError.Error())
+	}
+
+	timeRecords, timeRecordsError := merger.csvMapper.GetTimeRecords(rows, lines)
+	if timeRecordsError != nil {
+		return timeRecordsError
+	}
+
+	mergedTimeRecords, merged := mergeTimeRecords(timeRecords, merger.maxGap)
+
+	// keep the billable flags if the input has a "Billable" column
+	outputMapper := merger.csvMapper
+	if len(rows) > 0 && isTimeRecordHeadline(rows[0]) && hasColumnName(rows[0], columnBillable) {
+		outputMapper = outputMapper.WithBillableColumn()
+	}
+
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write(outputMapper.GetColumnNames())
+	for _, timeRecord := range mergedTimeRecords {
+		csvWriter.Write(outputMapper.GetRow(timeRecord))
+	}
+
+	csvWriter.Flush()
+	if flushError := csvWriter.Error(); flushError != nil {
+		return flushError
+	}
+
+	printMergeSummary(messageOutput, merged, len(timeRecords))
+	return nil
+}
+
+// mergeTimeRecords merges consecutive time records whose attributes are identical
+// and whose gap is not larger than the given maximum. Time records that overlap each other are merged as well.
+// Returns the time records ordered by their start date and the number of time records that were merged into others.
+// Running time records are not merged.
+func mergeTimeRecords(timeRecords []toggl.TimeRecord, maxGap time.Duration) ([]toggl.TimeRecord, int) {
+	sorted := make([]toggl.TimeRecord, len(timeRecords))
+	copy(sorted, timeRecords)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var result []toggl.TimeRecord
+	for _, timeRecord := range sorted {
+		if len(result) > 0 {
+			previous := &result[len(result)-1]
+			if canMerge(*previous, timeRecord, maxGap) {
+				if timeRecord.Stop.After(previous.Stop) {
+					previous.Stop = timeRecord.Stop
+				}
+
+				continue
+			}
+		}
+
+		result = append(result, timeRecord)
+	}
+
+	return result, len(timeRecords) - len(result)
+}
+
+// canMerge returns true if the given time record follows the previous one within the given gap
+// and all other attributes are identical.
+func canMerge(previous, timeRecord toggl.TimeRecord, maxGap time.Duration) bool {
+	if previous.IsRunning() || timeRecord.IsRunning() {
+		return false
+	}
+
+	if timeRecord.Start.Sub(previous.Stop) > maxGap {
+		return false
+	}
+
+	return previous.WorkspaceName == timeRecord.WorkspaceName &&
+		previous.ProjectName == timeRecord.ProjectName &&
+		previous.ClientName == timeRecord.ClientName &&
+		previous.Description == timeRecord.Description &&
+		previous.Billable == timeRecord.Billable &&
+		getTagsKey(previous.Tags) == getTagsKey(timeRecord.Tags)
+}
+
+// getTagsKey returns the given tags in a form that doesn't depend on their order.
+func getTagsKey(tags []string) string {
+	sortedTags := make([]string, len(tags))
+	copy(sortedTags, tags)
+	sort.Strings(sortedTags)
+
+	return strings.Join(sortedTags, "\n")
+}
+
+// printMergeSummary prints the number of time records that were merged into others.
+func printMergeSummary(output io.Writer, merged, total int) {
+	if output == nil {
+		return
+	}
+
+	fmt.Fprintf(output, "Merged %d of %d time records into adjacent time records.\n", merged, total)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglapi/date"
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func getAdjacentTestTimeRecord(startHour, startMinute, stopHour, stopMinute int, description string) toggl.TimeRecord {
+	return toggl.TimeRecord{
+		WorkspaceName: "Workspace",
+		ProjectName:   "Project",
+		Tags:          []string{"a", "b"},
+		Description:   description,
+		Start:         time.Date(2016, 8, 12, startHour, startMinute, 0, 0, time.UTC),
+		Stop:          time.Date(2016, 8, 12, stopHour, stopMinute, 0, 0, time.UTC),
+	}
+}
+
+func Test_mergeTimeRecords_GapBelowThreshold_RecordsAreMerged(t *testing.T) {
+	// arrange
+	second := getAdjacentTestTimeRecord(9, 31, 10, 0, "Coding")
+	second.Tags = []string{"b", "a"}
+
+	timeRecords := []toggl.TimeRecord{
+		second,
+		getAdjacentTestTimeRecord(9, 0, 9, 30, "Coding"),
+		getAdjacentTestTimeRecord(10, 0, 10, 15, "Coding"),
+	}
+
+	// act
+	result, merged := mergeTimeRecords(timeRecords, time.Minute)
+
+	// assert
+	if merged != 2 || len(result) != 1 || !result[0].Start.Equal(timeRecords[1].Start) || !result[0].Stop.Equal(timeRecords[2].Stop) {
+		t.Fail()
+		t.Logf("mergeTimeRecords should have merged all three time records into one but returned %#v (%d merged)", result, merged)
+	}
+}
+
+func Test_mergeTimeRecords_GapAboveThresholdOrDifferentAttributes_RecordsAreNotMerged(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		getAdjacentTestTimeRecord(9, 0, 9, 30, "Coding"),
+		getAdjacentTestTimeRecord(9, 35, 10, 0, "Coding"),
+		getAdjacentTestTimeRecord(10, 0, 10, 15, "Meeting"),
+		getAdjacentTestTimeRecord(10, 15, 10, 30, "Coding"),
+	}
+
+	// act
+	result, merged := mergeTimeRecords(timeRecords, time.Minute)
+
+	// assert
+	if merged != 0 || len(result) != len(timeRecords) {
+		t.Fail()
+		t.Logf("mergeTimeRecords should not have merged any time records but merged %d", merged)
+	}
+}
+
+func Test_mergeTimeRecords_RunningTimeRecord_RecordIsNotMerged(t *testing.T) {
+	// arrange
+	running := getAdjacentTestTimeRecord(9, 30, 0, 0, "Coding")
+	running.Stop = time.Time{}
+
+	timeRecords := []toggl.TimeRecord{
+		getAdjacentTestTimeRecord(9, 0, 9, 30, "Coding"),
+		running,
+	}
+
+	// act
+	result, merged := mergeTimeRecords(timeRecords, time.Minute)
+
+	// assert
+	if merged != 0 || len(result) != 2 || !result[1].IsRunning() {
+		t.Fail()
+		t.Logf("mergeTimeRecords should not have merged the running time record but returned %#v", result)
+	}
+}
+
+func Test_TogglCSVMerger_Merge_MergedCSVAndSummaryAreWritten(t *testing.T) {
+	// arrange
+	merger := &TogglCSVMerger{
+		csvMapper: NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		maxGap:    time.Minute,
+	}
+
+	input := `Start,Stop,Workspace Name,Project Name,Client Name,Tag(s),Description
+2015-03-26T08:00:00+01:00,2015-03-26T08:30:00+01:00,Workspace,Project XY,Client X,,Coding
+2015-03-26T08:30:30+01:00,2015-03-26T09:00:00+01:00,Workspace,Project XY,Client X,,Coding`
+
+	var outputBuffer bytes.Buffer
+	var messageBuffer bytes.Buffer
+
+	// act
+	err := merger.Merge(strings.NewReader(input), &outputBuffer, &messageBuffer)
+
+	// assert
+	expected := "Start,Stop,Workspace Name,Project Name,Client Name,Tag(s),Description\n" +
+		"2015-03-26T08:00:00+01:00,2015-03-26T09:00:00+01:00,Workspace,Project XY,Client X,,Coding\n"
+	if err != nil || outputBuffer.String() != expected {
+		t.Fail()
+		t.Logf("Merge should have written %q but wrote %q (%v)", expected, outputBuffer.String(), err)
+	}
+
+	if !strings.Contains(messageBuffer.String(), "Merged 1 of 2 time records") {
+		t.Fail()
+		t.Logf("Merge should have printed the number of merged time records but printed: %s", messageBuffer.String())
+	}
+}
+
+func Test_TogglCSVMerger_Merge_InvalidInput_ErrorIsReturned(t *testing.T) {
+	// arrange
+	merger := &TogglCSVMerger{
+		csvMapper: NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		maxGap:    time.Minute,
+	}
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	err := merger.Merge(strings.NewReader(`Invalid Date,2015-03-26T09:00:00+01:00,Workspace,Project XY,Client X,,Coding`), &outputBuffer, nil)
+
+	// assert
+	if _, isValidationErrors := err.(ValidationErrors); !isValidationErrors || outputBuffer.Len() > 0 {
+		t.Fail()
+		t.Logf("Merge should have returned the validation errors without writing anything but returned %v", err)
+	}
+}
+
+func Test_TogglCSVMerger_Merge_BillableColumnInHeadline_BillableColumnIsWritten(t *testing.T) {
+	// arrange
+	merger := &TogglCSVMerger{
+		csvMapper: NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		maxGap:    time.Minute,
+	}
+
+	input := `Start,Stop,Workspace Name,Project Name,Client Name,Tag(s),Description,Billable
+2015-03-26T08:00:00+01:00,2015-03-26T08:30:00+01:00,Workspace,Project XY,Client X,,Coding,yes`
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	err := merger.Merge(strings.NewReader(input), &outputBuffer, &bytes.Buffer{})
+
+	// assert
+	expected := "Start,Stop,Workspace Name,Project Name,Client Name,Tag(s),Description,Billable\n" +
+		"2015-03-26T08:00:00+01:00,2015-03-26T08:30:00+01:00,Workspace,Project XY,Client X,,Coding,yes\n"
+	if err != nil || outputBuffer.String() != expected {
+		t.Fail()
+		t.Logf("Merge should have written %q but wrote %q (%v)", expected, outputBuffer.String(), err)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// The policies for time records of the same workspace that overlap each other.
+const (
+	// overlapPolicyError aborts the import.
+	overlapPolicyError = "error"
+
+	// overlapPolicyWarn prints the overlaps and imports all time records.
+	overlapPolicyWarn = "warn"
+
+	// overlapPolicySkipLater doesn't import the time record that starts later.
+	overlapPolicySkipLater = "skip-later"
+
+	// overlapPolicyTrim shortens the time record that starts earlier.
+	overlapPolicyTrim = "trim"
+)
+
+// overlapPolicies contains all available overlap policies.
+var overlapPolicies = []string{overlapPolicyError, overlapPolicyWarn, overlapPolicySkipLater, overlapPolicyTrim}
+
+// overlapTimeFormat defines how start and stop dates are printed in overlap reports.
+const overlapTimeFormat = "2006-01-02 15:04"
+
+// resolveOverlaps finds time records of the same workspace that overlap each other
+// and handles them according to the given policy. positions contains the position of every time record
+// in the input such as "line 12" or "record 3" (optional; default: the number of the time record).
+// Running time records are not checked because they have no stop date yet.
+// Returns the remaining time records in their original order or an error if the policy is "error" and overlaps were found.
+func resolveOverlaps(timeRecords []toggl.TimeRecord, positions []string, policy string, output io.Writer) ([]toggl.TimeRecord, error) {
+	if output == nil {
+		output = ioutil.Discard
+	}
+
+	result := make([]toggl.TimeRecord, len(timeRecords))
+	copy(result, timeRecords)
+
+	position := func(index int) string {
+		if index < len(positions) {
+			return positions[index]
+		}
+
+		return fmt.Sprintf("record %d", index+1)
+	}
+
+	removed := make([]bool, len(result))
+	describe := func(index int) string {
+		return fmt.Sprintf("%s (%s - %s)", position(index), result[index].Start.Format(overlapTimeFormat), result[index].Stop.Format(overlapTimeFormat))
+	}
+
+	// sweep through the time records by start date and keep track of the
+	// records of each workspace that have not ended yet
+	order := make([]int, len(result))
+	for index := range order {
+		order[index] = index
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return result[order[i]].Start.Before(result[order[j]].Start)
+	})
+
+	var conflicts []string
+	activeByWorkspace := make(map[string][]int)
+	for _, index := range order {
+		timeRecord := result[index]
+		if timeRecord.IsRunning() {
+			continue
+		}
+
+		var overlapping []int
+		for _, earlierIndex := range activeByWorkspace[timeRecord.WorkspaceName] {
+			if result[earlierIndex].Stop.After(timeRecord.Start) {
+				overlapping = append(overlapping, earlierIndex)
+			}
+		}
+
+		var skipReasons []string
+		for _, earlierIndex := range overlapping {
+			conflict := fmt.Sprintf("%s overlaps %s in workspace %q", describe(earlierIndex), describe(index), timeRecord.WorkspaceName)
+
+			switch policy {
+			case overlapPolicySkipLater:
+				skipReasons = append(skipReasons, conflict)
+				removed[index] = true
+
+			case overlapPolicyTrim:
+				fmt.Fprintf(output, "Trimming %s to end at %s: %s\n", position(earlierIndex), timeRecord.Start.Format(overlapTimeFormat), conflict)
+
+				// an earlier time record that contains the later one loses the time after it
+				if result[earlierIndex].Stop.After(timeRecord.Stop) {
+					fmt.Fprintf(output, "Warning: Trimming %s removes %s - %s (%s) which %s doesn't cover\n", position(earlierIndex), timeRecord.Stop.Format(overlapTimeFormat), result[earlierIndex].Stop.Format(overlapTimeFormat), result[earlierIndex].Stop.Sub(timeRecord.Stop), position(index))
+				}
+
+				result[earlierIndex].Stop = timeRecord.Start
+				if !result[earlierIndex].Stop.After(result[earlierIndex].Start) {
+					fmt.Fprintf(output, "Skipping %s because nothing is left after trimming it\n", position(earlierIndex))
+					removed[earlierIndex] = true
+				}
+
+			default:
+				conflicts = append(conflicts, conflict)
+			}
+		}
+
+		if len(skipReasons) > 0 {
+			fmt.Fprintf(output, "Skipping %s:\n  %s\n", position(index), strings.Join(skipReasons, "\n  "))
+		}
+
+		// keep the records that have not ended yet
+		var active []int
+		for _, earlierIndex := range activeByWorkspace[timeRecord.WorkspaceName] {
+			if !removed[earlierIndex] && result[earlierIndex].Stop.After(timeRecord.Start) {
+				active = append(active, earlierIndex)
+			}
+		}
+
+		if !removed[index] {
+			active = append(active, index)
+		}
+
+		activeByWorkspace[timeRecord.WorkspaceName] = active
+	}
+
+	if len(conflicts) > 0 {
+		if policy == overlapPolicyError {
+			return nil, fmt.Errorf("Found %d overlap(s) between time records:\n  %s", len(conflicts), strings.Join(conflicts, "\n  "))
+		}
+
+		for _, conflict := range conflicts {
+			fmt.Fprintf(output, "Warning: %s\n", conflict)
+		}
+	}
+
+	var remaining []toggl.TimeRecord
+	for index, timeRecord := range result {
+		if removed[index] {
+			continue
+		}
+
+		remaining = append(remaining, timeRecord)
+	}
+
+	return remaining, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func getOverlappingTestTimeRecords() []toggl.TimeRecord {
+	return []toggl.TimeRecord{
+		toggl.TimeRecord{
+			WorkspaceName: "Workspace",
+			Start:         time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+			Stop:          time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC),
+			Description:   "Earlier",
+		},
+		toggl.TimeRecord{
+			WorkspaceName: "Other Workspace",
+			Start:         time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+			Stop:          time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC),
+			Description:   "Other workspace",
+		},
+		toggl.TimeRecord{
+			WorkspaceName: "Workspace",
+			Start:         time.Date(2016, 8, 12, 9, 30, 0, 0, time.UTC),
+			Stop:          time.Date(2016, 8, 12, 11, 0, 0, 0, time.UTC),
+			Description:   "Later",
+		},
+		toggl.TimeRecord{
+			WorkspaceName: "Workspace",
+			Start:         time.Date(2016, 8, 12, 11, 0, 0, 0, time.UTC),
+			Stop:          time.Date(2016, 8, 12, 12, 0, 0, 0, time.UTC),
+			Description:   "Adjacent",
+		},
+	}
+}
+
+func Test_resolveOverlaps_Error_ConflictingLinesAreReturned(t *testing.T) {
+	// arrange
+	timeRecords := getOverlappingTestTimeRecords()
+
+	// act
+	_, err := resolveOverlaps(timeRecords, []string{"line 2", "line 3", "line 4", "line 5"}, overlapPolicyError, nil)
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "Found 1 overlap(s)") || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "line 4") {
+		t.Fail()
+		t.Logf("resolveOverlaps should have reported that line 2 and line 4 overlap but returned: %v", err)
+	}
+}
+
+func Test_resolveOverlaps_Warn_AllRecordsAreReturned_WarningIsPrinted(t *testing.T) {
+	// arrange
+	timeRecords := getOverlappingTestTimeRecords()
+	var outputBuffer bytes.Buffer
+
+	// act
+	result, err := resolveOverlaps(timeRecords, nil, overlapPolicyWarn, &outputBuffer)
+
+	// assert
+	if err != nil || len(result) != len(timeRecords) {
+		t.Fail()
+		t.Logf("resolveOverlaps should have returned all time records but returned %d (%v)", len(result), err)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "Warning: record 1") {
+		t.Fail()
+		t.Logf("resolveOverlaps should have printed a warning but printed: %s", outputBuffer.String())
+	}
+}
+
+func Test_resolveOverlaps_SkipLater_LaterRecordIsRemoved(t *testing.T) {
+	// arrange
+	timeRecords := getOverlappingTestTimeRecords()
+
+	// act
+	result, _ := resolveOverlaps(timeRecords, nil, overlapPolicySkipLater, nil)
+
+	// assert
+	var descriptions []string
+	for _, timeRecord := range result {
+		descriptions = append(descriptions, timeRecord.Description)
+	}
+
+	if strings.Join(descriptions, ",") != "Earlier,Other workspace,Adjacent" {
+		t.Fail()
+		t.Logf("resolveOverlaps should have skipped the later time record but returned: %v", descriptions)
+	}
+}
+
+func Test_resolveOverlaps_Trim_EarlierRecordIsShortened(t *testing.T) {
+	// arrange
+	timeRecords := getOverlappingTestTimeRecords()
+
+	// act
+	result, _ := resolveOverlaps(timeRecords, nil, overlapPolicyTrim, nil)
+
+	// assert
+	expectedStop := time.Date(2016, 8, 12, 9, 30, 0, 0, time.UTC)
+	if len(result) != len(timeRecords) || !result[0].Stop.Equal(expectedStop) {
+		t.Fail()
+		t.Logf("resolveOverlaps should have trimmed the earlier time record to %s but returned: %#v", expectedStop, result)
+	}
+
+	if !timeRecords[0].Stop.Equal(time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC)) {
+		t.Fail()
+		t.Logf("resolveOverlaps should not modify the given time records")
+	}
+}
+
+func Test_resolveOverlaps_Trim_SameStart_EarlierRecordIsRemoved(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{
+			Start:       time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+			Stop:        time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC),
+			Description: "First",
+		},
+		toggl.TimeRecord{
+			Start:       time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+			Stop:        time.Date(2016, 8, 12, 9, 30, 0, 0, time.UTC),
+			Description: "Second",
+		},
+	}
+
+	// act
+	result, _ := resolveOverlaps(timeRecords, nil, overlapPolicyTrim, nil)
+
+	// assert
+	if len(result) != 1 || result[0].Description != "Second" {
+		t.Fail()
+		t.Logf("resolveOverlaps should have removed the time record that is empty after trimming but returned: %#v", result)
+	}
+}
+
+func Test_resolveOverlaps_RunningTimeRecord_RecordIsNotChecked(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{
+			WorkspaceName: "Workspace",
+			Start:         time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+		},
+		toggl.TimeRecord{
+			WorkspaceName: "Workspace",
+			Start:         time.Date(2016, 8, 12, 9, 30, 0, 0, time.UTC),
+			Stop:          time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	// act
+	result, err := resolveOverlaps(timeRecords, nil, overlapPolicyError, nil)
+
+	// assert
+	if err != nil || len(result) != 2 || !result[0].IsRunning() {
+		t.Fail()
+		t.Logf("resolveOverlaps should have kept the running time record but returned %#v (%v)", result, err)
+	}
+}
+
+func Test_resolveOverlaps_SkipLater_SeveralConflicts_RecordIsSkippedOnce(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{
+			Start: time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+			Stop:  time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC),
+		},
+		toggl.TimeRecord{
+			Start: time.Date(2016, 8, 12, 9, 15, 0, 0, time.UTC),
+			Stop:  time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC),
+		},
+		toggl.TimeRecord{
+			Start: time.Date(2016, 8, 12, 9, 30, 0, 0, time.UTC),
+			Stop:  time.Date(2016, 8, 12, 11, 0, 0, 0, time.UTC),
+		},
+	}
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	result, _ := resolveOverlaps(timeRecords, nil, overlapPolicySkipLater, &outputBuffer)
+
+	// assert
+	output := outputBuffer.String()
+	if len(result) != 1 || strings.Count(output, "Skipping record 3") != 1 || !strings.Contains(output, "record 1 (2016-08-12 09:00 - 2016-08-12 10:00) overlaps record 3") {
+		t.Fail()
+		t.Logf("resolveOverlaps should have skipped every later record once and listed all conflicts but printed: %s", output)
+	}
+}
+
+func Test_resolveOverlaps_Trim_EarlierRecordContainsLaterRecord_LostTimeIsReported(t *testing.T) {
+	// arrange
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{
+			Start: time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC),
+			Stop:  time.Date(2016, 8, 12, 12, 0, 0, 0, time.UTC),
+		},
+		toggl.TimeRecord{
+			Start: time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC),
+			Stop:  time.Date(2016, 8, 12, 11, 0, 0, 0, time.UTC),
+		},
+	}
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	result, _ := resolveOverlaps(timeRecords, []string{"line 2", "line 3"}, overlapPolicyTrim, &outputBuffer)
+
+	// assert
+	if len(result) != 2 || !result[0].Stop.Equal(time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC)) {
+		t.Fail()
+		t.Logf("resolveOverlaps should have trimmed the earlier time record to 10:00 but returned: %#v", result)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "Warning: Trimming line 2 removes 2016-08-12 11:00 - 2016-08-12 12:00 (1h0m0s)") {
+		t.Fail()
+		t.Logf("resolveOverlaps should have reported the time lost after the contained time record but printed: %s", outputBuffer.String())
+	}
+}
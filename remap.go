@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+	"github.com/pkg/errors"
+)
+
+// remapColumnNames contains the column names of a map file.
+var remapColumnNames = []string{"Type", "Name", "New Name", "Workspace Name", "Client Name"}
+
+// The remap rule types.
+const (
+	remapTypeWorkspace = "workspace"
+	remapTypeClient    = "client"
+	remapTypeProject   = "project"
+	remapTypeTag       = "tag"
+)
+
+// The TimeRecordTransformer interface modifies time records before they are imported.
+type TimeRecordTransformer interface {
+	// Transform returns the modified version of the given time record.
+	Transform(timeRecord toggl.TimeRecord) toggl.TimeRecord
+}
+
+// remapRule renames a workspace, client, project or tag.
+type remapRule struct {
+	name    string
+	newName string
+
+	// workspaceName and clientName restrict the rule to time records
+	// of the given workspace or client (empty for all)
+	workspaceName string
+	clientName    string
+}
+
+// matches returns true if the rule applies to the given name of the given time record.
+func (rule remapRule) matches(name string, timeRecord toggl.TimeRecord) bool {
+	if rule.name != name {
+		return false
+	}
+
+	if rule.workspaceName != "" && rule.workspaceName != timeRecord.WorkspaceName {
+		return false
+	}
+
+	if rule.clientName != "" && rule.clientName != timeRecord.ClientName {
+		return false
+	}
+
+	return true
+}
+
+// loadTimeRecordRemapper reads the rename rules from the CSV map file with the given path.
+func loadTimeRecordRemapper(path string) (*TimeRecordRemapper, error) {
+	file, openError := os.Open(path)
+	if openError != nil {
+		return nil, errors.Wrap(openError, fmt.Sprintf("Failed to open the map file %q", path))
+	}
+
+	defer file.Close()
+
+	remapper, readError := readTimeRecordRemapper(file)
+	if readError != nil {
+		return nil, errors.Wrap(readError, fmt.Sprintf("Failed to read the map file %q", path))
+	}
+
+	return remapper, nil
+}
+
+// readTimeRecordRemapper reads the rename rules from the given CSV input.
+// Every row contains the type (workspace, client, project or tag), the name, the new name
+// and optionally the workspace and client name the rule is restricted to.
+func readTimeRecordRemapper(input io.Reader) (*TimeRecordRemapper, error) {
+	rows, lines, csvError := readCSVRows(input)
+	if csvError != nil {
+		return nil, csvError
+	}
+
+	remapper := &TimeRecordRemapper{}
+	for index, row := range rows {
+
+		// skip the headline
+		if index == 0 && isHeadline(row, remapColumnNames) {
+			continue
+		}
+
+		if len(row) < 3 || len(row) > len(remapColumnNames) {
+			return nil, fmt.Errorf("Line %d: Wrong number of values. Expected 3 to %d but got %d", lines[index], len(remapColumnNames), len(row))
+		}
+
+		for len(row) < len(remapColumnNames) {
+			row = append(row, "")
+		}
+
+		rule := remapRule{
+			name:          strings.TrimSpace(row[1]),
+			newName:       strings.TrimSpace(row[2]),
+			workspaceName: strings.TrimSpace(row[3]),
+			clientName:    strings.TrimSpace(row[4]),
+		}
+
+		if rule.name == "" || rule.newName == "" {
+			return nil, fmt.Errorf("Line %d: The name and the new name must not be empty", lines[index])
+		}
+
+		switch ruleType := strings.ToLower(strings.TrimSpace(row[0])); ruleType {
+		case remapTypeWorkspace:
+			remapper.workspaces = append(remapper.workspaces, rule)
+
+		case remapTypeClient:
+			remapper.clients = append(remapper.clients, rule)
+
+		case remapTypeProject:
+			remapper.projects = append(remapper.projects, rule)
+
+		case remapTypeTag:
+			remapper.tags = append(remapper.tags, rule)
+
+		default:
+			return nil, fmt.Errorf("Line %d: Unknown type %q. Use workspace, client, project or tag", lines[index], row[0])
+		}
+	}
+
+	return remapper, nil
+}
+
+// TimeRecordRemapper renames the workspaces, clients, projects and tags of time records.
+// All rules are matched against the original names of a time record; the first matching rule of each type wins.
+type TimeRecordRemapper struct {
+	workspaces []remapRule
+	clients    []remapRule
+	projects   []remapRule
+	tags       []remapRule
+}
+
+// Transform returns the given time record with the new names.
+func (remapper *TimeRecordRemapper) Transform(timeRecord toggl.TimeRecord) toggl.TimeRecord {
+	original := timeRecord
+
+	timeRecord.WorkspaceName = remap(remapper.workspaces, original.WorkspaceName, original)
+	timeRecord.ClientName = remap(remapper.clients, original.ClientName, original)
+	timeRecord.ProjectName = remap(remapper.projects, original.ProjectName, original)
+
+	if len(original.Tags) > 0 {
+		timeRecord.Tags = make([]string, len(original.Tags))
+		for index, tag := range original.Tags {
+			timeRecord.Tags[index] = remap(remapper.tags, tag, original)
+		}
+	}
+
+	return timeRecord
+}
+
+// remap returns the new name of the first rule that matches the given name or the name itself.
+func remap(rules []remapRule, name string, timeRecord toggl.TimeRecord) string {
+	for _, rule := range rules {
+		if rule.matches(name, timeRecord) {
+			return rule.newName
+		}
+	}
+
+	return name
+}
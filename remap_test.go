@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func Test_readTimeRecordRemapper_ValidRules_RulesAreReturned(t *testing.T) {
+	// arrange
+	input := `Type,Name,New Name,Workspace Name,Client Name
+workspace,Old Co,New Co,,
+client,ACME,ACME Inc.
+project,Website,ACME Web Relaunch,,ACME
+tag,billable,Billable,,`
+
+	// act
+	remapper, err := readTimeRecordRemapper(strings.NewReader(input))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("readTimeRecordRemapper should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	if len(remapper.workspaces) != 1 || len(remapper.clients) != 1 || len(remapper.projects) != 1 || len(remapper.tags) != 1 {
+		t.Fail()
+		t.Logf("readTimeRecordRemapper should have returned one rule of each type but returned: %#v", remapper)
+	}
+
+	if remapper.projects[0].clientName != "ACME" {
+		t.Fail()
+		t.Logf("readTimeRecordRemapper should have restricted the project rule to the client %q but returned: %#v", "ACME", remapper.projects[0])
+	}
+}
+
+func Test_readTimeRecordRemapper_InvalidRules_ErrorIsReturned(t *testing.T) {
+	// arrange
+	inputs := []string{
+		`team,Old,New`,
+		`workspace,Old`,
+		`project,,New`,
+		`project,Old,New,Workspace,Client,Something else`,
+	}
+
+	for _, input := range inputs {
+
+		// act
+		_, err := readTimeRecordRemapper(strings.NewReader(input))
+
+		// assert
+		if err == nil {
+			t.Fail()
+			t.Logf("readTimeRecordRemapper should return an error for %q", input)
+		}
+	}
+}
+
+func Test_TimeRecordRemapper_Transform_NamesAreReplaced(t *testing.T) {
+	// arrange
+	remapper, _ := readTimeRecordRemapper(strings.NewReader(`workspace,Old Co,New Co
+client,ACME,ACME Inc.
+project,Website,ACME Web Relaunch,,ACME
+tag,billable,Billable`))
+
+	timeRecord := toggl.TimeRecord{
+		WorkspaceName: "Old Co",
+		ClientName:    "ACME",
+		ProjectName:   "Website",
+		Tags:          []string{"billable", "meeting"},
+	}
+
+	// act
+	result := remapper.Transform(timeRecord)
+
+	// assert
+	if result.WorkspaceName != "New Co" || result.ClientName != "ACME Inc." || result.ProjectName != "ACME Web Relaunch" {
+		t.Fail()
+		t.Logf("Transform should have renamed the workspace, client and project but returned: %#v", result)
+	}
+
+	if strings.Join(result.Tags, ",") != "Billable,meeting" {
+		t.Fail()
+		t.Logf("Transform should have renamed the tag but returned: %v", result.Tags)
+	}
+
+	if timeRecord.Tags[0] != "billable" {
+		t.Fail()
+		t.Logf("Transform should not modify the tags of the given time record")
+	}
+}
+
+func Test_TimeRecordRemapper_Transform_RuleOfOtherClient_ProjectIsNotRenamed(t *testing.T) {
+	// arrange
+	remapper, _ := readTimeRecordRemapper(strings.NewReader(`project,Website,ACME Web Relaunch,,ACME`))
+
+	timeRecord := toggl.TimeRecord{
+		WorkspaceName: "Workspace",
+		ClientName:    "Globex",
+		ProjectName:   "Website",
+	}
+
+	// act
+	result := remapper.Transform(timeRecord)
+
+	// assert
+	if result.ProjectName != "Website" {
+		t.Fail()
+		t.Logf("Transform should not rename the project of another client but returned: %#v", result)
+	}
+}
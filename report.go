@@ -0,0 +1,429 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// The attributes the time records of a report can be grouped by.
+const (
+	reportGroupWorkspace = "workspace"
+	reportGroupClient    = "client"
+	reportGroupProject   = "project"
+	reportGroupTag       = "tag"
+	reportGroupDay       = "day"
+	reportGroupWeek      = "week"
+	reportGroupMonth     = "month"
+)
+
+// reportGroups contains all attributes a report can be grouped by.
+var reportGroups = []string{reportGroupWorkspace, reportGroupClient, reportGroupProject, reportGroupTag, reportGroupDay, reportGroupWeek, reportGroupMonth}
+
+// reportGroupTitles contains the column titles of the report groups.
+var reportGroupTitles = map[string]string{
+	reportGroupWorkspace: "Workspace",
+	reportGroupClient:    "Client",
+	reportGroupProject:   "Project",
+	reportGroupTag:       "Tag",
+	reportGroupDay:       "Day",
+	reportGroupWeek:      "Week",
+	reportGroupMonth:     "Month",
+}
+
+// The ways time records with several tags are counted if a report is grouped by tag.
+const (
+	// tagModeSplit divides the duration evenly between the tags
+	tagModeSplit = "split"
+
+	// tagModeFull counts the full duration for every tag
+	tagModeFull = "full"
+
+	// tagModeCombination groups by the combination of all tags
+	tagModeCombination = "combination"
+)
+
+// tagModes contains all available tag modes.
+var tagModes = []string{tagModeSplit, tagModeFull, tagModeCombination}
+
+// reportFormatTable selects a human-readable table as the output format of a report.
+const reportFormatTable = "table"
+
+// reportFormats contains all output formats of a report.
+var reportFormats = []string{reportFormatTable, formatCSV, formatJSON}
+
+// The Reporter interface prints the totals of the time records of a Toggl account.
+type Reporter interface {
+	// Report prints the totals of all time records from the given start date until the given end date.
+	Report(startDate, endDate time.Time, writer io.Writer) error
+}
+
+// ReportOptions contains the settings of a report.
+type ReportOptions struct {
+	// GroupBy contains the attributes the time records are grouped by (see reportGroups).
+	GroupBy []string
+
+	// TagMode defines how time records with several tags are counted if GroupBy contains "tag"
+	// (split, full or combination; default: split).
+	TagMode string
+
+	// Format selects the output format (table, csv or json; default: table).
+	Format string
+
+	// Location defines the time zone of the days, weeks and months (optional).
+	Location *time.Location
+
+	// Filter selects the time records of the report (optional).
+	Filter *ExportFilter
+}
+
+// TogglReporter prints the totals of the time records of a Toggl account.
+type TogglReporter struct {
+	timeRecordRepository toggl.TimeRecorder
+
+	// groupBy contains the attributes the time records are grouped by
+	groupBy []string
+
+	// tagMode defines how time records with several tags are counted
+	tagMode string
+
+	// format selects the output format (table, csv or json; default: table)
+	format string
+
+	// location defines the time zone of the days, weeks and months (optional)
+	location *time.Location
+
+	// filter selects the time records of the report (optional)
+	filter *ExportFilter
+}
+
+// reportRow contains the totals of one group of time records.
+type reportRow struct {
+	groups   []string
+	duration time.Duration
+	count    int
+}
+
+// Report prints the totals of all completed time records from the given start date until the given end date
+// grouped by the configured attributes.
+func (reporter *TogglReporter) Report(startDate, endDate time.Time, writer io.Writer) error {
+	records, timeRecordsError := reporter.timeRecordRepository.GetTimeRecords(startDate, endDate)
+	if timeRecordsError != nil {
+		return fmt.Errorf("Failed to retrieve time records between %q and %q: %s", startDate, endDate, timeRecordsError.Error())
+	}
+
+	// timeRecords contains the parts of every time record that are counted
+	var timeRecords [][]toggl.TimeRecord
+	for _, record := range records {
+		if record.IsRunning() {
+			continue
+		}
+
+		if reporter.filter != nil && !reporter.filter.Includes(record) {
+			continue
+		}
+
+		// split at the day boundaries so that every part counts towards its own day, week and month
+		if reporter.isGroupedByDate() {
+			var segments []toggl.TimeRecord
+			for _, segment := range splitTimeRecord(record, splitPeriodDay, reporter.location) {
+				if isInDateRange(segment.Start, startDate, endDate) {
+					segments = append(segments, segment)
+				}
+			}
+
+			if len(segments) > 0 {
+				timeRecords = append(timeRecords, segments)
+			}
+
+			continue
+		}
+
+		timeRecords = append(timeRecords, []toggl.TimeRecord{record})
+	}
+
+	rows := reporter.getRows(timeRecords)
+
+	switch reporter.format {
+	case formatCSV:
+		return reporter.writeCSV(writer, rows)
+
+	case formatJSON:
+		return reporter.writeJSON(writer, rows)
+	}
+
+	return reporter.writeTable(writer, rows, timeRecords)
+}
+
+// isGroupedByDate returns true if the report is grouped by day, week or month.
+func (reporter *TogglReporter) isGroupedByDate() bool {
+	for _, group := range reporter.groupBy {
+		if group == reportGroupDay || group == reportGroupWeek || group == reportGroupMonth {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getRows returns the totals of the given time records for every group ordered by the group values.
+// Every element of timeRecords contains the parts of one time record. The count of a row is the number
+// of time records with at least one part or tag share in the row, so a time record is counted once per row.
+func (reporter *TogglReporter) getRows(timeRecords [][]toggl.TimeRecord) []*reportRow {
+	var rows []*reportRow
+	rowsByKey := make(map[string]*reportRow)
+
+	for _, segments := range timeRecords {
+
+		// counted contains the rows that already count the current time record
+		counted := make(map[*reportRow]bool)
+		for _, timeRecord := range segments {
+			for _, share := range reporter.getTagShares(timeRecord) {
+				var groups []string
+				for _, group := range reporter.groupBy {
+					groups = append(groups, reporter.getGroupValue(timeRecord, group, share.tag))
+				}
+
+				key := strings.Join(groups, "\x00")
+				row, exists := rowsByKey[key]
+				if !exists {
+					row = &reportRow{groups: groups}
+					rowsByKey[key] = row
+					rows = append(rows, row)
+				}
+
+				row.duration += share.duration
+				if !counted[row] {
+					row.count++
+					counted[row] = true
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for index := range rows[i].groups {
+			if rows[i].groups[index] != rows[j].groups[index] {
+				return rows[i].groups[index] < rows[j].groups[index]
+			}
+		}
+
+		return false
+	})
+
+	return rows
+}
+
+// tagShare contains the part of the duration of a time record that is counted for a tag.
+type tagShare struct {
+	tag      string
+	duration time.Duration
+}
+
+// getTagShares returns the tags the given time record is counted for according to the tag mode.
+// Time records without tags and reports that are not grouped by tag count with their full duration once.
+func (reporter *TogglReporter) getTagShares(timeRecord toggl.TimeRecord) []tagShare {
+	duration := timeRecord.Stop.Sub(timeRecord.Start)
+
+	groupedByTag := false
+	for _, group := range reporter.groupBy {
+		groupedByTag = groupedByTag || group == reportGroupTag
+	}
+
+	if !groupedByTag || len(timeRecord.Tags) == 0 {
+		return []tagShare{{duration: duration}}
+	}
+
+	switch reporter.tagMode {
+	case tagModeCombination:
+		tags := make([]string, len(timeRecord.Tags))
+		copy(tags, timeRecord.Tags)
+		sort.Strings(tags)
+
+		return []tagShare{{tag: strings.Join(tags, ", "), duration: duration}}
+
+	case tagModeFull:
+		var shares []tagShare
+		for _, tag := range timeRecord.Tags {
+			shares = append(shares, tagShare{tag: tag, duration: duration})
+		}
+
+		return shares
+	}
+
+	// the first tag gets the remainder so that the shares add up to the duration
+	tagCount := time.Duration(len(timeRecord.Tags))
+	var shares []tagShare
+	for index, tag := range timeRecord.Tags {
+		share := tagShare{tag: tag, duration: duration / tagCount}
+		if index == 0 {
+			share.duration += duration % tagCount
+		}
+
+		shares = append(shares, share)
+	}
+
+	return shares
+}
+
+// getGroupValue returns the value of the given group for the given time record and tag.
+func (reporter *TogglReporter) getGroupValue(timeRecord toggl.TimeRecord, group, tag string) string {
+	location := reporter.location
+	if location == nil {
+		location = time.UTC
+	}
+
+	start := timeRecord.Start.In(location)
+
+	switch group {
+	case reportGroupWorkspace:
+		return timeRecord.WorkspaceName
+
+	case reportGroupClient:
+		return timeRecord.ClientName
+
+	case reportGroupProject:
+		return timeRecord.ProjectName
+
+	case reportGroupTag:
+		return tag
+
+	case reportGroupDay:
+		return start.Format(exportDateFormat)
+
+	case reportGroupWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+
+	case reportGroupMonth:
+		return start.Format("2006-01")
+	}
+
+	return ""
+}
+
+// getTitles returns the column titles of the groups.
+func (reporter *TogglReporter) getTitles() []string {
+	var titles []string
+	for _, group := range reporter.groupBy {
+		titles = append(titles, reportGroupTitles[group])
+	}
+
+	return titles
+}
+
+// writeTable writes the given rows and the total of the given time records as a human-readable table.
+func (reporter *TogglReporter) writeTable(writer io.Writer, rows []*reportRow, timeRecords [][]toggl.TimeRecord) error {
+	tableWriter := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, strings.Join(append(reporter.getTitles(), "Hours", "Count"), "\t"))
+
+	for _, row := range rows {
+		var cells []string
+		for _, value := range row.groups {
+			if value == "" {
+				value = "-"
+			}
+
+			cells = append(cells, value)
+		}
+
+		cells = append(cells, formatDecimal(row.duration.Hours()), strconv.Itoa(row.count))
+		fmt.Fprintln(tableWriter, strings.Join(cells, "\t"))
+	}
+
+	// the total counts every time record once, independent of the tag mode
+	var total time.Duration
+	for _, segments := range timeRecords {
+		for _, timeRecord := range segments {
+			total += timeRecord.Stop.Sub(timeRecord.Start)
+		}
+	}
+
+	totalCells := make([]string, len(reporter.groupBy))
+	if len(totalCells) > 0 {
+		totalCells[0] = "Total"
+	}
+
+	totalCells = append(totalCells, formatDecimal(total.Hours()), strconv.Itoa(len(timeRecords)))
+	fmt.Fprintln(tableWriter, strings.Join(totalCells, "\t"))
+
+	return tableWriter.Flush()
+}
+
+// writeCSV writes the given rows as CSV with the hours as decimal numbers.
+func (reporter *TogglReporter) writeCSV(writer io.Writer, rows []*reportRow) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write(append(reporter.getTitles(), "Hours", "Count"))
+
+	for _, row := range rows {
+		values := append([]string{}, row.groups...)
+		csvWriter.Write(append(values, formatDecimal(row.duration.Hours()), strconv.Itoa(row.count)))
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// writeJSON writes the given rows as a JSON array of objects with the group values,
+// the duration in seconds, the hours and the count.
+func (reporter *TogglReporter) writeJSON(writer io.Writer, rows []*reportRow) error {
+	objects := []map[string]interface{}{}
+	for _, row := range rows {
+		object := map[string]interface{}{
+			"seconds": int64(row.duration / time.Second),
+			"hours":   math.Round(row.duration.Hours()*100) / 100,
+			"count":   row.count,
+		}
+
+		for index, group := range reporter.groupBy {
+			object[group] = row.groups[index]
+		}
+
+		objects = append(objects, object)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(objects)
+}
+
+// parseReportGroups returns the groups of the given comma-separated values.
+// Returns an error for unknown or repeated groups.
+func parseReportGroups(values []string) ([]string, error) {
+	var groups []string
+	for _, value := range values {
+		for _, group := range strings.Split(value, ",") {
+			group = strings.ToLower(strings.TrimSpace(group))
+			if group == "" {
+				continue
+			}
+
+			if _, isGroup := reportGroupTitles[group]; !isGroup {
+				return nil, fmt.Errorf("Unknown group %q. Use %s", group, strings.Join(reportGroups, ", "))
+			}
+
+			for _, existing := range groups {
+				if existing == group {
+					return nil, fmt.Errorf("The group %q is given more than once", group)
+				}
+			}
+
+			groups = append(groups, group)
+		}
+	}
+
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("The report needs at least one group")
+	}
+
+	return groups, nil
+}
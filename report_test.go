@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// getReportTestTimeRecords returns completed time records of two projects in two ISO weeks and one running time record.
+func getReportTestTimeRecords() []toggl.TimeRecord {
+	return []toggl.TimeRecord{
+		{
+			ProjectName: "Website",
+			Tags:        []string{"design", "meeting"},
+			Start:       time.Date(2016, 8, 5, 9, 0, 0, 0, time.UTC),
+			Stop:        time.Date(2016, 8, 5, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			ProjectName: "Website",
+			Tags:        []string{"design"},
+			Start:       time.Date(2016, 8, 8, 9, 0, 0, 0, time.UTC),
+			Stop:        time.Date(2016, 8, 8, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			ProjectName: "App",
+			Start:       time.Date(2016, 8, 8, 13, 0, 0, 0, time.UTC),
+			Stop:        time.Date(2016, 8, 8, 13, 45, 0, 0, time.UTC),
+		},
+		{
+			ProjectName: "App",
+			Start:       time.Date(2016, 8, 9, 13, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+// getTestReport returns the report of the test time records with the given options.
+func getTestReport(groupBy []string, tagMode, format string) (string, error) {
+	reporter := &TogglReporter{
+		timeRecordRepository: &mockTimeRecordRepository{
+			getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+				return getReportTestTimeRecords(), nil
+			},
+		},
+		groupBy: groupBy,
+		tagMode: tagMode,
+		format:  format,
+	}
+
+	var output bytes.Buffer
+	err := reporter.Report(time.Date(2016, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2016, 8, 31, 0, 0, 0, 0, time.UTC), &output)
+	return output.String(), err
+}
+
+func Test_Report_GroupByProjectAndWeek_TotalsArePrintedAsTable(t *testing.T) {
+	// act
+	result, err := getTestReport([]string{reportGroupProject, reportGroupWeek}, tagModeSplit, reportFormatTable)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Report should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	expected := `Project  Week      Hours  Count
+App      2016-W32  0.75   1
+Website  2016-W31  2      1
+Website  2016-W32  1.5    1
+Total              4.25   3
+`
+
+	if result != expected {
+		t.Fail()
+		t.Logf("Report should have printed\n%s\nbut printed\n%s", expected, result)
+	}
+}
+
+func Test_Report_GroupByTag_DurationIsCountedAccordingToTagMode(t *testing.T) {
+	inputs := map[string]string{
+		tagModeSplit:       "Tag,Hours,Count\n,0.75,1\ndesign,2.5,2\nmeeting,1,1\n",
+		tagModeFull:        "Tag,Hours,Count\n,0.75,1\ndesign,3.5,2\nmeeting,2,1\n",
+		tagModeCombination: "Tag,Hours,Count\n,0.75,1\ndesign,1.5,1\n\"design, meeting\",2,1\n",
+	}
+
+	for tagMode, expected := range inputs {
+		// act
+		result, err := getTestReport([]string{reportGroupTag}, tagMode, formatCSV)
+
+		// assert
+		if err != nil || result != expected {
+			t.Fail()
+			t.Logf("Report with the tag mode %q should have printed\n%s\nbut printed\n%s (%v)", tagMode, expected, result, err)
+		}
+	}
+}
+
+func Test_Report_JSONFormat_GroupValuesHoursAndSecondsAreWritten(t *testing.T) {
+	// act
+	result, err := getTestReport([]string{reportGroupMonth, reportGroupProject}, tagModeSplit, formatJSON)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Report should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	var rows []struct {
+		Month   string  `json:"month"`
+		Project string  `json:"project"`
+		Hours   float64 `json:"hours"`
+		Seconds int64   `json:"seconds"`
+		Count   int     `json:"count"`
+	}
+
+	if jsonError := json.Unmarshal([]byte(result), &rows); jsonError != nil {
+		t.Fail()
+		t.Logf("Report should have written valid JSON but wrote %s (%s)", result, jsonError.Error())
+		return
+	}
+
+	if len(rows) != 2 || rows[1].Month != "2016-08" || rows[1].Project != "Website" || rows[1].Hours != 3.5 || rows[1].Seconds != 12600 || rows[1].Count != 2 {
+		t.Fail()
+		t.Logf("Report wrote the wrong rows: %s", result)
+	}
+}
+
+func Test_Report_TimeRecordCrossesMidnight_DurationIsCountedForBothDays(t *testing.T) {
+	// arrange
+	reporter := &TogglReporter{
+		timeRecordRepository: &mockTimeRecordRepository{
+			getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+				return []toggl.TimeRecord{
+					{
+						ProjectName: "Website",
+						Start:       time.Date(2016, 8, 1, 23, 0, 0, 0, time.UTC),
+						Stop:        time.Date(2016, 8, 2, 1, 30, 0, 0, time.UTC),
+					},
+				}, nil
+			},
+		},
+		groupBy: []string{reportGroupDay},
+		format:  formatCSV,
+	}
+
+	var output bytes.Buffer
+
+	// act
+	err := reporter.Report(time.Date(2016, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2016, 8, 31, 0, 0, 0, 0, time.UTC), &output)
+
+	// assert
+	expected := "Day,Hours,Count\n2016-08-01,1,1\n2016-08-02,1.5,1\n"
+	if err != nil || output.String() != expected {
+		t.Fail()
+		t.Logf("Report should have printed\n%s\nbut printed\n%s (%v)", expected, output.String(), err)
+	}
+}
+
+func Test_parseReportGroups_CommaSeparatedAndRepeatedValues_GroupsAreReturned(t *testing.T) {
+	// act
+	groups, err := parseReportGroups([]string{"project, Week", "tag"})
+
+	// assert
+	if err != nil || strings.Join(groups, ",") != "project,week,tag" {
+		t.Fail()
+		t.Logf("parseReportGroups should have returned project, week and tag but returned %v (%v)", groups, err)
+	}
+}
+
+func Test_parseReportGroups_InvalidValues_ErrorIsReturned(t *testing.T) {
+	inputs := [][]string{
+		{"year"},
+		{"project,project"},
+		{","},
+	}
+
+	for _, input := range inputs {
+		// act
+		_, err := parseReportGroups(input)
+
+		// assert
+		if err == nil {
+			t.Fail()
+			t.Logf("parseReportGroups(%q) should return an error", input)
+		}
+	}
+}
+
+func Test_Report_GroupByWeek_TimeRecordCrossesMidnight_TimeRecordIsCountedOnce(t *testing.T) {
+	// arrange
+	reporter := &TogglReporter{
+		timeRecordRepository: &mockTimeRecordRepository{
+			getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+				return []toggl.TimeRecord{
+					{
+						ProjectName: "Website",
+						Tags:        []string{"design", "meeting"},
+						Start:       time.Date(2016, 8, 1, 23, 0, 0, 0, time.UTC),
+						Stop:        time.Date(2016, 8, 2, 1, 30, 0, 0, time.UTC),
+					},
+				}, nil
+			},
+		},
+		groupBy: []string{reportGroupWeek, reportGroupTag},
+		tagMode: tagModeSplit,
+		format:  reportFormatTable,
+	}
+
+	var output bytes.Buffer
+
+	// act
+	err := reporter.Report(time.Date(2016, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2016, 8, 31, 0, 0, 0, 0, time.UTC), &output)
+
+	// assert
+	expected := `Week      Tag      Hours  Count
+2016-W31  design   1.25   1
+2016-W31  meeting  1.25   1
+Total              2.5    1
+`
+
+	if err != nil || output.String() != expected {
+		t.Fail()
+		t.Logf("Report should have printed\n%s\nbut printed\n%s (%v)", expected, output.String(), err)
+	}
+}
+
+func Test_getTagShares_DurationIsNotDivisible_SharesAddUpToTheDuration(t *testing.T) {
+	// arrange
+	reporter := &TogglReporter{
+		groupBy: []string{reportGroupTag},
+		tagMode: tagModeSplit,
+	}
+
+	timeRecord := toggl.TimeRecord{
+		Tags:  []string{"design", "meeting", "review"},
+		Start: time.Date(2016, 8, 1, 9, 0, 0, 0, time.UTC),
+		Stop:  time.Date(2016, 8, 1, 9, 0, 10, 0, time.UTC),
+	}
+
+	// act
+	shares := reporter.getTagShares(timeRecord)
+
+	// assert
+	var total time.Duration
+	for _, share := range shares {
+		total += share.duration
+	}
+
+	if len(shares) != 3 || total != 10*time.Second {
+		t.Fail()
+		t.Logf("getTagShares should have returned 3 shares adding up to 10s but returned %v", shares)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+	"github.com/pkg/errors"
+)
+
+// roundingColumnNames contains the column names of a rounding file.
+var roundingColumnNames = []string{"Client Name", "Project Name", "Increment", "Direction", "Apply To"}
+
+// The rounding directions.
+const (
+	roundingDirectionUp      = "up"
+	roundingDirectionDown    = "down"
+	roundingDirectionNearest = "nearest"
+)
+
+// The parts of a time record that are rounded.
+const (
+	// roundingTargetTimes rounds the start and the stop date
+	roundingTargetTimes = "times"
+
+	// roundingTargetDuration keeps the start date and rounds the duration
+	roundingTargetDuration = "duration"
+)
+
+// The TransformationReporter interface prints a summary of the changes made by a transformer.
+type TransformationReporter interface {
+	// Report prints a summary of all changes since the last report to the given writer.
+	Report(output io.Writer)
+}
+
+// roundingRule rounds the time records of a client or project.
+type roundingRule struct {
+	// clientName and projectName restrict the rule to time records
+	// of the given client or project (empty for all)
+	clientName  string
+	projectName string
+
+	increment time.Duration
+	direction string
+	target    string
+}
+
+// matches returns true if the rule applies to the given time record.
+func (rule roundingRule) matches(timeRecord toggl.TimeRecord) bool {
+	if rule.clientName != "" && rule.clientName != timeRecord.ClientName {
+		return false
+	}
+
+	if rule.projectName != "" && rule.projectName != timeRecord.ProjectName {
+		return false
+	}
+
+	return true
+}
+
+// loadTimeRecordRounder reads the rounding rules from the CSV rounding file with the given path.
+func loadTimeRecordRounder(path string) (*TimeRecordRounder, error) {
+	file, openError := os.Open(path)
+	if openError != nil {
+		return nil, errors.Wrap(openError, fmt.Sprintf("Failed to open the rounding file %q", path))
+	}
+
+	defer file.Close()
+
+	rounder, readError := readTimeRecordRounder(file)
+	if readError != nil {
+		return nil, errors.Wrap(readError, fmt.Sprintf("Failed to read the rounding file %q", path))
+	}
+
+	return rounder, nil
+}
+
+// readTimeRecordRounder reads the rounding rules from the given CSV input.
+// Every row contains the client and project name the rule is restricted to (empty for all),
+// the increment (e.g. 6m or 15m), the direction (up, down or nearest)
+// and optionally what is rounded (times or duration; default: times).
+func readTimeRecordRounder(input io.Reader) (*TimeRecordRounder, error) {
+	rows, lines, csvError := readCSVRows(input)
+	if csvError != nil {
+		return nil, csvError
+	}
+
+	rounder := &TimeRecordRounder{}
+	for index, row := range rows {
+
+		// skip the headline
+		if index == 0 && isHeadline(row, roundingColumnNames) {
+			continue
+		}
+
+		if len(row) < 4 || len(row) > len(roundingColumnNames) {
+			return nil, fmt.Errorf("Line %d: Wrong number of values. Expected 4 to %d but got %d", lines[index], len(roundingColumnNames), len(row))
+		}
+
+		for len(row) < len(roundingColumnNames) {
+			row = append(row, "")
+		}
+
+		increment, incrementError := parseDuration(strings.TrimSpace(row[2]))
+		if incrementError != nil || increment <= 0 {
+			return nil, fmt.Errorf("Line %d: Invalid increment %q. Use a positive duration such as 6m or 15m", lines[index], row[2])
+		}
+
+		rule := roundingRule{
+			clientName:  strings.TrimSpace(row[0]),
+			projectName: strings.TrimSpace(row[1]),
+			increment:   increment,
+			direction:   strings.ToLower(strings.TrimSpace(row[3])),
+			target:      strings.ToLower(strings.TrimSpace(row[4])),
+		}
+
+		switch rule.direction {
+		case roundingDirectionUp, roundingDirectionDown, roundingDirectionNearest:
+		default:
+			return nil, fmt.Errorf("Line %d: Unknown direction %q. Use up, down or nearest", lines[index], row[3])
+		}
+
+		switch rule.target {
+		case "":
+			rule.target = roundingTargetTimes
+
+		case roundingTargetTimes, roundingTargetDuration:
+		default:
+			return nil, fmt.Errorf("Line %d: Unknown value %q. Use times or duration", lines[index], row[4])
+		}
+
+		rounder.rules = append(rounder.rules, rule)
+	}
+
+	return rounder, nil
+}
+
+// TimeRecordRounder rounds the start and stop dates or the durations of time records.
+// The first rule that matches the client and project of a time record is used.
+type TimeRecordRounder struct {
+	rules []roundingRule
+
+	// mutex protects the totals
+	mutex    sync.Mutex
+	added    time.Duration
+	removed  time.Duration
+	rounded  int
+	extended int
+}
+
+// Transform returns the given time record with rounded dates.
+// The start date of a running time record is only rounded if the rule rounds the times.
+// Time records that would be rounded to zero length are kept at one increment.
+func (rounder *TimeRecordRounder) Transform(timeRecord toggl.TimeRecord) toggl.TimeRecord {
+	for _, rule := range rounder.rules {
+		if !rule.matches(timeRecord) {
+			continue
+		}
+
+		original := timeRecord
+		switch rule.target {
+		case roundingTargetDuration:
+			if !timeRecord.IsRunning() {
+				duration := roundDuration(timeRecord.Stop.Sub(timeRecord.Start), rule.increment, rule.direction)
+				timeRecord.Stop = timeRecord.Start.Add(duration)
+			}
+
+		default:
+			timeRecord.Start = roundTime(timeRecord.Start, rule.increment, rule.direction)
+			if !timeRecord.IsRunning() {
+				timeRecord.Stop = roundTime(timeRecord.Stop, rule.increment, rule.direction)
+			}
+		}
+
+		if !original.IsRunning() {
+
+			// rounding must not produce empty or negative time records; keep at least one increment
+			extended := false
+			if !timeRecord.Stop.After(timeRecord.Start) {
+				timeRecord.Stop = timeRecord.Start.Add(rule.increment)
+				extended = true
+			}
+
+			rounder.count(original.Stop.Sub(original.Start), timeRecord.Stop.Sub(timeRecord.Start), extended)
+		}
+
+		return timeRecord
+	}
+
+	return timeRecord
+}
+
+// count adds the difference between the given durations to the totals.
+// extended marks time records that were kept at one increment instead of being rounded to zero.
+func (rounder *TimeRecordRounder) count(before, after time.Duration, extended bool) {
+	rounder.mutex.Lock()
+	defer rounder.mutex.Unlock()
+
+	rounder.rounded++
+	if extended {
+		rounder.extended++
+	}
+
+	if after > before {
+		rounder.added += after - before
+	} else {
+		rounder.removed += before - after
+	}
+}
+
+// Report prints the total time added and removed by rounding since the last report.
+func (rounder *TimeRecordRounder) Report(output io.Writer) {
+	rounder.mutex.Lock()
+	defer rounder.mutex.Unlock()
+
+	if rounder.rounded > 0 {
+		fmt.Fprintf(output, "Rounding added %s and removed %s (net %s) in %d time records.\n", rounder.added, rounder.removed, rounder.added-rounder.removed, rounder.rounded)
+	}
+
+	if rounder.extended > 0 {
+		fmt.Fprintf(output, "Warning: %d time records would have been rounded to zero length and were kept at one increment.\n", rounder.extended)
+	}
+
+	rounder.added = 0
+	rounder.removed = 0
+	rounder.rounded = 0
+	rounder.extended = 0
+}
+
+// roundTime rounds the given date to the given increment in the given direction.
+// The increments are counted from midnight of the date's own time zone.
+func roundTime(date time.Time, increment time.Duration, direction string) time.Time {
+	_, offset := date.Zone()
+	shift := time.Duration(offset) * time.Second
+
+	local := time.Duration(date.Add(shift).UnixNano())
+	return time.Unix(0, int64(roundDuration(local, increment, direction)-shift)).In(date.Location())
+}
+
+// roundDuration rounds the given duration to the given increment in the given direction.
+func roundDuration(duration, increment time.Duration, direction string) time.Duration {
+	remainder := duration % increment
+	if remainder < 0 {
+		remainder += increment
+	}
+
+	if remainder == 0 {
+		return duration
+	}
+
+	rounded := duration - remainder
+	switch direction {
+	case roundingDirectionUp:
+		return rounded + increment
+
+	case roundingDirectionNearest:
+		if remainder*2 >= increment {
+			return rounded + increment
+		}
+	}
+
+	return rounded
+}
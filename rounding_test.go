@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func Test_readTimeRecordRounder_ValidRules_RulesAreReturned(t *testing.T) {
+	// arrange
+	input := `Client Name,Project Name,Increment,Direction,Apply To
+ACME,Support,15m,up,duration
+ACME,,6m,nearest
+,,0:06,Down,times`
+
+	// act
+	rounder, err := readTimeRecordRounder(strings.NewReader(input))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("readTimeRecordRounder should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	if len(rounder.rules) != 3 || rounder.rules[1].target != roundingTargetTimes || rounder.rules[2].direction != roundingDirectionDown || rounder.rules[2].increment != 6*time.Minute {
+		t.Fail()
+		t.Logf("readTimeRecordRounder should have returned three rules but returned: %#v", rounder.rules)
+	}
+}
+
+func Test_readTimeRecordRounder_InvalidRules_ErrorIsReturned(t *testing.T) {
+	// arrange
+	inputs := []string{
+		`ACME,,15m`,
+		`ACME,,0m,up`,
+		`ACME,,15 minutes,up`,
+		`ACME,,15m,sideways`,
+		`ACME,,15m,up,everything`,
+	}
+
+	for _, input := range inputs {
+		// act
+		_, err := readTimeRecordRounder(strings.NewReader(input))
+
+		// assert
+		if err == nil {
+			t.Fail()
+			t.Logf("readTimeRecordRounder(%q) should have returned an error", input)
+		}
+	}
+}
+
+func Test_roundDuration_AllDirections_DurationIsRounded(t *testing.T) {
+	// arrange
+	inputs := []struct {
+		Duration  time.Duration
+		Direction string
+		Expected  time.Duration
+	}{
+		{62 * time.Minute, roundingDirectionUp, 75 * time.Minute},
+		{62 * time.Minute, roundingDirectionDown, 60 * time.Minute},
+		{62 * time.Minute, roundingDirectionNearest, 60 * time.Minute},
+		{68 * time.Minute, roundingDirectionNearest, 75 * time.Minute},
+		{60 * time.Minute, roundingDirectionUp, 60 * time.Minute},
+	}
+
+	for _, input := range inputs {
+		// act
+		result := roundDuration(input.Duration, 15*time.Minute, input.Direction)
+
+		// assert
+		if result != input.Expected {
+			t.Fail()
+			t.Logf("roundDuration(%s, 15m, %q) should have returned %s but returned %s", input.Duration, input.Direction, input.Expected, result)
+		}
+	}
+}
+
+func Test_roundTime_DateWithOffset_IncrementsStartAtLocalMidnight(t *testing.T) {
+	// arrange
+	location := time.FixedZone("+05:45", 5*3600+45*60)
+	date := time.Date(2016, 8, 12, 9, 10, 0, 0, location)
+
+	// act
+	result := roundTime(date, 15*time.Minute, roundingDirectionUp)
+
+	// assert
+	expected := time.Date(2016, 8, 12, 9, 15, 0, 0, location)
+	if !result.Equal(expected) {
+		t.Fail()
+		t.Logf("roundTime(%s, 15m, up) should have returned %s but returned %s", date, expected, result)
+	}
+}
+
+func Test_TimeRecordRounder_Transform_FirstMatchingRuleIsApplied_ChangesAreReported(t *testing.T) {
+	// arrange
+	rounder, _ := readTimeRecordRounder(strings.NewReader(`ACME,Support,15m,up,duration
+,,6m,down,times`))
+
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{
+			ClientName:  "ACME",
+			ProjectName: "Support",
+			Start:       time.Date(2016, 8, 12, 9, 7, 0, 0, time.UTC),
+			Stop:        time.Date(2016, 8, 12, 9, 17, 0, 0, time.UTC),
+		},
+		toggl.TimeRecord{
+			ClientName:  "ACME",
+			ProjectName: "Website",
+			Start:       time.Date(2016, 8, 12, 10, 7, 0, 0, time.UTC),
+			Stop:        time.Date(2016, 8, 12, 10, 17, 0, 0, time.UTC),
+		},
+	}
+
+	// act
+	support := rounder.Transform(timeRecords[0])
+	website := rounder.Transform(timeRecords[1])
+
+	var outputBuffer bytes.Buffer
+	rounder.Report(&outputBuffer)
+
+	// assert
+	if !support.Start.Equal(timeRecords[0].Start) || !support.Stop.Equal(time.Date(2016, 8, 12, 9, 22, 0, 0, time.UTC)) {
+		t.Fail()
+		t.Logf("Transform should have rounded the duration of the support time record up to 15m but returned %s - %s", support.Start, support.Stop)
+	}
+
+	if !website.Start.Equal(time.Date(2016, 8, 12, 10, 6, 0, 0, time.UTC)) || !website.Stop.Equal(time.Date(2016, 8, 12, 10, 12, 0, 0, time.UTC)) {
+		t.Fail()
+		t.Logf("Transform should have rounded the times of the website time record down to 6m but returned %s - %s", website.Start, website.Stop)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "Rounding added 5m0s and removed 4m0s (net 1m0s) in 2 time records") {
+		t.Fail()
+		t.Logf("Report should have printed the added and removed time but printed: %s", outputBuffer.String())
+	}
+}
+
+func Test_TimeRecordRounder_Report_TotalsAreReset(t *testing.T) {
+	// arrange
+	rounder, _ := readTimeRecordRounder(strings.NewReader(`,,15m,up`))
+	rounder.Transform(toggl.TimeRecord{
+		Start: time.Date(2016, 8, 12, 9, 7, 0, 0, time.UTC),
+		Stop:  time.Date(2016, 8, 12, 9, 17, 0, 0, time.UTC),
+	})
+
+	rounder.Report(&bytes.Buffer{})
+
+	// act
+	var outputBuffer bytes.Buffer
+	rounder.Report(&outputBuffer)
+
+	// assert
+	if outputBuffer.Len() > 0 {
+		t.Fail()
+		t.Logf("Report should not have printed anything after the totals were reported but printed: %s", outputBuffer.String())
+	}
+}
+
+func Test_TimeRecordRounder_Transform_RoundedToZeroLength_OneIncrementIsKept(t *testing.T) {
+	// arrange
+	rounder, _ := readTimeRecordRounder(strings.NewReader(`ACME,,15m,down,times
+,,15m,down,duration`))
+
+	timeRecords := []toggl.TimeRecord{
+		toggl.TimeRecord{
+			ClientName: "ACME",
+			Start:      time.Date(2016, 8, 12, 10, 1, 0, 0, time.UTC),
+			Stop:       time.Date(2016, 8, 12, 10, 4, 0, 0, time.UTC),
+		},
+		toggl.TimeRecord{
+			Start: time.Date(2016, 8, 12, 11, 1, 0, 0, time.UTC),
+			Stop:  time.Date(2016, 8, 12, 11, 4, 0, 0, time.UTC),
+		},
+	}
+
+	// act
+	times := rounder.Transform(timeRecords[0])
+	duration := rounder.Transform(timeRecords[1])
+
+	var outputBuffer bytes.Buffer
+	rounder.Report(&outputBuffer)
+
+	// assert
+	if !times.Start.Equal(time.Date(2016, 8, 12, 10, 0, 0, 0, time.UTC)) || !times.Stop.Equal(time.Date(2016, 8, 12, 10, 15, 0, 0, time.UTC)) {
+		t.Fail()
+		t.Logf("Transform should have kept one increment of the rounded times but returned %s - %s", times.Start, times.Stop)
+	}
+
+	if !duration.Start.Equal(timeRecords[1].Start) || !duration.Stop.Equal(time.Date(2016, 8, 12, 11, 16, 0, 0, time.UTC)) {
+		t.Fail()
+		t.Logf("Transform should have kept one increment of the rounded duration but returned %s - %s", duration.Start, duration.Stop)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "2 time records would have been rounded to zero length") {
+		t.Fail()
+		t.Logf("Report should have warned about the extended time records but printed: %s", outputBuffer.String())
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+	"github.com/jinzhu/now"
+)
+
+// The periods at whose boundaries time records are split.
+const (
+	splitPeriodDay   = "day"
+	splitPeriodWeek  = "week"
+	splitPeriodMonth = "month"
+)
+
+// splitPeriods contains all available split periods.
+var splitPeriods = []string{splitPeriodDay, splitPeriodWeek, splitPeriodMonth}
+
+// splitTimeRecords cuts all given time records that cross a boundary of the given period
+// (day, week or month in the given time zone) into one time record per period.
+// Returns the time records unchanged if no period is given.
+func splitTimeRecords(timeRecords []toggl.TimeRecord, period string, location *time.Location) []toggl.TimeRecord {
+	if period == "" {
+		return timeRecords
+	}
+
+	var result []toggl.TimeRecord
+	for _, timeRecord := range timeRecords {
+		result = append(result, splitTimeRecord(timeRecord, period, location)...)
+	}
+
+	return result
+}
+
+// splitTimeRecord cuts the given time record at every boundary of the given period in the given time zone.
+// Running time records are not split.
+func splitTimeRecord(timeRecord toggl.TimeRecord, period string, location *time.Location) []toggl.TimeRecord {
+	if period == "" || timeRecord.IsRunning() {
+		return []toggl.TimeRecord{timeRecord}
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	var segments []toggl.TimeRecord
+	segment := timeRecord
+	for {
+		boundary := getNextPeriodStart(segment.Start.In(location), period).In(segment.Start.Location())
+		if !boundary.Before(timeRecord.Stop) {
+			break
+		}
+
+		segment.Stop = boundary
+		segments = append(segments, segment)
+
+		segment.Start = boundary
+	}
+
+	segment.Stop = timeRecord.Stop
+	return append(segments, segment)
+}
+
+// getNextPeriodStart returns the beginning of the day, week or month after the given date
+// in the time zone of the given date. Weeks start on Monday unless now.FirstDayMonday is disabled.
+func getNextPeriodStart(date time.Time, period string) time.Time {
+	year, month, day := date.Date()
+
+	switch period {
+	case splitPeriodWeek:
+		// now.BeginningOfWeek subtracts whole 24 hour days, which misses local midnight
+		// in weeks with a daylight saving change, so only its first weekday is used here
+		firstWeekday := time.Sunday
+		if now.FirstDayMonday {
+			firstWeekday = time.Monday
+		}
+
+		daysSinceWeekStart := (int(date.Weekday()) - int(firstWeekday) + 7) % 7
+		return time.Date(year, month, day-daysSinceWeekStart+7, 0, 0, 0, 0, date.Location())
+
+	case splitPeriodMonth:
+		return time.Date(year, month+1, 1, 0, 0, 0, 0, date.Location())
+	}
+
+	return time.Date(year, month, day+1, 0, 0, 0, 0, date.Location())
+}
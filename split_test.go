@@ -0,0 +1,129 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+	"github.com/jinzhu/now"
+)
+
+func Test_splitTimeRecord_CrossesMidnight_TwoSegmentsAreReturned(t *testing.T) {
+	// arrange
+	timeRecord := toggl.TimeRecord{
+		Description: "Night shift",
+		Start:       time.Date(2016, 8, 12, 22, 0, 0, 0, time.UTC),
+		Stop:        time.Date(2016, 8, 13, 2, 0, 0, 0, time.UTC),
+	}
+
+	// act
+	segments := splitTimeRecord(timeRecord, splitPeriodDay, nil)
+
+	// assert
+	midnight := time.Date(2016, 8, 13, 0, 0, 0, 0, time.UTC)
+	if len(segments) != 2 || !segments[0].Stop.Equal(midnight) || !segments[1].Start.Equal(midnight) || !segments[1].Stop.Equal(timeRecord.Stop) || segments[1].Description != "Night shift" {
+		t.Fail()
+		t.Logf("splitTimeRecord should have split the time record at midnight but returned %#v", segments)
+	}
+}
+
+func Test_splitTimeRecord_MidnightInTimezone_RecordIsSplitAtLocalMidnight(t *testing.T) {
+	// arrange
+	location, _ := time.LoadLocation("Europe/Berlin")
+	timeRecord := toggl.TimeRecord{
+		Start: time.Date(2016, 8, 12, 21, 0, 0, 0, time.UTC),
+		Stop:  time.Date(2016, 8, 12, 23, 0, 0, 0, time.UTC),
+	}
+
+	// act
+	segments := splitTimeRecord(timeRecord, splitPeriodDay, location)
+
+	// assert
+	localMidnight := time.Date(2016, 8, 12, 22, 0, 0, 0, time.UTC)
+	if len(segments) != 2 || !segments[0].Stop.Equal(localMidnight) {
+		t.Fail()
+		t.Logf("splitTimeRecord should have split the time record at midnight in Berlin but returned %#v", segments)
+	}
+}
+
+func Test_splitTimeRecord_Periods_RecordIsSplitAtPeriodBoundaries(t *testing.T) {
+	// arrange
+	timeRecord := toggl.TimeRecord{
+		Start: time.Date(2016, 8, 30, 12, 0, 0, 0, time.UTC), // Tuesday
+		Stop:  time.Date(2016, 9, 6, 12, 0, 0, 0, time.UTC),  // Tuesday of the next week
+	}
+
+	inputs := map[string]int{
+		splitPeriodDay:   8,
+		splitPeriodWeek:  2,
+		splitPeriodMonth: 2,
+		"":               1,
+	}
+
+	for period, expectedSegments := range inputs {
+		// act
+		segments := splitTimeRecord(timeRecord, period, nil)
+
+		// assert
+		if len(segments) != expectedSegments {
+			t.Fail()
+			t.Logf("splitTimeRecord(%q) should have returned %d segments but returned %d", period, expectedSegments, len(segments))
+		}
+	}
+}
+
+func Test_splitTimeRecord_WeeksStartOnSunday_RecordIsSplitAtSunday(t *testing.T) {
+	// arrange
+	now.FirstDayMonday = false
+	defer func() { now.FirstDayMonday = true }()
+
+	timeRecord := toggl.TimeRecord{
+		Start: time.Date(2016, 9, 3, 22, 0, 0, 0, time.UTC), // Saturday
+		Stop:  time.Date(2016, 9, 5, 2, 0, 0, 0, time.UTC),  // Monday
+	}
+
+	// act
+	segments := splitTimeRecord(timeRecord, splitPeriodWeek, nil)
+
+	// assert
+	sunday := time.Date(2016, 9, 4, 0, 0, 0, 0, time.UTC)
+	if len(segments) != 2 || !segments[0].Stop.Equal(sunday) {
+		t.Fail()
+		t.Logf("splitTimeRecord should have split the time record at the beginning of Sunday but returned %#v", segments)
+	}
+}
+
+func Test_splitTimeRecord_WeekWithDaylightSavingChange_RecordIsSplitAtLocalMidnight(t *testing.T) {
+	// arrange
+	location, _ := time.LoadLocation("Europe/Berlin")
+	timeRecord := toggl.TimeRecord{
+		Start: time.Date(2016, 3, 27, 20, 0, 0, 0, location), // Sunday after the change to summer time
+		Stop:  time.Date(2016, 3, 28, 2, 0, 0, 0, location),
+	}
+
+	// act
+	segments := splitTimeRecord(timeRecord, splitPeriodWeek, location)
+
+	// assert
+	monday := time.Date(2016, 3, 28, 0, 0, 0, 0, location)
+	if len(segments) != 2 || !segments[0].Stop.Equal(monday) {
+		t.Fail()
+		t.Logf("splitTimeRecord should have split the time record at local midnight on Monday but returned %#v", segments)
+	}
+}
+
+func Test_splitTimeRecord_RunningTimeRecord_RecordIsNotSplit(t *testing.T) {
+	// arrange
+	timeRecord := toggl.TimeRecord{
+		Start: time.Date(2016, 8, 12, 22, 0, 0, 0, time.UTC),
+	}
+
+	// act
+	segments := splitTimeRecord(timeRecord, splitPeriodDay, nil)
+
+	// assert
+	if len(segments) != 1 || !segments[0].IsRunning() {
+		t.Fail()
+		t.Logf("splitTimeRecord should not have split the running time record but returned %#v", segments)
+	}
+}
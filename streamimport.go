@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+	"github.com/pkg/errors"
+	"gopkg.in/cheggaaa/pb.v1"
+)
+
+// importStream reads, validates and creates the time records row by row
+// so that the memory usage does not grow with the size of the input.
+// The first invalid row stops the import; all rows before it have been created already.
+// The input fingerprint identifies the input in the journal (optional).
+func (togglCSVImporter *TogglCSVImporter) importStream(input io.Reader, inputFingerprint string) error {
+
+	countingInput := &countingReader{reader: input}
+	readTimeRecord := togglCSVImporter.newTimeRecordStream(countingInput)
+
+	existingTimeRecords := newExistingTimeRecordIndex(togglCSVImporter.timeRecordRepository)
+
+	journal, journalError := togglCSVImporter.openJournal(inputFingerprint)
+	if journalError != nil {
+		return journalError
+	}
+
+	if journal != nil {
+		defer journal.Close()
+	}
+
+	// the number of rows is unknown so the progress is measured in bytes
+	progressbar := togglCSVImporter.startProgressBar(getInputSize(input))
+	progressbar.SetUnits(pb.U_BYTES)
+
+	skipped := 0
+	var pendingJobs []timeRecordJob
+	nextJob := func() (timeRecordJob, bool, error) {
+		for {
+			// return the remaining parts of a split time record first
+			if len(pendingJobs) > 0 {
+				job := pendingJobs[0]
+				pendingJobs = pendingJobs[1:]
+				return job, true, nil
+			}
+
+			timeRecord, line, readError := readTimeRecord()
+			if readError == io.EOF {
+				return timeRecordJob{}, false, nil
+			}
+
+			if readError != nil {
+				return timeRecordJob{}, false, readError
+			}
+
+			timeRecord = togglCSVImporter.transform(timeRecord)
+
+			segments := splitTimeRecord(timeRecord, togglCSVImporter.splitPeriod, togglCSVImporter.location)
+			for index, segment := range segments {
+
+				// skip all time records that already exist in Toggl
+				exists, existsError := existingTimeRecords.Contains(segment)
+				if existsError != nil {
+					return timeRecordJob{}, false, errors.Wrap(existsError, "Failed to retrieve the existing time records")
+				}
+
+				if exists {
+					skipped++
+					atomic.AddInt64(&togglCSVImporter.skipped, 1)
+					continue
+				}
+
+				name := fmt.Sprintf("time record in line %d", line)
+				if len(segments) > 1 {
+					name = fmt.Sprintf("part %d of %d of the time record in line %d", index+1, len(segments), line)
+				}
+
+				pendingJobs = append(pendingJobs, timeRecordJob{
+					name:       name,
+					timeRecord: segment,
+				})
+			}
+		}
+	}
+
+	progress := func() {
+		progressbar.Set64(countingInput.Count())
+	}
+
+	if createError := togglCSVImporter.createTimeRecords(nextJob, journal, progress); createError != nil {
+		return togglCSVImporter.rollback(createError)
+	}
+
+	togglCSVImporter.finishProgressBar(progressbar)
+
+	if skipped > 0 && togglCSVImporter.output != nil {
+		fmt.Fprintf(togglCSVImporter.output, "Skipped %d time records because they already exist.\n", skipped)
+	}
+
+	return nil
+}
+
+// newTimeRecordStream returns a function that reads and validates the next time record
+// of the given input in the configured format and returns it with its line number.
+// Like the batch import it rejects a second running time record, because starting
+// another timer would silently stop the one that was started before.
+// The function returns io.EOF after the last time record.
+func (togglCSVImporter *TogglCSVImporter) newTimeRecordStream(input io.Reader) func() (toggl.TimeRecord, int, error) {
+	next := togglCSVImporter.newRecordReader(input)
+	runningLine := 0
+
+	return func() (toggl.TimeRecord, int, error) {
+		timeRecord, line, err := next()
+		if err != nil || !timeRecord.IsRunning() {
+			return timeRecord, line, err
+		}
+
+		if runningLine > 0 {
+			return toggl.TimeRecord{}, line, fmt.Errorf("Invalid time record in line %d: Only one time record can be running but line %d is running already", line, runningLine)
+		}
+
+		runningLine = line
+		return timeRecord, line, nil
+	}
+}
+
+// newRecordReader returns a function that reads and validates the next time record
+// of the given input in the configured format and returns it with its line number.
+func (togglCSVImporter *TogglCSVImporter) newRecordReader(input io.Reader) func() (toggl.TimeRecord, int, error) {
+	if togglCSVImporter.format == formatJSON || togglCSVImporter.format == formatNDJSON {
+		reader := newJSONTimeRecordReader(input, togglCSVImporter.format)
+
+		return func() (toggl.TimeRecord, int, error) {
+			record, line, readError := reader.Read()
+			if readError != nil {
+				return toggl.TimeRecord{}, line, readError
+			}
+
+			timeRecord, problems := togglCSVImporter.jsonMapper.validateRecord(record)
+			if len(problems) > 0 {
+				return toggl.TimeRecord{}, line, fmt.Errorf("Invalid time record in line %d: %s", line, problems[0].Reason)
+			}
+
+			return timeRecord, line, nil
+		}
+	}
+
+	csvReader := csv.NewReader(input)
+	csvReader.FieldsPerRecord = -1
+
+	csvMapper := togglCSVImporter.csvMapper
+	line := 0
+	isFirstRow := true
+
+	return func() (toggl.TimeRecord, int, error) {
+		for {
+			row, readError := csvReader.Read()
+			if readError == io.EOF {
+				return toggl.TimeRecord{}, line, io.EOF
+			}
+
+			if readError != nil {
+				return toggl.TimeRecord{}, line, fmt.Errorf("Failed to read time records from CSV: %s", readError.Error())
+			}
+
+			// the reader skips blank lines and reads values that span several lines as one row
+			line, _ = csvReader.FieldPos(0)
+
+			// read the columns in the order of the headline
+			firstRow := isFirstRow
+			isFirstRow = false
+			if firstRow && isTimeRecordHeadline(row) {
+				headlineMapper, headlineError := csvMapper.WithHeadline(row)
+				if headlineError != nil {
+					return toggl.TimeRecord{}, line, headlineError
+				}
+
+				csvMapper = headlineMapper
+				continue
+			}
+
+			timeRecord, timeRecordError := csvMapper.GetTimeRecord(row)
+			if timeRecordError != nil {
+				return toggl.TimeRecord{}, line, fmt.Errorf("Invalid time record in line %d: %s", line, timeRecordError.Error())
+			}
+
+			return timeRecord, line, nil
+		}
+	}
+}
+
+// getInputSize returns the size of the given input in bytes
+// or 0 if the input is not a regular file (e.g. if it is piped from another process).
+func getInputSize(input io.Reader) int64 {
+	file, isFile := input.(*os.File)
+	if !isFile {
+		return 0
+	}
+
+	fileInfo, statError := file.Stat()
+	if statError != nil || !fileInfo.Mode().IsRegular() {
+		return 0
+	}
+
+	return fileInfo.Size()
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+// Read reads from the underlying reader and counts the bytes.
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.reader.Read(p)
+	atomic.AddInt64(&reader.count, int64(n))
+	return n, err
+}
+
+// Count returns the number of bytes read so far.
+func (reader *countingReader) Count() int64 {
+	return atomic.LoadInt64(&reader.count)
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglapi/date"
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func Test_Import_Stream_AllRowsAreCreatedInOrder(t *testing.T) {
+	// arrange
+	var createdDescriptions []string
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdDescriptions = append(createdDescriptions, timeRecord.Description)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		stream:               true,
+	}
+
+	input := `Start,Stop,Workspace Name,Project Name,Client Name,Tag(s),Description
+2015-03-26T08:00:00+01:00,2015-03-26T11:30:00+01:00,Workspace,Project XY,Client X,,Record 1
+2015-03-27T08:00:00+01:00,2015-03-27T11:30:00+01:00,Workspace,Project XY,Client X,,Record 2`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Import should not return an error but returned: %s", err.Error())
+	}
+
+	if fmt.Sprintf("%v", createdDescriptions) != "[Record 1 Record 2]" {
+		t.Fail()
+		t.Logf("Import should have created both records in order but created: %v", createdDescriptions)
+	}
+}
+
+func Test_Import_Stream_InvalidRow_PreviousRowsAreCreated_ErrorIsReturned(t *testing.T) {
+	// arrange
+	var createdDescriptions []string
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdDescriptions = append(createdDescriptions, timeRecord.Description)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		stream:               true,
+	}
+
+	input := `2015-03-26T08:00:00+01:00,2015-03-26T11:30:00+01:00,Workspace,Project XY,Client X,,Record 1
+Invalid Date,2015-03-27T11:30:00+01:00,Workspace,Project XY,Client X,,Record 2
+2015-03-28T08:00:00+01:00,2015-03-28T11:30:00+01:00,Workspace,Project XY,Client X,,Record 3`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fail()
+		t.Logf("Import should return an error for line 2 but returned: %v", err)
+	}
+
+	if len(createdDescriptions) != 1 || createdDescriptions[0] != "Record 1" {
+		t.Fail()
+		t.Logf("Import should only have created the first record but created: %v", createdDescriptions)
+	}
+}
+
+func Test_Import_Stream_BlankLinesAndMultiLineValues_LineOfTheInputIsReported(t *testing.T) {
+	// arrange
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		stream:               true,
+	}
+
+	input := `2015-03-26T08:00:00+01:00,2015-03-26T11:30:00+01:00,Workspace,Project XY,Client X,,"Record
+1"
+
+Invalid Date,2015-03-27T11:30:00+01:00,Workspace,Project XY,Client X,,Record 2`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Fail()
+		t.Logf("Import should return an error for line 4 but returned: %v", err)
+	}
+}
+
+func Test_Import_Stream_SecondRunningRecord_FirstTimerIsNotStopped_ErrorIsReturned(t *testing.T) {
+	// arrange
+	var createdDescriptions []string
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdDescriptions = append(createdDescriptions, timeRecord.Description)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		stream:               true,
+	}
+
+	input := `2015-03-26T08:00:00+01:00,,Workspace,Project XY,Client X,,Record 1
+2015-03-27T08:00:00+01:00,2015-03-27T11:30:00+01:00,Workspace,Project XY,Client X,,Record 2
+2015-03-28T08:00:00+01:00,,Workspace,Project XY,Client X,,Record 3`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "line 3") || !strings.Contains(err.Error(), "line 1 is running already") {
+		t.Fail()
+		t.Logf("Import should return an error for the running record in line 3 but returned: %v", err)
+	}
+
+	for _, description := range createdDescriptions {
+		if description == "Record 3" {
+			t.Fail()
+			t.Logf("Import should not have started a second timer but created: %v", createdDescriptions)
+		}
+	}
+}
+
+func Test_Import_Stream_TimeRecordsExistAlready_DuplicatesAreSkipped(t *testing.T) {
+	// arrange
+	existingTimeRecord := toggl.TimeRecord{
+		Start:         time.Date(2015, 3, 26, 7, 0, 0, 0, time.UTC),
+		Stop:          time.Date(2015, 3, 26, 10, 30, 0, 0, time.UTC),
+		WorkspaceName: "Workspace",
+		ProjectName:   "Project XY",
+		ClientName:    "Client X",
+		Description:   "Record 1",
+	}
+
+	fetches := 0
+	var createdDescriptions []string
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			fetches++
+			return []toggl.TimeRecord{existingTimeRecord}, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdDescriptions = append(createdDescriptions, timeRecord.Description)
+			return timeRecord, nil
+		},
+	}
+
+	var outputBuffer bytes.Buffer
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		output:               &outputBuffer,
+		stream:               true,
+	}
+
+	input := `2015-03-26T08:00:00+01:00,2015-03-26T11:30:00+01:00,Workspace,Project XY,Client X,,Record 1
+2015-03-27T08:00:00+01:00,2015-03-27T11:30:00+01:00,Workspace,Project XY,Client X,,Record 2`
+
+	// act
+	importer.Import(strings.NewReader(input))
+
+	// assert
+	if len(createdDescriptions) != 1 || createdDescriptions[0] != "Record 2" {
+		t.Fail()
+		t.Logf("Import should only have created the new record but created: %v", createdDescriptions)
+	}
+
+	if fetches != 1 {
+		t.Fail()
+		t.Logf("Import should have loaded the existing records of the month once but loaded them %d times", fetches)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "Skipped 1 time records") {
+		t.Fail()
+		t.Logf("Import should report the skipped records but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_Import_Stream_DurationColumn_StopIsCalculatedFromHeadline(t *testing.T) {
+	// arrange
+	var createdTimeRecords []toggl.TimeRecord
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdTimeRecords = append(createdTimeRecords, timeRecord)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		stream:               true,
+	}
+
+	input := `Start,Duration,Workspace Name,Project Name,Client Name,Tag(s),Description
+2015-03-26T08:00:00+01:00,2h,Workspace,Project XY,Client X,,Record 1`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	expectedStop := time.Date(2015, 3, 26, 9, 0, 0, 0, time.UTC)
+	if err != nil || len(createdTimeRecords) != 1 || !createdTimeRecords[0].Stop.Equal(expectedStop) {
+		t.Fail()
+		t.Logf("Import should have created the record with the stop date %s but created %#v (%v)", expectedStop, createdTimeRecords, err)
+	}
+}
+
+func Test_Import_Stream_SplitAtDay_AllPartsAreCreated(t *testing.T) {
+	// arrange
+	var createdTimeRecords []toggl.TimeRecord
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdTimeRecords = append(createdTimeRecords, timeRecord)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		stream:               true,
+		splitPeriod:          splitPeriodDay,
+	}
+
+	input := `2015-03-26T22:00:00+00:00,2015-03-27T02:00:00+00:00,Workspace,Project XY,Client X,,Night shift
+2015-03-27T08:00:00+00:00,2015-03-27T09:00:00+00:00,Workspace,Project XY,Client X,,Morning`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err != nil || len(createdTimeRecords) != 3 || createdTimeRecords[2].Description != "Morning" {
+		t.Fail()
+		t.Logf("Import should have created both parts of the first record and the second record but created %#v (%v)", createdTimeRecords, err)
+	}
+}
+
+func Test_getInputSize_RegularFile_SizeIsReturned(t *testing.T) {
+	// arrange
+	directory, directoryError := ioutil.TempDir("", "togglcsv")
+	if directoryError != nil {
+		t.Fatal(directoryError)
+	}
+
+	defer os.RemoveAll(directory)
+
+	path := filepath.Join(directory, "report.csv")
+	if writeError := ioutil.WriteFile(path, []byte("0123456789"), 0644); writeError != nil {
+		t.Fatal(writeError)
+	}
+
+	file, openError := os.Open(path)
+	if openError != nil {
+		t.Fatal(openError)
+	}
+
+	defer file.Close()
+
+	// act
+	size := getInputSize(file)
+
+	// assert
+	if size != 10 {
+		t.Fail()
+		t.Logf("getInputSize should have returned 10 but returned %d", size)
+	}
+}
+
+func Test_getInputSize_NoFile_ZeroIsReturned(t *testing.T) {
+	// act
+	size := getInputSize(strings.NewReader("0123456789"))
+
+	// assert
+	if size != 0 {
+		t.Fail()
+		t.Logf("getInputSize should have returned 0 for a reader that is not a file but returned %d", size)
+	}
+}
+
+func Test_Import_Stream_FormatNDJSON_InvalidLine_PreviousRecordsAreCreated_ErrorIsReturned(t *testing.T) {
+	// arrange
+	var createdDescriptions []string
+	timeRecordRepository := &mockTimeRecordRepository{
+		getTimeRecords: func(start, stop time.Time) ([]toggl.TimeRecord, error) {
+			return nil, nil
+		},
+		createTimeRecord: func(timeRecord toggl.TimeRecord) (toggl.TimeRecord, error) {
+			createdDescriptions = append(createdDescriptions, timeRecord.Description)
+			return timeRecord, nil
+		},
+	}
+
+	importer := TogglCSVImporter{
+		csvMapper:            NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		timeRecordRepository: timeRecordRepository,
+		stream:               true,
+		format:               formatNDJSON,
+		jsonMapper:           NewJSONTimeRecordMapper(date.NewISO8601Formatter()),
+	}
+
+	input := `{"start": "2015-03-26T08:00:00+01:00", "stop": "2015-03-26T11:30:00+01:00", "workspace": "Workspace", "description": "Record 1"}
+
+{"start": "Invalid Date", "stop": "2015-03-27T11:30:00+01:00", "workspace": "Workspace", "description": "Record 2"}
+{"start": "2015-03-28T08:00:00+01:00", "stop": "2015-03-28T11:30:00+01:00", "workspace": "Workspace", "description": "Record 3"}`
+
+	// act
+	err := importer.Import(strings.NewReader(input))
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fail()
+		t.Logf("Import should have reported the invalid time record in line 3 but returned: %v", err)
+	}
+
+	if fmt.Sprintf("%v", createdDescriptions) != "[Record 1]" {
+		t.Fail()
+		t.Logf("Import should have created the time records before the invalid line but created: %v", createdDescriptions)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// The TimeRecordWriter interface writes time records in an output format.
+type TimeRecordWriter interface {
+	// Write writes the given time record.
+	Write(timeRecord toggl.TimeRecord) error
+
+	// Close completes the output (e.g. closes the JSON array). The underlying writer is not closed.
+	Close() error
+}
+
+// newCSVTimeRecordWriter creates a TimeRecordWriter that writes the CSV header
+// and every time record as a CSV row using the given mapper.
+func newCSVTimeRecordWriter(writer io.Writer, csvMapper TimeRecordMapper) TimeRecordWriter {
+	csvWriter := csv.NewWriter(writer)
+
+	// write the header
+	csvWriter.Write(csvMapper.GetColumnNames())
+	csvWriter.Flush()
+
+	return &csvTimeRecordWriter{
+		csvWriter: csvWriter,
+		csvMapper: csvMapper,
+	}
+}
+
+// csvTimeRecordWriter writes time records as CSV rows.
+type csvTimeRecordWriter struct {
+	csvWriter *csv.Writer
+	csvMapper TimeRecordMapper
+}
+
+// Write writes the given time record as a CSV row.
+func (writer *csvTimeRecordWriter) Write(timeRecord toggl.TimeRecord) error {
+	writer.csvWriter.Write(writer.csvMapper.GetRow(timeRecord))
+	writer.csvWriter.Flush()
+
+	return writer.csvWriter.Error()
+}
+
+// Close flushes the CSV output.
+func (writer *csvTimeRecordWriter) Close() error {
+	writer.csvWriter.Flush()
+	return writer.csvWriter.Error()
+}
+
+// newJSONTimeRecordWriter creates a TimeRecordWriter that writes all time records as one JSON array.
+// The array is written element by element so that the time records don't have to be kept in memory.
+func newJSONTimeRecordWriter(writer io.Writer, jsonMapper *JSONTimeRecordMapper) TimeRecordWriter {
+	return &jsonTimeRecordWriter{
+		writer:     writer,
+		jsonMapper: jsonMapper,
+	}
+}
+
+// jsonTimeRecordWriter writes time records as the elements of a JSON array.
+type jsonTimeRecordWriter struct {
+	writer     io.Writer
+	jsonMapper *JSONTimeRecordMapper
+
+	// written contains the number of written time records
+	written int
+}
+
+// Write writes the given time record as the next element of the JSON array.
+func (writer *jsonTimeRecordWriter) Write(timeRecord toggl.TimeRecord) error {
+	element, marshalError := json.Marshal(writer.jsonMapper.getJSONTimeRecord(timeRecord))
+	if marshalError != nil {
+		return marshalError
+	}
+
+	separator := ",\n  "
+	if writer.written == 0 {
+		separator = "[\n  "
+	}
+
+	if _, writeError := io.WriteString(writer.writer, separator); writeError != nil {
+		return writeError
+	}
+
+	if _, writeError := writer.writer.Write(element); writeError != nil {
+		return writeError
+	}
+
+	writer.written++
+	return nil
+}
+
+// Close writes the end of the JSON array.
+func (writer *jsonTimeRecordWriter) Close() error {
+	end := "\n]\n"
+	if writer.written == 0 {
+		end = "[]\n"
+	}
+
+	_, writeError := io.WriteString(writer.writer, end)
+	return writeError
+}
+
+// newNDJSONTimeRecordWriter creates a TimeRecordWriter that writes every time record as a JSON object on its own line.
+func newNDJSONTimeRecordWriter(writer io.Writer, jsonMapper *JSONTimeRecordMapper) TimeRecordWriter {
+	return &ndjsonTimeRecordWriter{
+		encoder:    json.NewEncoder(writer),
+		jsonMapper: jsonMapper,
+	}
+}
+
+// ndjsonTimeRecordWriter writes time records as newline-delimited JSON.
+type ndjsonTimeRecordWriter struct {
+	encoder    *json.Encoder
+	jsonMapper *JSONTimeRecordMapper
+}
+
+// Write writes the given time record as a single line of JSON.
+func (writer *ndjsonTimeRecordWriter) Write(timeRecord toggl.TimeRecord) error {
+	return writer.encoder.Encode(writer.jsonMapper.getJSONTimeRecord(timeRecord))
+}
+
+// Close does nothing because every line is complete.
+func (writer *ndjsonTimeRecordWriter) Close() error {
+	return nil
+}
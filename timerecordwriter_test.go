@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglapi/date"
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+func getJSONTestTimeRecord(description string) toggl.TimeRecord {
+	return toggl.TimeRecord{
+		Start:         time.Date(2016, 8, 1, 9, 0, 0, 0, time.UTC),
+		Stop:          time.Date(2016, 8, 1, 10, 30, 0, 0, time.UTC),
+		WorkspaceName: "Work",
+		ProjectName:   "Website",
+		Description:   description,
+		Tags:          []string{"design"},
+		Billable:      true,
+	}
+}
+
+func Test_jsonTimeRecordWriter_TwoRecords_JSONArrayIsWritten(t *testing.T) {
+	// arrange
+	var output bytes.Buffer
+	writer := newJSONTimeRecordWriter(&output, NewJSONTimeRecordMapper(date.NewISO8601Formatter()))
+
+	// act
+	writer.Write(getJSONTestTimeRecord("Record 1"))
+	writer.Write(getJSONTestTimeRecord("Record 2"))
+	writer.Close()
+
+	// assert
+	expected := `[
+  {"start":"2016-08-01T09:00:00+00:00","stop":"2016-08-01T10:30:00+00:00","duration":5400,"workspace":"Work","project":"Website","client":"","description":"Record 1","tags":["design"],"billable":true},
+  {"start":"2016-08-01T09:00:00+00:00","stop":"2016-08-01T10:30:00+00:00","duration":5400,"workspace":"Work","project":"Website","client":"","description":"Record 2","tags":["design"],"billable":true}
+]
+`
+	if output.String() != expected {
+		t.Fail()
+		t.Logf("The JSON writer should have written\n%s\nbut wrote\n%s", expected, output.String())
+	}
+}
+
+func Test_jsonTimeRecordWriter_NoRecords_EmptyArrayIsWritten(t *testing.T) {
+	// arrange
+	var output bytes.Buffer
+	writer := newJSONTimeRecordWriter(&output, NewJSONTimeRecordMapper(date.NewISO8601Formatter()))
+
+	// act
+	writer.Close()
+
+	// assert
+	if output.String() != "[]\n" {
+		t.Fail()
+		t.Logf("The JSON writer should have written an empty array but wrote %q", output.String())
+	}
+}
+
+func Test_ndjsonTimeRecordWriter_TwoRecords_OneLinePerRecordIsWritten(t *testing.T) {
+	// arrange
+	var output bytes.Buffer
+	mapper := NewJSONTimeRecordMapper(date.NewISO8601Formatter())
+	writer := newNDJSONTimeRecordWriter(&output, mapper)
+
+	// act
+	writer.Write(getJSONTestTimeRecord("Record 1"))
+	writer.Write(getJSONTestTimeRecord("Record 2"))
+	writer.Close()
+
+	// assert
+	timeRecords, _, err := mapper.GetTimeRecords(bytes.NewReader(output.Bytes()), formatNDJSON)
+	if err != nil || len(timeRecords) != 2 || timeRecords[1].Description != "Record 2" || !timeRecords[0].Stop.Equal(getJSONTestTimeRecord("").Stop) {
+		t.Fail()
+		t.Logf("The NDJSON output should have been read back but the result was %#v (%v): %s", timeRecords, err, output.String())
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andreaskoch/togglapi/date"
+)
+
+// localDateFormats contains the formats of dates without an offset
+// that are read in the time zone given with --timezone.
+var localDateFormats = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// loadTimezone returns the location with the given IANA name (e.g. "Europe/Berlin")
+// or nil if no name is given.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown time zone %q: %s", name, err.Error())
+	}
+
+	return location, nil
+}
+
+// newDateFormatter returns an ISO 8601 date formatter for the given time zone.
+// Without a time zone the dates are written with the offset returned by Toggl
+// and dates without an offset cannot be read.
+func newDateFormatter(location *time.Location) date.Formatter {
+	formatter := date.NewISO8601Formatter()
+	if location == nil {
+		return formatter
+	}
+
+	return &timezoneFormatter{
+		formatter: formatter,
+		location:  location,
+	}
+}
+
+// timezoneFormatter writes dates in a given time zone and
+// reads dates without an offset as dates of that time zone.
+type timezoneFormatter struct {
+	formatter date.Formatter
+	location  *time.Location
+}
+
+// GetDateString returns the given date in the time zone of the formatter.
+func (formatter *timezoneFormatter) GetDateString(date time.Time) string {
+	return formatter.formatter.GetDateString(date.In(formatter.location))
+}
+
+// GetDate parses the given date. Dates without an offset are read in the time zone of the formatter.
+// Returns an error if the date could not be parsed.
+func (formatter *timezoneFormatter) GetDate(value string) (time.Time, error) {
+	parsedDate, err := formatter.formatter.GetDate(value)
+	if err == nil {
+		return parsedDate, nil
+	}
+
+	for _, layout := range localDateFormats {
+		if localDate, localError := time.ParseInLocation(layout, value, formatter.location); localError == nil {
+			return localDate, nil
+		}
+	}
+
+	return time.Time{}, err
+}
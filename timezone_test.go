@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_loadTimezone_NoName_NilIsReturned(t *testing.T) {
+	// act
+	location, err := loadTimezone("")
+
+	// assert
+	if location != nil || err != nil {
+		t.Fail()
+		t.Logf("loadTimezone should not have returned a location or an error but returned %v (%v)", location, err)
+	}
+}
+
+func Test_loadTimezone_UnknownName_ErrorIsReturned(t *testing.T) {
+	// act
+	_, err := loadTimezone("Europe/Atlantis")
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("loadTimezone should have returned an error for an unknown time zone")
+	}
+}
+
+func Test_newDateFormatter_Timezone_DateIsWrittenInTimezone(t *testing.T) {
+	// arrange
+	location, _ := time.LoadLocation("Europe/Berlin")
+	formatter := newDateFormatter(location)
+
+	// act
+	value := formatter.GetDateString(time.Date(2016, 3, 1, 7, 0, 0, 0, time.UTC))
+
+	// assert
+	if value != "2016-03-01T08:00:00+01:00" {
+		t.Fail()
+		t.Logf("GetDateString should have written the date in the given time zone but returned %q", value)
+	}
+}
+
+func Test_newDateFormatter_Timezone_DateWithoutOffsetIsReadInTimezone(t *testing.T) {
+	// arrange
+	location, _ := time.LoadLocation("Europe/Berlin")
+	formatter := newDateFormatter(location)
+
+	inputs := []string{"2016-03-01T08:00:00", "2016-03-01 08:00:00", "2016-03-01 08:00", "2016-03-01T08:00:00+01:00"}
+
+	for _, value := range inputs {
+		// act
+		parsedDate, err := formatter.GetDate(value)
+
+		// assert
+		expected := time.Date(2016, 3, 1, 7, 0, 0, 0, time.UTC)
+		if err != nil || !parsedDate.Equal(expected) {
+			t.Fail()
+			t.Logf("GetDate(%q) should have returned %s but returned %s (%v)", value, expected, parsedDate, err)
+		}
+	}
+}
+
+func Test_newDateFormatter_NoTimezone_DateWithoutOffsetIsRejected(t *testing.T) {
+	// arrange
+	formatter := newDateFormatter(nil)
+
+	// act
+	_, err := formatter.GetDate("2016-03-01T08:00:00")
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("GetDate should have rejected a date without offset if no time zone is given")
+	}
+}
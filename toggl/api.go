@@ -0,0 +1,191 @@
+package toggl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/andreaskoch/togglapi"
+	"github.com/andreaskoch/togglapi/model"
+	"github.com/pkg/errors"
+)
+
+// createdWith identifies the application in the time entries it creates.
+const createdWith = "github.com/andreaskoch/togglapi"
+
+// An API interface extends the Toggl API of the togglapi package
+// with functions for deleting time entries, projects and clients.
+type API interface {
+	model.TogglAPI
+
+	// DeleteTimeEntry deletes the time entry with the given ID.
+	DeleteTimeEntry(timeEntryID int) error
+
+	// DeleteProject deletes the project with the given ID.
+	DeleteProject(projectID int) error
+
+	// DeleteClient deletes the client with the given ID.
+	DeleteClient(clientID int) error
+}
+
+// NewAPI creates a Toggl API for the given base URL and API token that can be shared by concurrent callers.
+// The REST clients of the togglapi package remember the time of their last request and must not be
+// used concurrently, so every request is sent with a client of its own. The requests are not throttled;
+// wrap the API with NewRateLimitedAPI to stay within the limits of the Toggl API.
+func NewAPI(baseURL, token string) API {
+	return &concurrentAPI{
+		baseURL: baseURL,
+		token:   token,
+	}
+}
+
+// concurrentAPI creates a new togglapi client for every request.
+type concurrentAPI struct {
+	baseURL string
+	token   string
+}
+
+// GetWorkspaces returns all workspaces for the current user.
+func (api *concurrentAPI) GetWorkspaces() ([]model.Workspace, error) {
+	return togglapi.NewWorkspaceAPI(api.baseURL, api.token).GetWorkspaces()
+}
+
+// CreateProject creates a new project.
+func (api *concurrentAPI) CreateProject(project model.Project) (model.Project, error) {
+	return togglapi.NewProjectAPI(api.baseURL, api.token).CreateProject(project)
+}
+
+// GetProjects returns all projects for the given workspace.
+func (api *concurrentAPI) GetProjects(workspaceID int) ([]model.Project, error) {
+	return togglapi.NewProjectAPI(api.baseURL, api.token).GetProjects(workspaceID)
+}
+
+// DeleteProject deletes the project with the given ID.
+func (api *concurrentAPI) DeleteProject(projectID int) error {
+	if _, err := api.request(http.MethodDelete, fmt.Sprintf("projects/%d", projectID), nil); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Failed to delete project %d", projectID))
+	}
+
+	return nil
+}
+
+// CreateTimeEntry creates a new time entry.
+// A time entry without a stop date starts a running timer.
+func (api *concurrentAPI) CreateTimeEntry(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+	if !timeEntry.Stop.IsZero() {
+		return togglapi.NewTimeEntryAPI(api.baseURL, api.token).CreateTimeEntry(timeEntry)
+	}
+
+	// the togglapi package always sends the difference between start and stop as the duration,
+	// but Toggl marks running time entries with the negative start timestamp
+	timeEntryRequest := struct {
+		TimeEntry interface{} `json:"time_entry"`
+	}{
+		TimeEntry: struct {
+			Wid         int       `json:"wid"`
+			Pid         int       `json:"pid"`
+			Start       time.Time `json:"start"`
+			Duration    int64     `json:"duration"`
+			Billable    bool      `json:"billable"`
+			Description string    `json:"description"`
+			Tags        []string  `json:"tags"`
+			CreatedWith string    `json:"created_with"`
+		}{
+			Wid:         timeEntry.Wid,
+			Pid:         timeEntry.Pid,
+			Start:       timeEntry.Start,
+			Duration:    -timeEntry.Start.Unix(),
+			Billable:    timeEntry.Billable,
+			Description: timeEntry.Description,
+			Tags:        timeEntry.Tags,
+			CreatedWith: createdWith,
+		},
+	}
+
+	jsonBody, marshalError := json.Marshal(timeEntryRequest)
+	if marshalError != nil {
+		return model.TimeEntry{}, errors.Wrap(marshalError, "Failed to serialize the time entry")
+	}
+
+	content, err := api.request(http.MethodPost, "time_entries", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return model.TimeEntry{}, errors.Wrap(err, "Failed to start time entry")
+	}
+
+	var timeEntryResponse struct {
+		TimeEntry model.TimeEntry `json:"data"`
+	}
+
+	if unmarshalError := json.Unmarshal(content, &timeEntryResponse); unmarshalError != nil {
+		return model.TimeEntry{}, errors.Wrap(unmarshalError, "Failed to deserialize the time entry")
+	}
+
+	return timeEntryResponse.TimeEntry, nil
+}
+
+// GetTimeEntries returns all time entries created between the given start and end date.
+func (api *concurrentAPI) GetTimeEntries(start, end time.Time) ([]model.TimeEntry, error) {
+	return togglapi.NewTimeEntryAPI(api.baseURL, api.token).GetTimeEntries(start, end)
+}
+
+// DeleteTimeEntry deletes the time entry with the given ID.
+func (api *concurrentAPI) DeleteTimeEntry(timeEntryID int) error {
+	if _, err := api.request(http.MethodDelete, fmt.Sprintf("time_entries/%d", timeEntryID), nil); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Failed to delete time entry %d", timeEntryID))
+	}
+
+	return nil
+}
+
+// CreateClient creates a new client.
+func (api *concurrentAPI) CreateClient(client model.Client) (model.Client, error) {
+	return togglapi.NewClientAPI(api.baseURL, api.token).CreateClient(client)
+}
+
+// GetClients returns all clients.
+func (api *concurrentAPI) GetClients() ([]model.Client, error) {
+	return togglapi.NewClientAPI(api.baseURL, api.token).GetClients()
+}
+
+// DeleteClient deletes the client with the given ID.
+func (api *concurrentAPI) DeleteClient(clientID int) error {
+	if _, err := api.request(http.MethodDelete, fmt.Sprintf("clients/%d", clientID), nil); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Failed to delete client %d", clientID))
+	}
+
+	return nil
+}
+
+// request sends an HTTP request with the given method, route and payload to the Toggl API
+// and returns the response. The togglapi package has no functions for deleting objects
+// or starting running timers, so these requests are sent directly.
+func (api *concurrentAPI) request(method, route string, payload io.Reader) ([]byte, error) {
+	request, requestError := http.NewRequest(method, fmt.Sprintf("%s/%s", api.baseURL, route), payload)
+	if requestError != nil {
+		return nil, requestError
+	}
+
+	request.SetBasicAuth(api.token, "api_token")
+
+	response, responseError := http.DefaultClient.Do(request)
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	defer response.Body.Close()
+
+	content, readError := ioutil.ReadAll(response.Body)
+	if readError != nil {
+		return nil, errors.Wrap(readError, "Failed to read response body")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("The %s request against %s failed (%s): %s", request.Method, request.URL, response.Status, content)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,136 @@
+package toggl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglapi/model"
+)
+
+func Test_NewAPI_ConcurrentRequests_AllRequestsAreSent(t *testing.T) {
+	// arrange
+	var mutex sync.Mutex
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		requests++
+		id := requests
+		mutex.Unlock()
+
+		fmt.Fprintf(response, `{"data":{"id":%d}}`, id)
+	}))
+	defer server.Close()
+
+	api := NewAPI(server.URL, "token")
+
+	// act
+	start := time.Now()
+	var waitGroup sync.WaitGroup
+	errors := make(chan error, 10)
+	for index := 0; index < 10; index++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+
+			if _, err := api.CreateTimeEntry(model.TimeEntry{Start: start, Stop: start.Add(time.Hour)}); err != nil {
+				errors <- err
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+	close(errors)
+
+	// assert
+	for err := range errors {
+		t.Fail()
+		t.Logf("CreateTimeEntry should not return an error but returned: %s", err.Error())
+	}
+
+	if requests != 10 {
+		t.Fail()
+		t.Logf("The API should have sent 10 requests but sent %d", requests)
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Fail()
+		t.Logf("The API should not pause between the requests but took %s", time.Since(start))
+	}
+}
+
+func Test_NewAPI_DeleteObjects_DeleteRequestsAreSent(t *testing.T) {
+	// arrange
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		requests = append(requests, request.Method+" "+request.URL.Path)
+	}))
+	defer server.Close()
+
+	api := NewAPI(server.URL, "token")
+
+	// act
+	api.DeleteTimeEntry(1)
+	api.DeleteProject(2)
+	api.DeleteClient(3)
+
+	// assert
+	expected := "[DELETE /time_entries/1 DELETE /projects/2 DELETE /clients/3]"
+	if fmt.Sprintf("%v", requests) != expected {
+		t.Fail()
+		t.Logf("The API should have sent %s but sent %v", expected, requests)
+	}
+}
+
+func Test_NewAPI_DeleteFails_ErrorIsReturned(t *testing.T) {
+	// arrange
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		http.Error(response, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	api := NewAPI(server.URL, "token")
+
+	// act
+	err := api.DeleteProject(2)
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("DeleteProject should return an error if Toggl rejects the request")
+	}
+}
+
+func Test_NewAPI_CreateTimeEntryWithoutStop_RunningTimerIsStarted(t *testing.T) {
+	// arrange
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		content, _ := ioutil.ReadAll(request.Body)
+		body = string(content)
+		fmt.Fprint(response, `{"data":{"id":42}}`)
+	}))
+	defer server.Close()
+
+	api := NewAPI(server.URL, "token")
+	start := time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC)
+
+	// act
+	timeEntry, err := api.CreateTimeEntry(model.TimeEntry{Start: start})
+
+	// assert
+	if err != nil || timeEntry.ID != 42 {
+		t.Fail()
+		t.Logf("CreateTimeEntry should return the created time entry but returned %#v (%v)", timeEntry, err)
+	}
+
+	if !strings.Contains(body, fmt.Sprintf(`"duration":%d`, -start.Unix())) {
+		t.Fail()
+		t.Logf("CreateTimeEntry should send the negative start timestamp as the duration but sent %s", body)
+	}
+}
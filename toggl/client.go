@@ -2,6 +2,7 @@ package toggl
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/andreaskoch/togglapi/model"
 	"github.com/pkg/errors"
@@ -42,7 +43,10 @@ func NewClientRepository(clientAPI model.ClientAPI, workspaceProvider Workspacer
 
 // ClientRepository provides read/write access to Toggl clients.
 type ClientRepository struct {
-	clientAPI    model.ClientAPI
+	clientAPI model.ClientAPI
+
+	// cacheMutex makes the clients cache safe for concurrent use
+	cacheMutex   sync.Mutex
 	clientsCache []Client
 
 	workspaces Workspacer
@@ -67,7 +71,9 @@ func (repository *ClientRepository) CreateClient(workspaceID int, name string) (
 	}
 
 	// reset the clients cache
+	repository.cacheMutex.Lock()
 	repository.clientsCache = nil
+	repository.cacheMutex.Unlock()
 
 	return Client{
 		ID:        createdClient.ID,
@@ -78,6 +84,9 @@ func (repository *ClientRepository) CreateClient(workspaceID int, name string) (
 
 // GetClients returns all clients.
 func (repository *ClientRepository) GetClients() ([]Client, error) {
+	repository.cacheMutex.Lock()
+	defer repository.cacheMutex.Unlock()
+
 	if repository.clientsCache != nil {
 		return repository.clientsCache, nil
 	}
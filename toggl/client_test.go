@@ -10,6 +10,7 @@ import (
 type mockClientAPI struct {
 	createClient func(client model.Client) (model.Client, error)
 	getClients   func() ([]model.Client, error)
+	deleteClient func(clientID int) error
 }
 
 func (clientAPI *mockClientAPI) CreateClient(client model.Client) (model.Client, error) {
@@ -20,6 +21,10 @@ func (clientAPI *mockClientAPI) GetClients() ([]model.Client, error) {
 	return clientAPI.getClients()
 }
 
+func (clientAPI *mockClientAPI) DeleteClient(clientID int) error {
+	return clientAPI.deleteClient(clientID)
+}
+
 func Test_CreateClient_CreateSucceeds_ClientIsReturned(t *testing.T) {
 	// arrange
 	clientAPI := &mockClientAPI{
@@ -34,20 +34,30 @@ func (converter *togglModelConverter) ConvertTimeRecordToTimeEntry(timeRecord Ti
 		return model.TimeEntry{}, errors.Wrap(workspaceError, "Cannot convert time record to time entry.")
 	}
 
-	// lookup the project
-	project, projectError := converter.projects.GetProjectByName(timeRecord.ProjectName, timeRecord.WorkspaceName, timeRecord.ClientName)
-	if projectError != nil {
-		return model.TimeEntry{}, errors.Wrap(projectError, "Cannot convert time record to time entry.")
+	// lookup the project (optional)
+	var project Project
+	if timeRecord.ProjectName != "" {
+		projectByName, projectError := converter.projects.GetProjectByName(timeRecord.ProjectName, timeRecord.WorkspaceName, timeRecord.ClientName)
+		if projectError != nil {
+			return model.TimeEntry{}, errors.Wrap(projectError, "Cannot convert time record to time entry.")
+		}
+
+		project = projectByName
+
+	} else if timeRecord.ClientName != "" {
+		return model.TimeEntry{}, fmt.Errorf("Cannot convert time record to time entry. The client %q requires a project", timeRecord.ClientName)
 	}
 
 	// create the time entry
 	timeEntryModel := model.TimeEntry{
+		ID:          timeRecord.ID,
 		Wid:         workspace.ID,
 		Pid:         project.ID,
 		Start:       timeRecord.Start,
 		Stop:        timeRecord.Stop,
 		Description: timeRecord.Description,
 		Tags:        timeRecord.Tags,
+		Billable:    timeRecord.Billable,
 	}
 
 	return timeEntryModel, nil
@@ -91,6 +101,8 @@ func (converter *togglModelConverter) ConvertTimeEntryToTimeRecord(timeEntry mod
 	}
 
 	record := TimeRecord{
+		ID: timeEntry.ID,
+
 		WorkspaceName: workspace.Name,
 		ProjectName:   project.Name,
 		ClientName:    client.Name,
@@ -99,6 +111,7 @@ func (converter *togglModelConverter) ConvertTimeEntryToTimeRecord(timeEntry mod
 		Stop:        timeEntry.Stop,
 		Tags:        timeEntry.Tags,
 		Description: timeEntry.Description,
+		Billable:    timeEntry.Billable,
 	}
 
 	return record, nil
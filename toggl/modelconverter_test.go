@@ -159,6 +159,7 @@ func Test_ConvertTimeRecordToTimeEntry_ProjectAndWorkspaceExist_TimeRecordIsRetu
 		Start:         start,
 		Stop:          stop,
 		Description:   "Yada Yada",
+		Billable:      true,
 	}
 
 	// act
@@ -170,6 +171,11 @@ func Test_ConvertTimeRecordToTimeEntry_ProjectAndWorkspaceExist_TimeRecordIsRetu
 		t.Logf("ConvertTimeRecordToTimeEntry(%#v) have returned a time record with the description %q but returned this instead: %#v", inputTimeRecord, inputTimeRecord.Description, resultTimeEntry)
 	}
 
+	if !resultTimeEntry.Billable {
+		t.Fail()
+		t.Logf("ConvertTimeRecordToTimeEntry(%#v) should have returned a billable time entry but returned this instead: %#v", inputTimeRecord, resultTimeEntry)
+	}
+
 	if err != nil {
 		t.Fail()
 		t.Logf("ConvertTimeRecordToTimeEntry(%#v) should not have returned an error but returned this: %s", inputTimeRecord, err)
@@ -281,6 +287,7 @@ func Test_ConvertTimeEntryToTimeRecord_ProjectAndWorkspaceExist_TimeRecordIsRetu
 		Start:       start,
 		Stop:        stop,
 		Description: "Yada Yada",
+		Billable:    true,
 	}
 
 	// act
@@ -292,9 +299,75 @@ func Test_ConvertTimeEntryToTimeRecord_ProjectAndWorkspaceExist_TimeRecordIsRetu
 		t.Logf("ConvertTimeEntryToTimeRecord(%#v) have returned a time record with the description %q but returned this instead: %#v", timeEntry, timeEntry.Description, timeRecord)
 	}
 
+	if !timeRecord.Billable {
+		t.Fail()
+		t.Logf("ConvertTimeEntryToTimeRecord(%#v) should have returned a billable time record but returned this instead: %#v", timeEntry, timeRecord)
+	}
+
 	if err != nil {
 		t.Fail()
 		t.Logf("ConvertTimeEntryToTimeRecord(%#v) should not have returned an error but returned this: %s", timeEntry, err)
 	}
 
 }
+
+func Test_ConvertTimeRecordToTimeEntry_NoProjectName_TimeEntryWithoutProjectIsReturned(t *testing.T) {
+	// arrange
+	modelConverter := &togglModelConverter{
+		workspaces: &mockWorkspacer{
+			getWorkspaceByName: func(workspaceName string) (Workspace, error) {
+				return Workspace{
+					ID:   1,
+					Name: workspaceName,
+				}, nil
+			},
+		},
+		projects: &mockProjecter{
+			getProjectByName: func(projectName, workspaceName, clientName string) (Project, error) {
+				return Project{}, fmt.Errorf("Project not found")
+			},
+		},
+	}
+
+	inputTimeRecord := TimeRecord{
+		WorkspaceName: "Workspace",
+		Description:   "Yada Yada",
+	}
+
+	// act
+	resultTimeEntry, err := modelConverter.ConvertTimeRecordToTimeEntry(inputTimeRecord)
+
+	// assert
+	if err != nil || resultTimeEntry.Pid != 0 || resultTimeEntry.Wid != 1 {
+		t.Fail()
+		t.Logf("ConvertTimeRecordToTimeEntry(%#v) should have returned a time entry without project but returned %#v (%v)", inputTimeRecord, resultTimeEntry, err)
+	}
+}
+
+func Test_ConvertTimeRecordToTimeEntry_ClientWithoutProject_ErrorIsReturned(t *testing.T) {
+	// arrange
+	modelConverter := &togglModelConverter{
+		workspaces: &mockWorkspacer{
+			getWorkspaceByName: func(workspaceName string) (Workspace, error) {
+				return Workspace{
+					ID:   1,
+					Name: workspaceName,
+				}, nil
+			},
+		},
+	}
+
+	inputTimeRecord := TimeRecord{
+		WorkspaceName: "Workspace",
+		ClientName:    "Client",
+	}
+
+	// act
+	_, err := modelConverter.ConvertTimeRecordToTimeEntry(inputTimeRecord)
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("ConvertTimeRecordToTimeEntry(%#v) should return an error if a client is given without a project", inputTimeRecord)
+	}
+}
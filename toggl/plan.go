@@ -0,0 +1,146 @@
+package toggl
+
+import (
+	"github.com/pkg/errors"
+)
+
+// A ChangePlan describes the changes that creating a set of time records would apply to a Toggl account.
+type ChangePlan struct {
+	// MissingWorkspaces contains the names of all referenced workspaces that don't exist.
+	MissingWorkspaces []string
+
+	// Clients contains the clients that would be created.
+	Clients []Client
+
+	// Projects contains the projects that would be created.
+	Projects []Project
+
+	// TimeRecords contains the number of time records that would be created.
+	TimeRecords int
+
+	// UnresolvableTimeRecords contains the number of time records that cannot be
+	// created because their workspace does not exist.
+	UnresolvableTimeRecords int
+}
+
+// A ChangePlanner interface calculates the changes that are required for creating time records.
+type ChangePlanner interface {
+	// GetChangePlan returns the changes that creating the given time records would apply.
+	// Returns an error if the existing workspaces, clients or projects could not be retrieved.
+	GetChangePlan(timeRecords []TimeRecord) (ChangePlan, error)
+}
+
+// NewChangePlanner creates a new change planner instance.
+func NewChangePlanner(workspaceRepository Workspacer, projectRepository Projecter, clientRepository Clienter) ChangePlanner {
+	return &ChangePlanCalculator{
+		workspaces: workspaceRepository,
+		projects:   projectRepository,
+		clients:    clientRepository,
+	}
+}
+
+// ChangePlanCalculator resolves time records against the existing workspaces,
+// clients and projects without writing anything to Toggl.
+type ChangePlanCalculator struct {
+	workspaces Workspacer
+	projects   Projecter
+	clients    Clienter
+}
+
+// GetChangePlan returns the changes that creating the given time records would apply.
+// Returns an error if the existing workspaces, clients or projects could not be retrieved.
+func (calculator *ChangePlanCalculator) GetChangePlan(timeRecords []TimeRecord) (ChangePlan, error) {
+
+	workspaces, workspacesError := calculator.workspaces.GetWorkspaces()
+	if workspacesError != nil {
+		return ChangePlan{}, errors.Wrap(workspacesError, "Failed to retrieve workspaces")
+	}
+
+	clients, clientsError := calculator.clients.GetClients()
+	if clientsError != nil {
+		return ChangePlan{}, errors.Wrap(clientsError, "Failed to retrieve clients")
+	}
+
+	projects, projectsError := calculator.projects.GetProjects()
+	if projectsError != nil {
+		return ChangePlan{}, errors.Wrap(projectsError, "Failed to retrieve projects")
+	}
+
+	workspacesByName := make(map[string]Workspace)
+	for _, workspace := range workspaces {
+		workspacesByName[workspace.Name] = workspace
+	}
+
+	existingClients := make(map[clientKey]bool)
+	for _, client := range clients {
+		existingClients[clientKey{client.Workspace.Name, client.Name}] = true
+	}
+
+	existingProjects := make(map[projectKey]bool)
+	for _, project := range projects {
+		existingProjects[projectKey{project.Workspace.Name, project.Client.Name, project.Name}] = true
+	}
+
+	var plan ChangePlan
+	missingWorkspaces := make(map[string]bool)
+	for _, timeRecord := range timeRecords {
+
+		// the workspace must exist because it cannot be created via the API
+		workspace, workspaceExists := workspacesByName[timeRecord.WorkspaceName]
+		if !workspaceExists {
+			if !missingWorkspaces[timeRecord.WorkspaceName] {
+				missingWorkspaces[timeRecord.WorkspaceName] = true
+				plan.MissingWorkspaces = append(plan.MissingWorkspaces, timeRecord.WorkspaceName)
+			}
+
+			plan.UnresolvableTimeRecords++
+			continue
+		}
+
+		plan.TimeRecords++
+
+		// time records without a project don't need a project or client
+		if timeRecord.ProjectName == "" {
+			continue
+		}
+
+		project := projectKey{timeRecord.WorkspaceName, timeRecord.ClientName, timeRecord.ProjectName}
+		if existingProjects[project] {
+			continue
+		}
+
+		// the client is created together with the project
+		var client Client
+		if timeRecord.ClientName != "" {
+			client = Client{Name: timeRecord.ClientName, Workspace: workspace}
+
+			key := clientKey{timeRecord.WorkspaceName, timeRecord.ClientName}
+			if !existingClients[key] {
+				existingClients[key] = true
+				plan.Clients = append(plan.Clients, client)
+			}
+		}
+
+		existingProjects[project] = true
+		plan.Projects = append(plan.Projects, Project{
+			Name:      timeRecord.ProjectName,
+			Client:    client,
+			Workspace: workspace,
+		})
+	}
+
+	return plan, nil
+}
+
+// clientKey identifies a client by its workspace and client name.
+type clientKey struct {
+	workspaceName string
+	clientName    string
+}
+
+// projectKey identifies a project by its workspace, client and project name.
+type projectKey struct {
+	workspaceName string
+	clientName    string
+	projectName   string
+}
@@ -0,0 +1,161 @@
+package toggl
+
+import (
+	"fmt"
+	"testing"
+)
+
+func getChangePlanCalculator(workspaces []Workspace, clients []Client, projects []Project) *ChangePlanCalculator {
+	return &ChangePlanCalculator{
+		workspaces: &mockWorkspacer{
+			getWorkspaces: func() ([]Workspace, error) {
+				return workspaces, nil
+			},
+		},
+		clients: &mockClienter{
+			getClients: func() ([]Client, error) {
+				return clients, nil
+			},
+		},
+		projects: &mockProjecter{
+			getProjects: func() ([]Project, error) {
+				return projects, nil
+			},
+		},
+	}
+}
+
+func Test_GetChangePlan_WorkspacesCannotBeRetrieved_ErrorIsReturned(t *testing.T) {
+	// arrange
+	calculator := getChangePlanCalculator(nil, nil, nil)
+	calculator.workspaces = &mockWorkspacer{
+		getWorkspaces: func() ([]Workspace, error) {
+			return nil, fmt.Errorf("Some error")
+		},
+	}
+
+	// act
+	_, err := calculator.GetChangePlan([]TimeRecord{TimeRecord{WorkspaceName: "Workspace"}})
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("GetChangePlan should return an error if the workspaces cannot be retrieved")
+	}
+}
+
+func Test_GetChangePlan_AllEntitiesExist_OnlyTimeRecordsAreCreated(t *testing.T) {
+	// arrange
+	workspace := Workspace{ID: 1, Name: "Workspace"}
+	client := Client{ID: 2, Name: "Client", Workspace: workspace}
+	project := Project{ID: 3, Name: "Project", Client: client, Workspace: workspace}
+
+	calculator := getChangePlanCalculator([]Workspace{workspace}, []Client{client}, []Project{project})
+
+	timeRecords := []TimeRecord{
+		TimeRecord{WorkspaceName: "Workspace", ClientName: "Client", ProjectName: "Project"},
+		TimeRecord{WorkspaceName: "Workspace", ClientName: "Client", ProjectName: "Project"},
+	}
+
+	// act
+	plan, err := calculator.GetChangePlan(timeRecords)
+
+	// assert
+	if err != nil {
+		t.Fatalf("GetChangePlan returned an error: %s", err)
+	}
+
+	if plan.TimeRecords != 2 || len(plan.Projects) != 0 || len(plan.Clients) != 0 || len(plan.MissingWorkspaces) != 0 {
+		t.Fail()
+		t.Logf("GetChangePlan should only plan the creation of the two time records but returned: %#v", plan)
+	}
+}
+
+func Test_GetChangePlan_ProjectAndClientDontExist_ProjectAndClientArePlannedOnce(t *testing.T) {
+	// arrange
+	workspace := Workspace{ID: 1, Name: "Workspace"}
+
+	calculator := getChangePlanCalculator([]Workspace{workspace}, nil, nil)
+
+	timeRecords := []TimeRecord{
+		TimeRecord{WorkspaceName: "Workspace", ClientName: "Client", ProjectName: "Project A"},
+		TimeRecord{WorkspaceName: "Workspace", ClientName: "Client", ProjectName: "Project A"},
+		TimeRecord{WorkspaceName: "Workspace", ClientName: "Client", ProjectName: "Project B"},
+		TimeRecord{WorkspaceName: "Workspace", ClientName: "", ProjectName: "Project C"},
+	}
+
+	// act
+	plan, err := calculator.GetChangePlan(timeRecords)
+
+	// assert
+	if err != nil {
+		t.Fatalf("GetChangePlan returned an error: %s", err)
+	}
+
+	if len(plan.Clients) != 1 || plan.Clients[0].Name != "Client" {
+		t.Fail()
+		t.Logf("GetChangePlan should plan the creation of exactly one client but returned: %#v", plan.Clients)
+	}
+
+	if len(plan.Projects) != 3 {
+		t.Fail()
+		t.Logf("GetChangePlan should plan the creation of three projects but returned: %#v", plan.Projects)
+	}
+
+	if plan.TimeRecords != 4 {
+		t.Fail()
+		t.Logf("GetChangePlan should plan the creation of four time records but returned %d", plan.TimeRecords)
+	}
+}
+
+func Test_GetChangePlan_WorkspaceDoesNotExist_WorkspaceIsReportedAsMissing(t *testing.T) {
+	// arrange
+	calculator := getChangePlanCalculator([]Workspace{Workspace{ID: 1, Name: "Workspace"}}, nil, nil)
+
+	timeRecords := []TimeRecord{
+		TimeRecord{WorkspaceName: "Old Co", ProjectName: "Project"},
+		TimeRecord{WorkspaceName: "Old Co", ProjectName: "Project"},
+	}
+
+	// act
+	plan, err := calculator.GetChangePlan(timeRecords)
+
+	// assert
+	if err != nil {
+		t.Fatalf("GetChangePlan returned an error: %s", err)
+	}
+
+	if len(plan.MissingWorkspaces) != 1 || plan.MissingWorkspaces[0] != "Old Co" {
+		t.Fail()
+		t.Logf("GetChangePlan should report the missing workspace once but returned: %#v", plan.MissingWorkspaces)
+	}
+
+	if plan.UnresolvableTimeRecords != 2 || plan.TimeRecords != 0 || len(plan.Projects) != 0 {
+		t.Fail()
+		t.Logf("GetChangePlan should not plan any changes for records in missing workspaces: %#v", plan)
+	}
+}
+
+func Test_GetChangePlan_TimeRecordWithoutProject_NoProjectIsPlanned(t *testing.T) {
+	// arrange
+	workspace := Workspace{ID: 1, Name: "Workspace"}
+
+	calculator := getChangePlanCalculator([]Workspace{workspace}, nil, nil)
+
+	timeRecords := []TimeRecord{
+		TimeRecord{WorkspaceName: "Workspace"},
+	}
+
+	// act
+	plan, err := calculator.GetChangePlan(timeRecords)
+
+	// assert
+	if err != nil {
+		t.Fatalf("GetChangePlan returned an error: %s", err)
+	}
+
+	if plan.TimeRecords != 1 || len(plan.Projects) != 0 || len(plan.Clients) != 0 {
+		t.Fail()
+		t.Logf("GetChangePlan should only plan the creation of the time record but returned: %#v", plan)
+	}
+}
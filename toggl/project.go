@@ -2,6 +2,7 @@ package toggl
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/andreaskoch/togglapi/model"
 	"github.com/pkg/errors"
@@ -21,7 +22,7 @@ type Projecter interface {
 	// Returns an error of the creation failed.
 	CreateProject(projectName, workspaceName, clientName string) (Project, error)
 
-	// GetProjects returns all projects.
+	// GetProjects returns all projects of the included workspaces.
 	GetProjects() ([]Project, error)
 
 	// GetProjectByID returns the project for the given project id.
@@ -42,18 +43,41 @@ func NewProjectRepository(projectAPI model.ProjectAPI, workspaceProvider Workspa
 	}
 }
 
+// NewFilteredProjectRepository creates a new project repository instance
+// that only loads the projects of the workspaces selected by includeWorkspace.
+// The projects of the other workspaces and their clients are not requested from Toggl.
+func NewFilteredProjectRepository(projectAPI model.ProjectAPI, workspaceProvider Workspacer, clientProvider Clienter, includeWorkspace func(workspaceName string) bool) Projecter {
+	return &ProjectRepository{
+		projectAPI:       projectAPI,
+		workspaces:       workspaceProvider,
+		clients:          clientProvider,
+		includeWorkspace: includeWorkspace,
+	}
+}
+
 // ProjectRepository provides read/write access to Toggl projects.
 type ProjectRepository struct {
-	projectAPI    model.ProjectAPI
+	projectAPI model.ProjectAPI
+
+	// createMutex makes sure clients are only created once if projects are created concurrently
+	createMutex sync.Mutex
+
+	// cacheMutex makes the projects cache safe for concurrent use
+	cacheMutex    sync.Mutex
 	projectsCache []Project
 
 	workspaces Workspacer
 	clients    Clienter
+
+	// includeWorkspace selects the workspaces whose projects are loaded (optional)
+	includeWorkspace func(workspaceName string) bool
 }
 
 // CreateProject creates a new project with the given name.
 // Returns an error of the creation failed.
 func (repository *ProjectRepository) CreateProject(projectName, workspaceName, clientName string) (Project, error) {
+	repository.createMutex.Lock()
+	defer repository.createMutex.Unlock()
 
 	workspace, workspaceError := repository.workspaces.GetWorkspaceByName(workspaceName)
 	if workspaceError != nil {
@@ -90,7 +114,9 @@ func (repository *ProjectRepository) CreateProject(projectName, workspaceName, c
 	}
 
 	// reset the projects cache
+	repository.cacheMutex.Lock()
 	repository.projectsCache = nil
+	repository.cacheMutex.Unlock()
 
 	return Project{
 		ID:        createdProject.ID,
@@ -100,8 +126,11 @@ func (repository *ProjectRepository) CreateProject(projectName, workspaceName, c
 	}, nil
 }
 
-// GetProjects returns all projects.
+// GetProjects returns all projects of the included workspaces.
 func (repository *ProjectRepository) GetProjects() ([]Project, error) {
+	repository.cacheMutex.Lock()
+	defer repository.cacheMutex.Unlock()
+
 	if repository.projectsCache != nil {
 		return repository.projectsCache, nil
 	}
@@ -114,6 +143,11 @@ func (repository *ProjectRepository) GetProjects() ([]Project, error) {
 	var projects []Project
 	for _, workspace := range workspaces {
 
+		// don't request the projects and clients of excluded workspaces
+		if repository.includeWorkspace != nil && !repository.includeWorkspace(workspace.Name) {
+			continue
+		}
+
 		projectsByWorkspace, projectsByWorkspaceError := repository.projectAPI.GetProjects(workspace.ID)
 		if projectsByWorkspaceError != nil {
 			return nil, errors.Wrap(projectsByWorkspaceError, "Failed to get projects from Toggl")
@@ -10,6 +10,7 @@ import (
 type mockProjectAPI struct {
 	createProject func(project model.Project) (model.Project, error)
 	getProjects   func(workspaceID int) ([]model.Project, error)
+	deleteProject func(projectID int) error
 }
 
 func (projectAPI *mockProjectAPI) CreateProject(project model.Project) (model.Project, error) {
@@ -20,6 +21,10 @@ func (projectAPI *mockProjectAPI) GetProjects(workspaceID int) ([]model.Project,
 	return projectAPI.getProjects(workspaceID)
 }
 
+func (projectAPI *mockProjectAPI) DeleteProject(projectID int) error {
+	return projectAPI.deleteProject(projectID)
+}
+
 type mockClienter struct {
 	createClient    func(workspaceID int, name string) (Client, error)
 	getClients      func() ([]Client, error)
@@ -281,6 +286,60 @@ func Test_GetProjects_SecondCallUsesTheCache(t *testing.T) {
 	}
 }
 
+func Test_GetProjects_WorkspaceFilterIsGiven_OnlyProjectsAndClientsOfIncludedWorkspacesAreRequested(t *testing.T) {
+	// arrange
+	var requestedWorkspaceIDs []int
+	projectAPI := &mockProjectAPI{
+		getProjects: func(workspaceID int) ([]model.Project, error) {
+			requestedWorkspaceIDs = append(requestedWorkspaceIDs, workspaceID)
+
+			return []model.Project{
+				model.Project{ID: workspaceID * 10, WorkspaceID: workspaceID, ClientID: workspaceID * 100},
+			}, nil
+		},
+	}
+
+	workspaceProvider := &mockWorkspacer{
+		getWorkspaces: func() ([]Workspace, error) {
+			return []Workspace{
+				Workspace{ID: 1, Name: "Included"},
+				Workspace{ID: 2, Name: "Excluded"},
+			}, nil
+		},
+	}
+
+	var requestedClientIDs []int
+	clientProvider := &mockClienter{
+		getClientByID: func(clientID int) (Client, error) {
+			requestedClientIDs = append(requestedClientIDs, clientID)
+			return Client{ID: clientID}, nil
+		},
+	}
+
+	projectRepository := NewFilteredProjectRepository(projectAPI, workspaceProvider, clientProvider, func(workspaceName string) bool {
+		return workspaceName == "Included"
+	})
+
+	// act
+	projects, err := projectRepository.GetProjects()
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("GetProjects should not return an error but returned: %s", err.Error())
+	}
+
+	if len(projects) != 1 || projects[0].ID != 10 {
+		t.Fail()
+		t.Logf("GetProjects should only have returned the project of the included workspace but returned: %#v", projects)
+	}
+
+	if fmt.Sprintf("%v %v", requestedWorkspaceIDs, requestedClientIDs) != "[1] [100]" {
+		t.Fail()
+		t.Logf("GetProjects should only have requested the projects and clients of workspace 1 but requested the projects of %v and the clients %v", requestedWorkspaceIDs, requestedClientIDs)
+	}
+}
+
 func Test_GetProjectByName_NoProjectsAvailabe_ErrorIsReturned(t *testing.T) {
 	// arrange
 	projectAPI := &mockProjectAPI{
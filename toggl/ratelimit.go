@@ -0,0 +1,146 @@
+package toggl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/andreaskoch/togglapi/model"
+)
+
+// A RateLimiter interface limits the number of requests that are sent to the Toggl API.
+type RateLimiter interface {
+	// Wait blocks until the next request may be sent.
+	Wait()
+}
+
+// NewTokenBucket creates a new token bucket rate limiter that allows the given
+// number of requests per second and bursts of up to the given size.
+func NewTokenBucket(requestsPerSecond float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &TokenBucket{
+		rate:     requestsPerSecond,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+}
+
+// TokenBucket is a RateLimiter that can be shared by concurrent callers.
+// Tokens are refilled at a fixed rate; every request consumes one token.
+type TokenBucket struct {
+	mutex sync.Mutex
+
+	rate     float64
+	capacity float64
+	tokens   float64
+	lastFill time.Time
+
+	now   func() time.Time
+	sleep func(duration time.Duration)
+}
+
+// Wait blocks until a token is available.
+func (bucket *TokenBucket) Wait() {
+	bucket.mutex.Lock()
+
+	// refill the tokens that accumulated since the last call
+	now := bucket.now()
+	if !bucket.lastFill.IsZero() {
+		bucket.tokens += now.Sub(bucket.lastFill).Seconds() * bucket.rate
+		if bucket.tokens > bucket.capacity {
+			bucket.tokens = bucket.capacity
+		}
+	}
+	bucket.lastFill = now
+
+	// reserve a token; a negative balance is paid off by waiting
+	bucket.tokens--
+	var waitTime time.Duration
+	if bucket.tokens < 0 {
+		waitTime = time.Duration(-bucket.tokens / bucket.rate * float64(time.Second))
+	}
+
+	bucket.mutex.Unlock()
+
+	if waitTime > 0 {
+		bucket.sleep(waitTime)
+	}
+}
+
+// NewRateLimitedAPI returns a Toggl API whose requests are throttled by the given rate limiter.
+func NewRateLimitedAPI(api API, limiter RateLimiter) API {
+	return &rateLimitedAPI{
+		api:     api,
+		limiter: limiter,
+	}
+}
+
+// rateLimitedAPI waits for the rate limiter before every call of the underlying Toggl API.
+type rateLimitedAPI struct {
+	api     API
+	limiter RateLimiter
+}
+
+// GetWorkspaces returns all workspaces for the current user.
+func (api *rateLimitedAPI) GetWorkspaces() ([]model.Workspace, error) {
+	api.limiter.Wait()
+	return api.api.GetWorkspaces()
+}
+
+// CreateProject creates a new project.
+func (api *rateLimitedAPI) CreateProject(project model.Project) (model.Project, error) {
+	api.limiter.Wait()
+	return api.api.CreateProject(project)
+}
+
+// GetProjects returns all projects for the given workspace.
+func (api *rateLimitedAPI) GetProjects(workspaceID int) ([]model.Project, error) {
+	api.limiter.Wait()
+	return api.api.GetProjects(workspaceID)
+}
+
+// DeleteProject deletes the project with the given ID.
+func (api *rateLimitedAPI) DeleteProject(projectID int) error {
+	api.limiter.Wait()
+	return api.api.DeleteProject(projectID)
+}
+
+// CreateTimeEntry creates a new time entry.
+func (api *rateLimitedAPI) CreateTimeEntry(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+	api.limiter.Wait()
+	return api.api.CreateTimeEntry(timeEntry)
+}
+
+// GetTimeEntries returns all time entries created between the given start and end date.
+func (api *rateLimitedAPI) GetTimeEntries(start, end time.Time) ([]model.TimeEntry, error) {
+	api.limiter.Wait()
+	return api.api.GetTimeEntries(start, end)
+}
+
+// DeleteTimeEntry deletes the time entry with the given ID.
+func (api *rateLimitedAPI) DeleteTimeEntry(timeEntryID int) error {
+	api.limiter.Wait()
+	return api.api.DeleteTimeEntry(timeEntryID)
+}
+
+// CreateClient creates a new client.
+func (api *rateLimitedAPI) CreateClient(client model.Client) (model.Client, error) {
+	api.limiter.Wait()
+	return api.api.CreateClient(client)
+}
+
+// GetClients returns all clients.
+func (api *rateLimitedAPI) GetClients() ([]model.Client, error) {
+	api.limiter.Wait()
+	return api.api.GetClients()
+}
+
+// DeleteClient deletes the client with the given ID.
+func (api *rateLimitedAPI) DeleteClient(clientID int) error {
+	api.limiter.Wait()
+	return api.api.DeleteClient(clientID)
+}
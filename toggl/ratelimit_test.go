@@ -0,0 +1,97 @@
+package toggl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglapi/model"
+)
+
+type mockRateLimiter struct {
+	calls int
+}
+
+func (limiter *mockRateLimiter) Wait() {
+	limiter.calls++
+}
+
+type mockTogglAPI struct {
+	mockWorkspaceAPI
+	mockProjectAPI
+	mockTimeEntryAPI
+	mockClientAPI
+}
+
+func Test_TokenBucket_BurstIsUsedUp_CallersHaveToWait(t *testing.T) {
+	// arrange
+	currentTime := time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC)
+	var waitTimes []time.Duration
+
+	bucket := NewTokenBucket(2, 1)
+	bucket.now = func() time.Time { return currentTime }
+	bucket.sleep = func(duration time.Duration) { waitTimes = append(waitTimes, duration) }
+
+	// act
+	bucket.Wait()
+	bucket.Wait()
+	bucket.Wait()
+
+	// assert
+	expected := []time.Duration{500 * time.Millisecond, time.Second}
+	if len(waitTimes) != len(expected) || waitTimes[0] != expected[0] || waitTimes[1] != expected[1] {
+		t.Fail()
+		t.Logf("The token bucket should have waited %v but waited %v", expected, waitTimes)
+	}
+}
+
+func Test_TokenBucket_TokensAreRefilled_CallersDontHaveToWait(t *testing.T) {
+	// arrange
+	currentTime := time.Date(2016, 8, 12, 9, 0, 0, 0, time.UTC)
+	var waitTimes []time.Duration
+
+	bucket := NewTokenBucket(1, 3)
+	bucket.now = func() time.Time { return currentTime }
+	bucket.sleep = func(duration time.Duration) { waitTimes = append(waitTimes, duration) }
+
+	// act
+	bucket.Wait()
+	bucket.Wait()
+	bucket.Wait()
+
+	currentTime = currentTime.Add(10 * time.Second)
+	bucket.Wait()
+	bucket.Wait()
+
+	// assert
+	if len(waitTimes) != 0 {
+		t.Fail()
+		t.Logf("The token bucket should not have waited but waited %v", waitTimes)
+	}
+}
+
+func Test_NewRateLimitedAPI_EveryRequestWaitsForTheLimiter(t *testing.T) {
+	// arrange
+	limiter := &mockRateLimiter{}
+	api := NewRateLimitedAPI(&mockTogglAPI{
+		mockWorkspaceAPI: mockWorkspaceAPI{
+			getWorkspaces: func() ([]model.Workspace, error) {
+				return nil, nil
+			},
+		},
+		mockTimeEntryAPI: mockTimeEntryAPI{
+			createTimeEntry: func(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+				return timeEntry, nil
+			},
+		},
+	}, limiter)
+
+	// act
+	api.GetWorkspaces()
+	api.CreateTimeEntry(model.TimeEntry{})
+
+	// assert
+	if limiter.calls != 2 {
+		t.Fail()
+		t.Logf("The rate limited API should have waited for the limiter twice but waited %d times", limiter.calls)
+	}
+}
@@ -122,3 +122,21 @@ func Test_GetTimeRanges_StopDateBeforeStartDate_ErrorIsReturned(t *testing.T) {
 	}
 
 }
+
+func Test_GetTimeRanges_StartDateInTimezone_RangeStartsAtBeginningOfLocalDay(t *testing.T) {
+	// arrange
+	location, _ := time.LoadLocation("Europe/Berlin")
+	timeRangeProvider := &fullMonthTimeRangeProvider{}
+	start := time.Date(2016, 3, 1, 0, 0, 0, 0, location)
+	stop := time.Date(2016, 3, 31, 0, 0, 0, 0, location)
+
+	// act
+	ranges, err := timeRangeProvider.GetTimeRanges(start, stop)
+
+	// assert
+	expectedStart := time.Date(2016, 2, 29, 23, 0, 1, 0, time.UTC)
+	if err != nil || len(ranges) != 1 || !ranges[0].Start().Equal(expectedStart) {
+		t.Fail()
+		t.Logf("GetTimeRanges(%q, %q) should have returned one range starting at %s but returned %v (%v)", start, stop, expectedStart, ranges, err)
+	}
+}
@@ -24,9 +24,9 @@ type fullMonthTimeRangeProvider struct {
 // Returns an error if no ranges can be calculated for the given start and end date.
 func (fullMonthTimeRangeProvider) GetTimeRanges(startDate, endDate time.Time) ([]timeRange, error) {
 
-	// normalize the time of the start and end date
-	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 1, 0, time.UTC)
-	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 0, time.UTC)
+	// normalize the time of the start and end date (in the time zone of the given dates)
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 1, 0, startDate.Location())
+	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 0, endDate.Location())
 
 	// validate start and end date
 	if startDate.After(endDate) {
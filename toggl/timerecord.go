@@ -2,6 +2,7 @@ package toggl
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/andreaskoch/togglapi/model"
@@ -10,6 +11,9 @@ import (
 
 // TimeRecord represents a single time tracking record
 type TimeRecord struct {
+	// ID contains the id of the Toggl time entry (0 if the record has not been created yet)
+	ID int
+
 	WorkspaceName string
 	ProjectName   string
 	ClientName    string
@@ -18,19 +22,47 @@ type TimeRecord struct {
 	Stop        time.Time
 	Description string
 	Tags        []string
+	Billable    bool
+}
+
+// IsRunning returns true if the time record has no stop date because its timer is still running.
+func (timeRecord TimeRecord) IsRunning() bool {
+	return timeRecord.Stop.IsZero()
 }
 
 // A TimeRecorder interface provides functions for reading and writing time records.
 type TimeRecorder interface {
-	// CreateTimeRecord creates a new time record.
+	// CreateTimeRecord creates a new time record and returns it with the ID of the created time entry.
 	// Returns an error if the creation failed.
-	CreateTimeRecord(timeRecord TimeRecord) error
+	CreateTimeRecord(timeRecord TimeRecord) (TimeRecord, error)
 
 	// GetTimeRecords returns all time records from the given start date until the given stop date.
+	// Running time records and time records without project are skipped unless the filter of the repository includes them.
 	// Returns an error of the time records could not be retrieved.
 	GetTimeRecords(start, stop time.Time) ([]TimeRecord, error)
 }
 
+// TimeRecordFilter selects time records by their workspace and project
+// and includes running time records and time records without project on request.
+// The filter is applied to the fetched time entries before they are converted
+// so that the projects and clients of excluded time entries are not looked up.
+// Pass a project repository created by NewFilteredProjectRepository with the same
+// workspace filter to load only the projects of the included workspaces.
+type TimeRecordFilter struct {
+	// IncludeWorkspace returns true if the time records of the workspace with the given name are included (optional).
+	IncludeWorkspace func(workspaceName string) bool
+
+	// IncludeProject returns true if the time records of the project with the given name are included (optional).
+	// The project name of time records without project is empty.
+	IncludeProject func(projectName string) bool
+
+	// IncludeRunning includes the running time record. It has no stop date.
+	IncludeRunning bool
+
+	// IncludeWithoutProject includes the time records without project.
+	IncludeWithoutProject bool
+}
+
 // NewTimeRecordRepository creates a new time record repository instance.
 func NewTimeRecordRepository(
 	timeEntryAPI model.TimeEntryAPI,
@@ -38,6 +70,18 @@ func NewTimeRecordRepository(
 	projectRepository Projecter,
 	clientRepository Clienter) TimeRecorder {
 
+	return NewFilteredTimeRecordRepository(timeEntryAPI, workspaceRepository, projectRepository, clientRepository, TimeRecordFilter{})
+}
+
+// NewFilteredTimeRecordRepository creates a new time record repository instance
+// that only returns the time records selected by the given filter.
+func NewFilteredTimeRecordRepository(
+	timeEntryAPI model.TimeEntryAPI,
+	workspaceRepository Workspacer,
+	projectRepository Projecter,
+	clientRepository Clienter,
+	filter TimeRecordFilter) TimeRecorder {
+
 	return &TimeRecordRepository{
 		timeEntryAPI:      timeEntryAPI,
 		timeRangeProvider: fullMonthTimeRangeProvider{},
@@ -50,6 +94,10 @@ func NewTimeRecordRepository(
 			projects:   projectRepository,
 			clients:    clientRepository,
 		},
+
+		filter:             filter,
+		includedWorkspaces: make(map[int]bool),
+		includedProjects:   make(map[int]bool),
 	}
 }
 
@@ -58,32 +106,60 @@ type TimeRecordRepository struct {
 	timeEntryAPI      model.TimeEntryAPI
 	timeRangeProvider timeRangeProvider
 
+	// projectMutex makes sure missing projects are only created once
+	// if time records are created concurrently
+	projectMutex sync.Mutex
+
 	workspaces Workspacer
 	projects   Projecter
 
 	modelConverter modelConverter
+
+	// filter selects the returned time records;
+	// the decisions are cached by workspace and project ID
+	filter             TimeRecordFilter
+	filterMutex        sync.Mutex
+	includedWorkspaces map[int]bool
+	includedProjects   map[int]bool
 }
 
-// CreateTimeRecord creates a new time record.
+// CreateTimeRecord creates a new time record and returns it with the ID of the created time entry.
 // Returns an error if the creation failed.
-func (repository *TimeRecordRepository) CreateTimeRecord(timeRecord TimeRecord) error {
+func (repository *TimeRecordRepository) CreateTimeRecord(timeRecord TimeRecord) (TimeRecord, error) {
 
 	// create the project if it does not exist
-	if _, projectError := repository.projects.GetProjectByName(timeRecord.ProjectName, timeRecord.WorkspaceName, timeRecord.ClientName); projectError != nil {
-
-		if _, createProjectError := repository.projects.CreateProject(timeRecord.ProjectName, timeRecord.WorkspaceName, timeRecord.ClientName); createProjectError != nil {
-			return errors.Wrap(createProjectError, fmt.Sprintf("Failed to create project for time record: %#v", timeRecord))
+	if timeRecord.ProjectName != "" {
+		if createProjectError := repository.ensureProjectExists(timeRecord); createProjectError != nil {
+			return TimeRecord{}, createProjectError
 		}
 	}
 
 	timeEntry, conversionError := repository.modelConverter.ConvertTimeRecordToTimeEntry(timeRecord)
 	if conversionError != nil {
-		return errors.Wrap(conversionError, fmt.Sprintf("Failed to convert the given time record (%#v) into a valid time entry", timeRecord))
+		return TimeRecord{}, errors.Wrap(conversionError, fmt.Sprintf("Failed to convert the given time record (%#v) into a valid time entry", timeRecord))
 	}
 
-	_, createError := repository.timeEntryAPI.CreateTimeEntry(timeEntry)
+	createdTimeEntry, createError := repository.timeEntryAPI.CreateTimeEntry(timeEntry)
 	if createError != nil {
-		return errors.Wrap(createError, fmt.Sprintf("Failed to create time record (%v)", timeRecord))
+		return TimeRecord{}, errors.Wrap(createError, fmt.Sprintf("Failed to create time record (%v)", timeRecord))
+	}
+
+	timeRecord.ID = createdTimeEntry.ID
+
+	return timeRecord, nil
+}
+
+// ensureProjectExists creates the project of the given time record if it does not exist yet.
+func (repository *TimeRecordRepository) ensureProjectExists(timeRecord TimeRecord) error {
+	repository.projectMutex.Lock()
+	defer repository.projectMutex.Unlock()
+
+	if _, projectError := repository.projects.GetProjectByName(timeRecord.ProjectName, timeRecord.WorkspaceName, timeRecord.ClientName); projectError == nil {
+		return nil
+	}
+
+	if _, createProjectError := repository.projects.CreateProject(timeRecord.ProjectName, timeRecord.WorkspaceName, timeRecord.ClientName); createProjectError != nil {
+		return errors.Wrap(createProjectError, fmt.Sprintf("Failed to create project for time record: %#v", timeRecord))
 	}
 
 	return nil
@@ -135,12 +211,21 @@ func (repository *TimeRecordRepository) getTimeRecords(start, stop time.Time) ([
 	for _, timeEntry := range timeEntries {
 
 		// skip entries without project
-		if noProjectIDSet := timeEntry.Pid == 0; noProjectIDSet {
+		if noProjectIDSet := timeEntry.Pid == 0; noProjectIDSet && !repository.filter.IncludeWithoutProject {
 			continue
 		}
 
 		// skip running entries
-		if isRunningEntry := timeEntry.Stop.Equal(time.Time{}); isRunningEntry {
+		if isRunningEntry := timeEntry.Stop.Equal(time.Time{}); isRunningEntry && !repository.filter.IncludeRunning {
+			continue
+		}
+
+		included, filterError := repository.isIncluded(timeEntry)
+		if filterError != nil {
+			return nil, errors.Wrap(filterError, fmt.Sprintf("Failed to filter time entry %d", timeEntry.ID))
+		}
+
+		if !included {
 			continue
 		}
 
@@ -154,3 +239,51 @@ func (repository *TimeRecordRepository) getTimeRecords(start, stop time.Time) ([
 
 	return records, nil
 }
+
+// isIncluded returns true if the workspace and the project of the given time entry are selected by the filter.
+// Returns an error if the workspace or project could not be loaded.
+func (repository *TimeRecordRepository) isIncluded(timeEntry model.TimeEntry) (bool, error) {
+	repository.filterMutex.Lock()
+	defer repository.filterMutex.Unlock()
+
+	if repository.filter.IncludeWorkspace != nil {
+		included, isCached := repository.includedWorkspaces[timeEntry.Wid]
+		if !isCached {
+			workspace, workspaceError := repository.workspaces.GetWorkspaceByID(timeEntry.Wid)
+			if workspaceError != nil {
+				return false, workspaceError
+			}
+
+			included = repository.filter.IncludeWorkspace(workspace.Name)
+			repository.includedWorkspaces[timeEntry.Wid] = included
+		}
+
+		if !included {
+			return false, nil
+		}
+	}
+
+	if repository.filter.IncludeProject != nil {
+		included, isCached := repository.includedProjects[timeEntry.Pid]
+		if !isCached {
+			projectName := ""
+			if timeEntry.Pid != 0 {
+				project, projectError := repository.projects.GetProjectByID(timeEntry.Pid)
+				if projectError != nil {
+					return false, projectError
+				}
+
+				projectName = project.Name
+			}
+
+			included = repository.filter.IncludeProject(projectName)
+			repository.includedProjects[timeEntry.Pid] = included
+		}
+
+		if !included {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -2,6 +2,7 @@ package toggl
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 type mockTimeEntryAPI struct {
 	createTimeEntry func(timeEntry model.TimeEntry) (model.TimeEntry, error)
 	getTimeEntries  func(start, end time.Time) ([]model.TimeEntry, error)
+	deleteTimeEntry func(timeEntryID int) error
 }
 
 func (timeEntryAPI *mockTimeEntryAPI) CreateTimeEntry(timeEntry model.TimeEntry) (model.TimeEntry, error) {
@@ -22,6 +24,10 @@ func (timeEntryAPI *mockTimeEntryAPI) GetTimeEntries(start, end time.Time) ([]mo
 	return timeEntryAPI.getTimeEntries(start, end)
 }
 
+func (timeEntryAPI *mockTimeEntryAPI) DeleteTimeEntry(timeEntryID int) error {
+	return timeEntryAPI.deleteTimeEntry(timeEntryID)
+}
+
 type mockModelConverter struct {
 	convertTimeEntryToTimeRecord func(timeEntry model.TimeEntry) (TimeRecord, error)
 	convertTimeRecordToTimeEntry func(timeRecord TimeRecord) (model.TimeEntry, error)
@@ -82,12 +88,12 @@ func Test_CreateTimeRecord_ConversionFails_ErrorIsReturned(t *testing.T) {
 	}
 
 	// act
-	err := repository.CreateTimeRecord(inputTimeRecord)
+	_, err := repository.CreateTimeRecord(inputTimeRecord)
 
 	// assert
 	if err == nil {
 		t.Fail()
-		t.Logf("CreateTimeRecord(%q) should have returned an error", inputTimeRecord)
+		t.Logf("CreateTimeRecord(%#v) should have returned an error", inputTimeRecord)
 	}
 }
 
@@ -129,12 +135,12 @@ func Test_CreateTimeRecord_CreateFails_ErrorIsReturned(t *testing.T) {
 	}
 
 	// act
-	err := repository.CreateTimeRecord(inputTimeRecord)
+	_, err := repository.CreateTimeRecord(inputTimeRecord)
 
 	// assert
 	if err == nil {
 		t.Fail()
-		t.Logf("CreateTimeRecord(%q) should have returned an error", inputTimeRecord)
+		t.Logf("CreateTimeRecord(%#v) should have returned an error", inputTimeRecord)
 	}
 }
 
@@ -176,12 +182,51 @@ func Test_CreateTimeRecord_CreateSucceeds_NoErrorIsReturned(t *testing.T) {
 	}
 
 	// act
-	err := repository.CreateTimeRecord(inputTimeRecord)
+	_, err := repository.CreateTimeRecord(inputTimeRecord)
 
 	// assert
 	if err != nil {
 		t.Fail()
-		t.Logf("CreateTimeRecord(%q) should not have returned an error but returned this instead: %s", inputTimeRecord, err)
+		t.Logf("CreateTimeRecord(%#v) should not have returned an error but returned this instead: %s", inputTimeRecord, err)
+	}
+}
+
+func Test_CreateTimeRecord_CreateSucceeds_IDOfTheTimeEntryIsReturned(t *testing.T) {
+	// arrange
+	timeEntryAPI := &mockTimeEntryAPI{
+		createTimeEntry: func(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+			timeEntry.ID = 412
+			return timeEntry, nil
+		},
+	}
+
+	repository := &TimeRecordRepository{
+		timeEntryAPI: timeEntryAPI,
+		modelConverter: &mockModelConverter{
+			convertTimeRecordToTimeEntry: func(timeRecord TimeRecord) (model.TimeEntry, error) {
+				return model.TimeEntry{}, nil
+			},
+		},
+		projects: &mockProjecter{
+			getProjectByName: func(projectName, workspaceName, clientName string) (Project, error) {
+				return Project{}, nil
+			},
+		},
+	}
+
+	inputTimeRecord := TimeRecord{
+		Start:       time.Date(2016, 8, 1, 9, 0, 0, 0, time.UTC),
+		Stop:        time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC),
+		Description: "Yada Yada",
+	}
+
+	// act
+	timeRecord, _ := repository.CreateTimeRecord(inputTimeRecord)
+
+	// assert
+	if timeRecord.ID != 412 || timeRecord.Description != inputTimeRecord.Description {
+		t.Fail()
+		t.Logf("CreateTimeRecord should have returned the given time record with the ID of the created time entry but returned %#v", timeRecord)
 	}
 }
 
@@ -410,6 +455,113 @@ func Test_GetTimeRecords_APIReturnsTimeEntryWithoutProject_NoTimeRecordIsReturne
 	}
 }
 
+func Test_GetTimeRecords_IncludeRunning_APIReturnsRunningTimeEntry_TimeRecordWithoutStopIsReturned(t *testing.T) {
+	// arrange
+	start := time.Date(2016, 8, 1, 0, 0, 1, 0, time.UTC)
+	stop := time.Date(2016, 8, 31, 23, 59, 59, 0, time.UTC)
+
+	runningTimeEntry := model.TimeEntry{
+		Pid:   1,
+		Wid:   1,
+		Start: start,
+	}
+
+	timeEntryAPI := &mockTimeEntryAPI{
+		getTimeEntries: func(start, end time.Time) ([]model.TimeEntry, error) {
+			return []model.TimeEntry{
+				runningTimeEntry,
+			}, nil
+		},
+	}
+
+	timeRangeProvider := &mockTimeRangeProvider{
+		getTimeRanges: func(startDate, endDate time.Time) ([]timeRange, error) {
+			return []timeRange{
+				timeRange{start, stop},
+			}, nil
+		},
+	}
+
+	repository := &TimeRecordRepository{
+		timeEntryAPI:      timeEntryAPI,
+		timeRangeProvider: timeRangeProvider,
+		modelConverter: &mockModelConverter{
+			convertTimeEntryToTimeRecord: func(timeEntry model.TimeEntry) (TimeRecord, error) {
+				return TimeRecord{Start: timeEntry.Start, Stop: timeEntry.Stop}, nil
+			},
+		},
+		filter: TimeRecordFilter{IncludeRunning: true},
+	}
+
+	// act
+	records, err := repository.GetTimeRecords(start, stop)
+
+	// assert
+	if len(records) != 1 || !records[0].IsRunning() {
+		t.Fail()
+		t.Logf("GetTimeRecords(%q, %q) should have returned one running time record but returned %#v", start, stop, records)
+	}
+
+	if err != nil {
+		t.Fail()
+		t.Logf("GetTimeRecords(%q, %q) should not have an error but returned this: %s", start, stop, err)
+	}
+}
+
+func Test_GetTimeRecords_IncludeWithoutProject_APIReturnsTimeEntryWithoutProject_TimeRecordIsReturned(t *testing.T) {
+	// arrange
+	start := time.Date(2016, 8, 1, 0, 0, 1, 0, time.UTC)
+	stop := time.Date(2016, 8, 31, 23, 59, 59, 0, time.UTC)
+
+	timeEntryWithoutProject := model.TimeEntry{
+		Pid:   0,
+		Wid:   1,
+		Start: start,
+		Stop:  stop,
+	}
+
+	timeEntryAPI := &mockTimeEntryAPI{
+		getTimeEntries: func(start, end time.Time) ([]model.TimeEntry, error) {
+			return []model.TimeEntry{
+				timeEntryWithoutProject,
+			}, nil
+		},
+	}
+
+	timeRangeProvider := &mockTimeRangeProvider{
+		getTimeRanges: func(startDate, endDate time.Time) ([]timeRange, error) {
+			return []timeRange{
+				timeRange{start, stop},
+			}, nil
+		},
+	}
+
+	repository := &TimeRecordRepository{
+		timeEntryAPI:      timeEntryAPI,
+		timeRangeProvider: timeRangeProvider,
+		modelConverter: &mockModelConverter{
+			convertTimeEntryToTimeRecord: func(timeEntry model.TimeEntry) (TimeRecord, error) {
+				return TimeRecord{Description: "Without project"}, nil
+			},
+		},
+		filter: TimeRecordFilter{IncludeWithoutProject: true},
+	}
+
+	// act
+	records, err := repository.GetTimeRecords(start, stop)
+
+	// assert
+	if len(records) != 1 || records[0].Description != "Without project" {
+		t.Fail()
+		t.Logf("GetTimeRecords(%q, %q) should have returned the time record without project but returned: %#v", start, stop, records)
+	}
+
+	if err != nil {
+		t.Fail()
+		t.Logf("GetTimeRecords(%q, %q) should not have an error but returned this: %s", start, stop, err)
+	}
+}
+
 func Test_GetTimeRecords_APIReturnsValidTimeEntry_ConversionFails_ErrorIsReturned(t *testing.T) {
 	// arrange
 	start := time.Date(2016, 8, 1, 0, 0, 1, 0, time.UTC)
@@ -515,3 +667,224 @@ func Test_GetTimeRecords_APIReturnsValidTimeEntry_ConversionSucceeds_TimeRecordI
 		t.Logf("GetTimeRecords(%q, %q) should not have an error but returned this: %s", start, stop, err)
 	}
 }
+
+func Test_GetTimeRecords_FilterExcludesWorkspaceAndProject_OnlyIncludedTimeEntriesAreConverted(t *testing.T) {
+	// arrange
+	start := time.Date(2016, 8, 1, 0, 0, 1, 0, time.UTC)
+	stop := time.Date(2016, 8, 31, 23, 59, 59, 0, time.UTC)
+
+	timeEntryAPI := &mockTimeEntryAPI{
+		getTimeEntries: func(start, end time.Time) ([]model.TimeEntry, error) {
+			return []model.TimeEntry{
+				{ID: 1, Wid: 1, Pid: 1, Start: start, Stop: stop},
+				{ID: 2, Wid: 1, Pid: 2, Start: start, Stop: stop},
+				{ID: 3, Wid: 2, Pid: 1, Start: start, Stop: stop},
+				{ID: 4, Wid: 1, Pid: 1, Start: start, Stop: stop},
+			}, nil
+		},
+	}
+
+	timeRangeProvider := &mockTimeRangeProvider{
+		getTimeRanges: func(startDate, endDate time.Time) ([]timeRange, error) {
+			return []timeRange{
+				timeRange{start, stop},
+			}, nil
+		},
+	}
+
+	workspaceLookups := 0
+	projectLookups := 0
+	var convertedIDs []int
+	repository := NewFilteredTimeRecordRepository(
+		timeEntryAPI,
+		&mockWorkspacer{
+			getWorkspaceByID: func(workspaceID int) (Workspace, error) {
+				workspaceLookups++
+				return Workspace{ID: workspaceID, Name: fmt.Sprintf("Workspace %d", workspaceID)}, nil
+			},
+		},
+		&mockProjecter{
+			getProjectByID: func(projectID int) (Project, error) {
+				projectLookups++
+				return Project{ID: projectID, Name: fmt.Sprintf("Project %d", projectID)}, nil
+			},
+		},
+		&mockClienter{},
+		TimeRecordFilter{
+			IncludeWorkspace: func(workspaceName string) bool { return workspaceName == "Workspace 1" },
+			IncludeProject:   func(projectName string) bool { return projectName == "Project 1" },
+		},
+	).(*TimeRecordRepository)
+
+	repository.timeRangeProvider = timeRangeProvider
+	repository.modelConverter = &mockModelConverter{
+		convertTimeEntryToTimeRecord: func(timeEntry model.TimeEntry) (TimeRecord, error) {
+			convertedIDs = append(convertedIDs, timeEntry.ID)
+			return TimeRecord{}, nil
+		},
+	}
+
+	// act
+	records, err := repository.GetTimeRecords(start, stop)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("GetTimeRecords(%q, %q) should not have an error but returned this: %s", start, stop, err)
+	}
+
+	if len(records) != 2 || fmt.Sprintf("%v", convertedIDs) != "[1 4]" {
+		t.Fail()
+		t.Logf("GetTimeRecords(%q, %q) should have converted the time entries [1 4] but converted %v", start, stop, convertedIDs)
+	}
+
+	if workspaceLookups != 2 || projectLookups != 2 {
+		t.Fail()
+		t.Logf("GetTimeRecords(%q, %q) should have looked up every workspace and project once but looked up %d workspaces and %d projects", start, stop, workspaceLookups, projectLookups)
+	}
+}
+
+func Test_GetTimeRecords_FilterIsGiven_WorkspaceLookupFails_ErrorIsReturned(t *testing.T) {
+	// arrange
+	start := time.Date(2016, 8, 1, 0, 0, 1, 0, time.UTC)
+	stop := time.Date(2016, 8, 31, 23, 59, 59, 0, time.UTC)
+
+	timeEntryAPI := &mockTimeEntryAPI{
+		getTimeEntries: func(start, end time.Time) ([]model.TimeEntry, error) {
+			return []model.TimeEntry{
+				{ID: 1, Wid: 1, Pid: 1, Start: start, Stop: stop},
+			}, nil
+		},
+	}
+
+	repository := NewFilteredTimeRecordRepository(
+		timeEntryAPI,
+		&mockWorkspacer{
+			getWorkspaceByID: func(workspaceID int) (Workspace, error) {
+				return Workspace{}, fmt.Errorf("Workspace not found")
+			},
+		},
+		&mockProjecter{},
+		&mockClienter{},
+		TimeRecordFilter{
+			IncludeWorkspace: func(workspaceName string) bool { return true },
+		},
+	).(*TimeRecordRepository)
+
+	repository.timeRangeProvider = &mockTimeRangeProvider{
+		getTimeRanges: func(startDate, endDate time.Time) ([]timeRange, error) {
+			return []timeRange{
+				timeRange{start, stop},
+			}, nil
+		},
+	}
+
+	// act
+	_, err := repository.GetTimeRecords(start, stop)
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("GetTimeRecords(%q, %q) should have returned an error because the workspace could not be loaded", start, stop)
+	}
+}
+
+func Test_CreateTimeRecord_ConcurrentCallsForAMissingProject_ProjectIsCreatedOnce(t *testing.T) {
+	// arrange
+	var mutex sync.Mutex
+	var projects []Project
+	createProjectCalls := 0
+
+	repository := &TimeRecordRepository{
+		timeEntryAPI: &mockTimeEntryAPI{
+			createTimeEntry: func(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+				return timeEntry, nil
+			},
+		},
+		modelConverter: &mockModelConverter{
+			convertTimeRecordToTimeEntry: func(timeRecord TimeRecord) (model.TimeEntry, error) {
+				return model.TimeEntry{}, nil
+			},
+		},
+		projects: &mockProjecter{
+			getProjectByName: func(projectName, workspaceName, clientName string) (Project, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				for _, project := range projects {
+					if project.Name == projectName {
+						return project, nil
+					}
+				}
+
+				return Project{}, fmt.Errorf("Project %q was not found", projectName)
+			},
+			createProject: func(projectName, workspaceName, clientName string) (Project, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				createProjectCalls++
+				project := Project{ID: createProjectCalls, Name: projectName}
+				projects = append(projects, project)
+				return project, nil
+			},
+		},
+	}
+
+	// act
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			repository.CreateTimeRecord(TimeRecord{WorkspaceName: "Workspace", ProjectName: "New Project"})
+		}()
+	}
+
+	waitGroup.Wait()
+
+	// assert
+	if createProjectCalls != 1 {
+		t.Fail()
+		t.Logf("CreateTimeRecord should have created the missing project once but created it %d times", createProjectCalls)
+	}
+}
+
+func Test_CreateTimeRecord_NoProjectName_NoProjectIsLookedUpOrCreated(t *testing.T) {
+	// arrange
+	timeEntryAPI := &mockTimeEntryAPI{
+		createTimeEntry: func(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+			return timeEntry, nil
+		},
+	}
+
+	repository := &TimeRecordRepository{
+		timeEntryAPI: timeEntryAPI,
+		modelConverter: &mockModelConverter{
+			convertTimeRecordToTimeEntry: func(timeRecord TimeRecord) (model.TimeEntry, error) {
+				return model.TimeEntry{}, nil
+			},
+		},
+		projects: &mockProjecter{
+			getProjectByName: func(projectName, workspaceName, clientName string) (Project, error) {
+				t.Fail()
+				t.Logf("CreateTimeRecord should not look up a project for a time record without project")
+				return Project{}, nil
+			},
+		},
+	}
+
+	inputTimeRecord := TimeRecord{
+		WorkspaceName: "Workspace",
+		Description:   "Yada Yada",
+	}
+
+	// act
+	_, err := repository.CreateTimeRecord(inputTimeRecord)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("CreateTimeRecord(%#v) should not have returned an error but returned this instead: %s", inputTimeRecord, err)
+	}
+}
@@ -0,0 +1,119 @@
+package toggl
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/andreaskoch/togglapi/model"
+)
+
+// A Rollbacker interface provides a function for undoing changes.
+type Rollbacker interface {
+	// Rollback deletes everything that has been created since the last rollback.
+	// Returns an error if one or more objects could not be deleted.
+	Rollback() error
+}
+
+// NewTransaction returns a Toggl API that keeps track of all time entries, projects and clients
+// created through it so that they can be deleted again.
+func NewTransaction(api API) *Transaction {
+	return &Transaction{
+		API: api,
+	}
+}
+
+// Transaction is a Toggl API that records the IDs of all created objects.
+type Transaction struct {
+	API
+
+	// mutex makes the transaction safe for concurrent use
+	mutex sync.Mutex
+
+	timeEntryIDs []int
+	projectIDs   []int
+	clientIDs    []int
+}
+
+// CreateTimeEntry creates a new time entry and records its ID.
+func (transaction *Transaction) CreateTimeEntry(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+	createdTimeEntry, err := transaction.API.CreateTimeEntry(timeEntry)
+	if err != nil {
+		return createdTimeEntry, err
+	}
+
+	transaction.mutex.Lock()
+	defer transaction.mutex.Unlock()
+
+	transaction.timeEntryIDs = append(transaction.timeEntryIDs, createdTimeEntry.ID)
+	return createdTimeEntry, nil
+}
+
+// CreateProject creates a new project and records its ID.
+func (transaction *Transaction) CreateProject(project model.Project) (model.Project, error) {
+	createdProject, err := transaction.API.CreateProject(project)
+	if err != nil {
+		return createdProject, err
+	}
+
+	transaction.mutex.Lock()
+	defer transaction.mutex.Unlock()
+
+	transaction.projectIDs = append(transaction.projectIDs, createdProject.ID)
+	return createdProject, nil
+}
+
+// CreateClient creates a new client and records its ID.
+func (transaction *Transaction) CreateClient(client model.Client) (model.Client, error) {
+	createdClient, err := transaction.API.CreateClient(client)
+	if err != nil {
+		return createdClient, err
+	}
+
+	transaction.mutex.Lock()
+	defer transaction.mutex.Unlock()
+
+	transaction.clientIDs = append(transaction.clientIDs, createdClient.ID)
+	return createdClient, nil
+}
+
+// Rollback deletes all created time entries, projects and clients in reverse order.
+// Time entries are deleted first because projects can only be removed once they are no longer used.
+// Objects that cannot be deleted are skipped and reported in the returned error.
+func (transaction *Transaction) Rollback() error {
+	transaction.mutex.Lock()
+	defer transaction.mutex.Unlock()
+
+	var failures []string
+
+	for index := len(transaction.timeEntryIDs) - 1; index >= 0; index-- {
+		timeEntryID := transaction.timeEntryIDs[index]
+		if err := transaction.API.DeleteTimeEntry(timeEntryID); err != nil {
+			failures = append(failures, fmt.Sprintf("time entry %d (%s)", timeEntryID, err.Error()))
+		}
+	}
+
+	for index := len(transaction.projectIDs) - 1; index >= 0; index-- {
+		projectID := transaction.projectIDs[index]
+		if err := transaction.API.DeleteProject(projectID); err != nil {
+			failures = append(failures, fmt.Sprintf("project %d (%s)", projectID, err.Error()))
+		}
+	}
+
+	for index := len(transaction.clientIDs) - 1; index >= 0; index-- {
+		clientID := transaction.clientIDs[index]
+		if err := transaction.API.DeleteClient(clientID); err != nil {
+			failures = append(failures, fmt.Sprintf("client %d (%s)", clientID, err.Error()))
+		}
+	}
+
+	transaction.timeEntryIDs = nil
+	transaction.projectIDs = nil
+	transaction.clientIDs = nil
+
+	if len(failures) > 0 {
+		return fmt.Errorf("Failed to delete %s", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package toggl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/andreaskoch/togglapi/model"
+)
+
+func Test_Transaction_Rollback_CreatedObjectsAreDeletedInReverseOrder(t *testing.T) {
+	// arrange
+	var deleted []string
+
+	transaction := NewTransaction(&mockTogglAPI{
+		mockTimeEntryAPI: mockTimeEntryAPI{
+			createTimeEntry: func(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+				return timeEntry, nil
+			},
+			deleteTimeEntry: func(timeEntryID int) error {
+				deleted = append(deleted, fmt.Sprintf("time entry %d", timeEntryID))
+				return nil
+			},
+		},
+		mockProjectAPI: mockProjectAPI{
+			createProject: func(project model.Project) (model.Project, error) {
+				return project, nil
+			},
+			deleteProject: func(projectID int) error {
+				deleted = append(deleted, fmt.Sprintf("project %d", projectID))
+				return nil
+			},
+		},
+		mockClientAPI: mockClientAPI{
+			createClient: func(client model.Client) (model.Client, error) {
+				return client, nil
+			},
+			deleteClient: func(clientID int) error {
+				deleted = append(deleted, fmt.Sprintf("client %d", clientID))
+				return nil
+			},
+		},
+	})
+
+	transaction.CreateClient(model.Client{ID: 1})
+	transaction.CreateProject(model.Project{ID: 2})
+	transaction.CreateTimeEntry(model.TimeEntry{ID: 3})
+	transaction.CreateTimeEntry(model.TimeEntry{ID: 4})
+
+	// act
+	err := transaction.Rollback()
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Rollback should not have returned an error but returned: %s", err.Error())
+	}
+
+	expected := "[time entry 4 time entry 3 project 2 client 1]"
+	if fmt.Sprintf("%v", deleted) != expected {
+		t.Fail()
+		t.Logf("Rollback should have deleted %s but deleted %v", expected, deleted)
+	}
+}
+
+func Test_Transaction_Rollback_DeleteFails_RemainingObjectsAreDeletedAndErrorIsReturned(t *testing.T) {
+	// arrange
+	var deletedProjects []int
+
+	transaction := NewTransaction(&mockTogglAPI{
+		mockTimeEntryAPI: mockTimeEntryAPI{
+			createTimeEntry: func(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+				return timeEntry, nil
+			},
+			deleteTimeEntry: func(timeEntryID int) error {
+				return fmt.Errorf("Time entry is locked")
+			},
+		},
+		mockProjectAPI: mockProjectAPI{
+			createProject: func(project model.Project) (model.Project, error) {
+				return project, nil
+			},
+			deleteProject: func(projectID int) error {
+				deletedProjects = append(deletedProjects, projectID)
+				return nil
+			},
+		},
+	})
+
+	transaction.CreateProject(model.Project{ID: 2})
+	transaction.CreateTimeEntry(model.TimeEntry{ID: 3})
+
+	// act
+	err := transaction.Rollback()
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("Rollback should have returned an error because the time entry could not be deleted")
+	}
+
+	if len(deletedProjects) != 1 || deletedProjects[0] != 2 {
+		t.Fail()
+		t.Logf("Rollback should have deleted project 2 but deleted %v", deletedProjects)
+	}
+}
+
+func Test_Transaction_CreateFails_NothingIsRolledBack(t *testing.T) {
+	// arrange
+	deleteCalls := 0
+
+	transaction := NewTransaction(&mockTogglAPI{
+		mockTimeEntryAPI: mockTimeEntryAPI{
+			createTimeEntry: func(timeEntry model.TimeEntry) (model.TimeEntry, error) {
+				return model.TimeEntry{}, fmt.Errorf("Creation failed")
+			},
+			deleteTimeEntry: func(timeEntryID int) error {
+				deleteCalls++
+				return nil
+			},
+		},
+	})
+
+	transaction.CreateTimeEntry(model.TimeEntry{ID: 3})
+
+	// act
+	transaction.Rollback()
+
+	// assert
+	if deleteCalls != 0 {
+		t.Fail()
+		t.Logf("Rollback should not have deleted anything but called delete %d times", deleteCalls)
+	}
+}
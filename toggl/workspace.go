@@ -2,6 +2,7 @@ package toggl
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/andreaskoch/togglapi/model"
 )
@@ -39,7 +40,10 @@ func NewWorkspaceRepository(workspaceAPI model.WorkspaceAPI) Workspacer {
 // WorkspaceRepository provides read access to the Toggl workspaces.
 // Write is unfortunately not supported by the Toggl API.
 type WorkspaceRepository struct {
-	workspaceAPI    model.WorkspaceAPI
+	workspaceAPI model.WorkspaceAPI
+
+	// cacheMutex makes the workspaces cache safe for concurrent use
+	cacheMutex      sync.Mutex
 	workspacesCache []Workspace
 }
 
@@ -50,6 +54,9 @@ func (repository *WorkspaceRepository) CreateWorkspace(name string) (Workspace,
 
 // GetWorkspaces returns all available workspaces.
 func (repository *WorkspaceRepository) GetWorkspaces() ([]Workspace, error) {
+	repository.cacheMutex.Lock()
+	defer repository.cacheMutex.Unlock()
+
 	if repository.workspacesCache != nil {
 		return repository.workspacesCache, nil
 	}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// validationFormatJSON selects the JSON output of the validation errors.
+const validationFormatJSON = "json"
+
+// The CSVValidator interface checks CSV time records without sending them to Toggl.
+type CSVValidator interface {
+	// Validate checks all time records supplied via the given input and writes the problems to the given writer.
+	// Returns an error if the input is not valid.
+	Validate(input io.Reader, writer io.Writer) error
+}
+
+// TogglCSVValidator checks CSV time records using the CSV mapper of the import.
+type TogglCSVValidator struct {
+	csvMapper TimeRecordMapper
+
+	// format defines how the validation errors are printed ("table" or "json")
+	format string
+}
+
+// Validate checks all time records supplied via the given input and writes the problems to the given writer.
+// Returns an error if the input is not valid.
+func (validator *TogglCSVValidator) Validate(input io.Reader, writer io.Writer) error {
+
+	rows, lines, csvError := readCSVRows(input)
+	if csvError != nil {
+		return fmt.Errorf("Failed to read time records from CSV: %s", csvError.Error())
+	}
+
+	timeRecords, timeRecordsError := validator.csvMapper.GetTimeRecords(rows, lines)
+
+	validationErrors, isValidationError := timeRecordsError.(ValidationErrors)
+	if timeRecordsError != nil && !isValidationError {
+		return timeRecordsError
+	}
+
+	if validator.format == validationFormatJSON {
+		if jsonError := writeValidationJSON(writer, validationErrors); jsonError != nil {
+			return jsonError
+		}
+	} else if len(validationErrors) == 0 {
+		fmt.Fprintf(writer, "All %d time records are valid.\n", len(timeRecords))
+	} else {
+		writeValidationTable(writer, validationErrors)
+	}
+
+	if len(validationErrors) > 0 {
+		return fmt.Errorf("Found %d problem(s) in the input", len(validationErrors))
+	}
+
+	return nil
+}
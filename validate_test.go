@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/andreaskoch/togglapi/date"
+)
+
+func Test_Validate_ValidInput_NoErrorIsReturned(t *testing.T) {
+	// arrange
+	validator := TogglCSVValidator{
+		csvMapper: NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+	}
+
+	input := `Start,Stop,Workspace Name,Project Name,Client Name,Tag(s),Description
+2015-03-26T08:00:00+01:00,2015-03-26T11:30:00+01:00,Workspace,Project XY,Client X,,Some stuff`
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	err := validator.Validate(strings.NewReader(input), &outputBuffer)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("Validate should not return an error for valid input but returned: %s", err.Error())
+	}
+
+	if !strings.Contains(outputBuffer.String(), "All 1 time records are valid") {
+		t.Fail()
+		t.Logf("Validate should report that the input is valid but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_Validate_InvalidRows_AllProblemsAreWritten(t *testing.T) {
+	// arrange
+	validator := TogglCSVValidator{
+		csvMapper: NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+	}
+
+	input := `Start,Stop,Workspace Name,Project Name,Client Name,Tag(s),Description
+Invalid Date,2015-03-26T11:30:00+01:00,Workspace,Project XY,Client X,,Some stuff
+2015-03-27T08:00:00+01:00,Workspace`
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	err := validator.Validate(strings.NewReader(input), &outputBuffer)
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "Found 2 problem(s)") {
+		t.Fail()
+		t.Logf("Validate should return an error with the number of problems but returned: %v", err)
+	}
+
+	if !strings.Contains(outputBuffer.String(), "Invalid Date") || !strings.Contains(outputBuffer.String(), "Wrong number of values") {
+		t.Fail()
+		t.Logf("Validate should have written all problems but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_Validate_JSONFormat_ProblemsAreWrittenAsJSON(t *testing.T) {
+	// arrange
+	validator := TogglCSVValidator{
+		csvMapper: NewCSVTimeRecordMapper(date.NewISO8601Formatter()),
+		format:    validationFormatJSON,
+	}
+
+	input := `Invalid Date,2015-03-26T11:30:00+01:00,Workspace,Project XY,Client X,,Some stuff`
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	validator.Validate(strings.NewReader(input), &outputBuffer)
+
+	// assert
+	if !strings.Contains(outputBuffer.String(), `"line": 1`) || !strings.Contains(outputBuffer.String(), `"column": "Start"`) {
+		t.Fail()
+		t.Logf("Validate should have written the problems as JSON but wrote: %s", outputBuffer.String())
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// maxValidationValueLength defines the number of characters after which values are cut off in the validation table.
+const maxValidationValueLength = 40
+
+// ValidationError describes a single problem of a CSV row.
+type ValidationError struct {
+	// Line contains the number of the CSV row, starting with 1 for the first row of the input.
+	Line int `json:"line"`
+
+	// Column contains the name of the affected column (empty if the whole row is affected).
+	Column string `json:"column"`
+
+	// Value contains the invalid value.
+	Value string `json:"value"`
+
+	// Reason describes why the value is invalid.
+	Reason string `json:"reason"`
+}
+
+// ValidationErrors contains all problems found in the input.
+type ValidationErrors []ValidationError
+
+// Error returns a table of all validation errors.
+func (validationErrors ValidationErrors) Error() string {
+	var table bytes.Buffer
+	fmt.Fprintf(&table, "Found %d problem(s) in the input:\n", len(validationErrors))
+	writeValidationTable(&table, validationErrors)
+
+	return strings.TrimSuffix(table.String(), "\n")
+}
+
+// writeValidationTable writes the given validation errors as a human-readable table.
+func writeValidationTable(writer io.Writer, validationErrors ValidationErrors) {
+	tableWriter := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "Line\tColumn\tValue\tReason")
+
+	for _, validationError := range validationErrors {
+		column := validationError.Column
+		if column == "" {
+			column = "-"
+		}
+
+		fmt.Fprintf(tableWriter, "%d\t%s\t%q\t%s\n", validationError.Line, column, shorten(validationError.Value, maxValidationValueLength), validationError.Reason)
+	}
+
+	tableWriter.Flush()
+}
+
+// writeValidationJSON writes the given validation errors as a JSON array.
+func writeValidationJSON(writer io.Writer, validationErrors ValidationErrors) error {
+	if validationErrors == nil {
+		validationErrors = ValidationErrors{}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(validationErrors)
+}
+
+// shorten cuts off the given text after the given number of characters.
+func shorten(text string, maxLength int) string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+
+	return string([]rune(text)[:maxLength]) + "..."
+}
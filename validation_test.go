@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_writeValidationTable_AllProblemsArePrinted(t *testing.T) {
+	// arrange
+	validationErrors := ValidationErrors{
+		ValidationError{Line: 2, Column: "Start", Value: "Invalid Date", Reason: "Cannot parse the start date"},
+		ValidationError{Line: 5, Value: "a,b", Reason: "Wrong number of values in the given row"},
+	}
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	writeValidationTable(&outputBuffer, validationErrors)
+
+	// assert
+	lines := strings.Split(strings.TrimSpace(outputBuffer.String()), "\n")
+	if len(lines) != 3 {
+		t.Fail()
+		t.Logf("writeValidationTable should have written a header and two rows but wrote: %s", outputBuffer.String())
+		return
+	}
+
+	if !strings.Contains(lines[1], `"Invalid Date"`) || !strings.Contains(lines[1], "Cannot parse the start date") {
+		t.Fail()
+		t.Logf("writeValidationTable should have printed the value and the reason but printed: %s", lines[1])
+	}
+}
+
+func Test_writeValidationTable_LongValuesAreShortened(t *testing.T) {
+	// arrange
+	validationErrors := ValidationErrors{
+		ValidationError{Line: 2, Column: "Description", Value: strings.Repeat("a", 3001), Reason: "Too long"},
+	}
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	writeValidationTable(&outputBuffer, validationErrors)
+
+	// assert
+	if strings.Contains(outputBuffer.String(), strings.Repeat("a", maxValidationValueLength+1)) {
+		t.Fail()
+		t.Logf("writeValidationTable should have shortened the value but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_writeValidationJSON_ProblemsAreWrittenAsJSONArray(t *testing.T) {
+	// arrange
+	validationErrors := ValidationErrors{
+		ValidationError{Line: 2, Column: "Start", Value: "Invalid Date", Reason: "Cannot parse the start date"},
+	}
+
+	var outputBuffer bytes.Buffer
+
+	// act
+	writeValidationJSON(&outputBuffer, validationErrors)
+
+	// assert
+	var decoded []ValidationError
+	if err := json.Unmarshal(outputBuffer.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0] != validationErrors[0] {
+		t.Fail()
+		t.Logf("writeValidationJSON should have written the problems as JSON but wrote: %s", outputBuffer.String())
+	}
+}
+
+func Test_writeValidationJSON_NoProblems_EmptyArrayIsWritten(t *testing.T) {
+	// arrange
+	var outputBuffer bytes.Buffer
+
+	// act
+	writeValidationJSON(&outputBuffer, nil)
+
+	// assert
+	if strings.TrimSpace(outputBuffer.String()) != "[]" {
+		t.Fail()
+		t.Logf("writeValidationJSON should have written an empty array but wrote: %s", outputBuffer.String())
+	}
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreaskoch/togglcsv/toggl"
+)
+
+// xlsxSheetName contains the name of the worksheet written by the export.
+const xlsxSheetName = "Time Records"
+
+// The cell styles defined in xlsxStyles.
+const (
+	xlsxStyleDate     = 1
+	xlsxStyleHeadline = 2
+)
+
+// xlsxDateLayout defines how dates read from Excel date cells are passed to the CSV mapper.
+const xlsxDateLayout = "2006-01-02T15:04:05-07:00"
+
+// xlsxEpoch is day zero of the Excel date system (the 1900 leap year bug is already included).
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxColumnWidths contains the widths of the exported columns (in characters).
+var xlsxColumnWidths = map[string]int{
+	columnStart:         20,
+	columnStop:          20,
+	columnDuration:      10,
+	columnWorkspaceName: 20,
+	columnProjectName:   25,
+	columnClientName:    20,
+	columnTags:          25,
+	columnDescription:   50,
+	columnBillable:      10,
+}
+
+// The static parts of an exported workbook.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+
+	xlsxRootRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+	xlsxWorkbookRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + xlsxSheetName + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	// xlsxStyles defines the default style (0), a date-time style (1) and a bold headline style (2)
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`
+)
+
+// newXLSXTimeRecordWriter creates a TimeRecordWriter that writes an Excel workbook with a single worksheet.
+// The worksheet has the columns of the given CSV mapper, a frozen headline and
+// Excel date cells for the start and stop dates in the given time zone (default: UTC).
+// The rows are written one by one; the workbook is complete after Close.
+func newXLSXTimeRecordWriter(writer io.Writer, csvMapper TimeRecordMapper, location *time.Location) TimeRecordWriter {
+	if location == nil {
+		location = time.UTC
+	}
+
+	xlsxWriter := &xlsxTimeRecordWriter{
+		zipWriter: zip.NewWriter(writer),
+		csvMapper: csvMapper,
+		location:  location,
+	}
+
+	xlsxWriter.err = xlsxWriter.writeHeader()
+	return xlsxWriter
+}
+
+// xlsxTimeRecordWriter writes time records as the rows of an Excel worksheet.
+type xlsxTimeRecordWriter struct {
+	zipWriter *zip.Writer
+	csvMapper TimeRecordMapper
+	location  *time.Location
+
+	// sheet receives the rows of the worksheet
+	sheet io.Writer
+
+	// rows contains the number of written rows including the headline
+	rows int
+
+	// err contains the first error; no further data is written after an error
+	err error
+}
+
+// writeHeader writes all parts of the workbook before the rows of the worksheet.
+func (writer *xlsxTimeRecordWriter) writeHeader() error {
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRelationships},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRelationships},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for _, part := range parts {
+		partWriter, createError := writer.zipWriter.Create(part.name)
+		if createError != nil {
+			return createError
+		}
+
+		if _, writeError := io.WriteString(partWriter, part.content); writeError != nil {
+			return writeError
+		}
+	}
+
+	sheet, sheetError := writer.zipWriter.Create("xl/worksheets/sheet1.xml")
+	if sheetError != nil {
+		return sheetError
+	}
+
+	writer.sheet = sheet
+
+	// freeze the headline and set the column widths
+	var header bytes.Buffer
+	header.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	header.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	header.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	header.WriteString(`<cols>`)
+	for index, columnName := range writer.csvMapper.GetColumnNames() {
+		width := xlsxColumnWidths[columnName]
+		if width == 0 {
+			width = 15
+		}
+
+		fmt.Fprintf(&header, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, index+1, index+1, width)
+	}
+
+	header.WriteString(`</cols><sheetData>`)
+	if _, writeError := writer.sheet.Write(header.Bytes()); writeError != nil {
+		return writeError
+	}
+
+	// write the headline
+	var headline []xlsxOutputCell
+	for _, columnName := range writer.csvMapper.GetColumnNames() {
+		headline = append(headline, xlsxOutputCell{text: columnName, style: xlsxStyleHeadline})
+	}
+
+	return writer.writeRow(headline)
+}
+
+// Write writes the given time record as a row of the worksheet.
+// The start and stop dates are written as Excel dates, all other values as text.
+func (writer *xlsxTimeRecordWriter) Write(timeRecord toggl.TimeRecord) error {
+	if writer.err != nil {
+		return writer.err
+	}
+
+	row := writer.csvMapper.GetRow(timeRecord)
+
+	var cells []xlsxOutputCell
+	for index, columnName := range writer.csvMapper.GetColumnNames() {
+		cell := xlsxOutputCell{text: row[index]}
+
+		switch columnName {
+		case columnStart:
+			cell = writer.getDateCell(timeRecord.Start)
+
+		case columnStop:
+			if !timeRecord.IsRunning() {
+				cell = writer.getDateCell(timeRecord.Stop)
+			}
+		}
+
+		cells = append(cells, cell)
+	}
+
+	writer.err = writer.writeRow(cells)
+	return writer.err
+}
+
+// Close writes the end of the worksheet and the directory of the workbook.
+func (writer *xlsxTimeRecordWriter) Close() error {
+	if writer.err != nil {
+		return writer.err
+	}
+
+	if _, writeError := io.WriteString(writer.sheet, `</sheetData></worksheet>`); writeError != nil {
+		return writeError
+	}
+
+	return writer.zipWriter.Close()
+}
+
+// getDateCell returns a date cell with the wall clock time of the given date in the time zone of the writer.
+func (writer *xlsxTimeRecordWriter) getDateCell(date time.Time) xlsxOutputCell {
+	local := date.In(writer.location)
+	wallClock := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+
+	return xlsxOutputCell{
+		number:   wallClock.Sub(xlsxEpoch).Hours() / 24,
+		isNumber: true,
+		style:    xlsxStyleDate,
+	}
+}
+
+// writeRow writes the given cells as the next row of the worksheet. Empty text cells are left out.
+func (writer *xlsxTimeRecordWriter) writeRow(cells []xlsxOutputCell) error {
+	writer.rows++
+
+	var row bytes.Buffer
+	fmt.Fprintf(&row, `<row r="%d">`, writer.rows)
+	for index, cell := range cells {
+		reference := getXLSXColumnName(index) + strconv.Itoa(writer.rows)
+
+		switch {
+		case cell.isNumber:
+			fmt.Fprintf(&row, `<c r="%s" s="%d"><v>%s</v></c>`, reference, cell.style, strconv.FormatFloat(cell.number, 'f', -1, 64))
+
+		case cell.text != "":
+			fmt.Fprintf(&row, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, reference, cell.style)
+			xml.EscapeText(&row, []byte(cell.text))
+			row.WriteString(`</t></is></c>`)
+		}
+	}
+
+	row.WriteString("</row>")
+
+	_, writeError := writer.sheet.Write(row.Bytes())
+	return writeError
+}
+
+// xlsxOutputCell contains the value and the style of a cell that is written.
+type xlsxOutputCell struct {
+	text     string
+	number   float64
+	isNumber bool
+	style    int
+}
+
+// getXLSXColumnName returns the name of the column with the given index (0: A, 25: Z, 26: AA).
+func getXLSXColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
+
+// getXLSXColumnIndex returns the index of the column of the given cell reference (A1: 0, AA3: 26)
+// or -1 if the reference doesn't start with a column name.
+func getXLSXColumnIndex(reference string) int {
+	index := 0
+	letters := 0
+	for _, character := range strings.ToUpper(reference) {
+		if character < 'A' || character > 'Z' {
+			break
+		}
+
+		index = index*26 + int(character-'A'+1)
+		letters++
+	}
+
+	if letters == 0 {
+		return -1
+	}
+
+	return index - 1
+}
+
+// The XML elements of a workbook that are read by the import.
+type (
+	xlsxInputWorkbook struct {
+		Sheets []struct {
+			Name           string `xml:"name,attr"`
+			RelationshipID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+
+	xlsxInputRelationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	xlsxInputSharedStrings struct {
+		Items []xlsxInputText `xml:"si"`
+	}
+
+	// xlsxInputText contains either a plain text or the runs of a rich text
+	xlsxInputText struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	}
+
+	xlsxInputWorksheet struct {
+		Rows []struct {
+			Number int             `xml:"r,attr"`
+			Cells  []xlsxInputCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+
+	xlsxInputCell struct {
+		Reference    string        `xml:"r,attr"`
+		Type         string        `xml:"t,attr"`
+		Value        string        `xml:"v"`
+		InlineString xlsxInputText `xml:"is"`
+	}
+)
+
+// String returns the plain text or the concatenated runs of a rich text.
+func (text xlsxInputText) String() string {
+	if len(text.Runs) == 0 {
+		return text.Text
+	}
+
+	var result strings.Builder
+	for _, run := range text.Runs {
+		result.WriteString(run.Text)
+	}
+
+	return result.String()
+}
+
+// readXLSXRows reads the rows of the worksheet with the given name (default: the first worksheet)
+// from the given Excel workbook. Excel dates in the start and stop columns are read in the given time zone
+// (default: UTC) and numbers in the duration column as a fraction of a day. The date columns are identified
+// by the headline or, without a headline, by the given column names.
+// Excel leaves out empty cells, so the rows are padded with empty values to the width of the headline.
+// Empty rows are skipped. Returns the rows and their row numbers in the worksheet.
+func readXLSXRows(input io.Reader, sheetName string, location *time.Location, columnNames []string) ([][]string, []int, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	data, readError := ioutil.ReadAll(input)
+	if readError != nil {
+		return nil, nil, readError
+	}
+
+	archive, archiveError := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if archiveError != nil {
+		return nil, nil, fmt.Errorf("The input is not an Excel workbook: %s", archiveError.Error())
+	}
+
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, sheetError := getXLSXSheetPath(files, sheetName)
+	if sheetError != nil {
+		return nil, nil, sheetError
+	}
+
+	var sharedStrings xlsxInputSharedStrings
+	if _, hasSharedStrings := files["xl/sharedStrings.xml"]; hasSharedStrings {
+		if xmlError := readXLSXPart(files, "xl/sharedStrings.xml", &sharedStrings); xmlError != nil {
+			return nil, nil, xmlError
+		}
+	}
+
+	var worksheet xlsxInputWorksheet
+	if xmlError := readXLSXPart(files, sheetPath, &worksheet); xmlError != nil {
+		return nil, nil, xmlError
+	}
+
+	var rows [][]string
+	var lines []int
+	var columnTypes []string
+	for rowIndex, inputRow := range worksheet.Rows {
+		line := inputRow.Number
+		if line == 0 {
+			line = rowIndex + 1
+		}
+
+		var row []string
+		for position, cell := range inputRow.Cells {
+			column := getXLSXColumnIndex(cell.Reference)
+			if column < 0 {
+				column = position
+			}
+
+			for len(row) <= column {
+				row = append(row, "")
+			}
+
+			row[column] = getXLSXCellValue(cell, sharedStrings)
+		}
+
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		// identify the date columns by the headline or the given column names
+		if columnTypes == nil {
+			columnTypes = columnNames
+			if isTimeRecordHeadline(row) {
+				columnTypes = make([]string, len(row))
+				for index, value := range row {
+					columnTypes[index] = getKnownColumnName(value)
+				}
+
+				rows = append(rows, row)
+				lines = append(lines, line)
+				continue
+			}
+		}
+
+		for len(row) < len(columnTypes) {
+			row = append(row, "")
+		}
+
+		for index, cell := range inputRow.Cells {
+			column := getXLSXColumnIndex(cell.Reference)
+			if column < 0 {
+				column = index
+			}
+
+			if column >= len(columnTypes) || (cell.Type != "" && cell.Type != "n") {
+				continue
+			}
+
+			row[column] = convertXLSXNumber(row[column], columnTypes[column], location)
+		}
+
+		rows = append(rows, row)
+		lines = append(lines, line)
+	}
+
+	return rows, lines, nil
+}
+
+// getXLSXSheetPath returns the path of the worksheet with the given name or of the first worksheet if no name is given.
+func getXLSXSheetPath(files map[string]*zip.File, sheetName string) (string, error) {
+	var workbook xlsxInputWorkbook
+	if xmlError := readXLSXPart(files, "xl/workbook.xml", &workbook); xmlError != nil {
+		return "", xmlError
+	}
+
+	var relationships xlsxInputRelationships
+	if xmlError := readXLSXPart(files, "xl/_rels/workbook.xml.rels", &relationships); xmlError != nil {
+		return "", xmlError
+	}
+
+	var sheetNames []string
+	for _, sheet := range workbook.Sheets {
+		sheetNames = append(sheetNames, sheet.Name)
+		if sheetName != "" && sheet.Name != sheetName {
+			continue
+		}
+
+		for _, relationship := range relationships.Relationships {
+			if relationship.ID != sheet.RelationshipID {
+				continue
+			}
+
+			if strings.HasPrefix(relationship.Target, "/") {
+				return strings.TrimPrefix(relationship.Target, "/"), nil
+			}
+
+			return path.Join("xl", relationship.Target), nil
+		}
+
+		return "", fmt.Errorf("The workbook has no data for the worksheet %q", sheet.Name)
+	}
+
+	if sheetName == "" {
+		return "", fmt.Errorf("The workbook has no worksheets")
+	}
+
+	return "", fmt.Errorf("The workbook has no worksheet %q. Available worksheets: %s", sheetName, strings.Join(sheetNames, ", "))
+}
+
+// readXLSXPart decodes the XML file with the given name of the workbook into the given value.
+func readXLSXPart(files map[string]*zip.File, name string, value interface{}) error {
+	file, exists := files[name]
+	if !exists {
+		return fmt.Errorf("The workbook has no %q", name)
+	}
+
+	reader, openError := file.Open()
+	if openError != nil {
+		return fmt.Errorf("Failed to open %q of the workbook: %s", name, openError.Error())
+	}
+
+	defer reader.Close()
+
+	if decodeError := xml.NewDecoder(reader).Decode(value); decodeError != nil {
+		return fmt.Errorf("Failed to read %q of the workbook: %s", name, decodeError.Error())
+	}
+
+	return nil
+}
+
+// getXLSXCellValue returns the text of the given cell.
+// Numbers are returned unchanged and booleans as 1 or 0.
+func getXLSXCellValue(cell xlsxInputCell, sharedStrings xlsxInputSharedStrings) string {
+	switch cell.Type {
+	case "s":
+		index, parseError := strconv.Atoi(cell.Value)
+		if parseError != nil || index < 0 || index >= len(sharedStrings.Items) {
+			return cell.Value
+		}
+
+		return sharedStrings.Items[index].String()
+
+	case "inlineStr":
+		return cell.InlineString.String()
+	}
+
+	return cell.Value
+}
+
+// convertXLSXNumber converts the given number of a date or duration column into a value the CSV mapper can read.
+// Excel dates are read as the wall clock time of the given time zone.
+// Other numbers and values that are not numbers are returned unchanged.
+func convertXLSXNumber(value, columnName string, location *time.Location) string {
+	number, parseError := strconv.ParseFloat(value, 64)
+	if parseError != nil {
+		return value
+	}
+
+	// excel stores dates and durations in days
+	seconds := time.Duration(math.Round(number*24*60*60)) * time.Second
+
+	switch columnName {
+	case columnStart, columnStop:
+		wallClock := xlsxEpoch.Add(seconds)
+		date := time.Date(wallClock.Year(), wallClock.Month(), wallClock.Day(), wallClock.Hour(), wallClock.Minute(), wallClock.Second(), 0, location)
+		return date.Format(xlsxDateLayout)
+
+	case columnDuration:
+		return seconds.String()
+	}
+
+	return value
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreaskoch/togglapi/date"
+)
+
+// getXLSXTestWorkbook returns an Excel workbook with the given worksheets (name and sheet data XML)
+// and the given shared strings.
+func getXLSXTestWorkbook(sheets map[string]string, sheetNames []string, sharedStrings []string) []byte {
+	var output bytes.Buffer
+	archive := zip.NewWriter(&output)
+
+	addFile := func(name, content string) {
+		fileWriter, _ := archive.Create(name)
+		fileWriter.Write([]byte(content))
+	}
+
+	var workbookSheets, relationships string
+	for index, sheetName := range sheetNames {
+		workbookSheets += fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, sheetName, index+1, index+1)
+		relationships += fmt.Sprintf(`<Relationship Id="rId%d" Target="worksheets/sheet%d.xml"/>`, index+1, index+1)
+		addFile(fmt.Sprintf("xl/worksheets/sheet%d.xml", index+1), `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`+sheets[sheetName]+`</sheetData></worksheet>`)
+	}
+
+	addFile("xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`+workbookSheets+`</sheets></workbook>`)
+	addFile("xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+relationships+`</Relationships>`)
+
+	var items string
+	for _, sharedString := range sharedStrings {
+		items += "<si><t>" + sharedString + "</t></si>"
+	}
+
+	addFile("xl/sharedStrings.xml", `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+items+`</sst>`)
+
+	archive.Close()
+	return output.Bytes()
+}
+
+func Test_xlsxTimeRecordWriter_TimeRecordsAreWritten_TimeRecordsCanBeImported(t *testing.T) {
+	// arrange
+	location, _ := time.LoadLocation("Europe/Berlin")
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+
+	var output bytes.Buffer
+	writer := newXLSXTimeRecordWriter(&output, csvMapper, location)
+
+	timeRecord := getJSONTestTimeRecord("Record <1> & more")
+	timeRecord.Tags = []string{"design", "review"}
+
+	// act
+	writer.Write(timeRecord)
+	writer.Write(getJSONTestTimeRecord("Record 2"))
+	closeError := writer.Close()
+
+	// assert
+	if closeError != nil {
+		t.Fail()
+		t.Logf("Close should not return an error but returned: %s", closeError.Error())
+	}
+
+	rows, _, readError := readXLSXRows(bytes.NewReader(output.Bytes()), "", location, csvMapper.GetColumnNames())
+	if readError != nil {
+		t.Fail()
+		t.Logf("readXLSXRows should not return an error but returned: %s", readError.Error())
+		return
+	}
+
+	timeRecords, mapError := csvMapper.GetTimeRecords(rows[1:], nil)
+	if mapError != nil {
+		t.Fail()
+		t.Logf("The rows should be valid time records but returned: %s", mapError.Error())
+		return
+	}
+
+	if len(timeRecords) != 2 {
+		t.Fail()
+		t.Logf("The workbook should contain 2 time records but contained %d", len(timeRecords))
+		return
+	}
+
+	if !timeRecords[0].Start.Equal(timeRecord.Start) || !timeRecords[0].Stop.Equal(timeRecord.Stop) {
+		t.Fail()
+		t.Logf("The time record should start at %s and stop at %s but starts at %s and stops at %s", timeRecord.Start, timeRecord.Stop, timeRecords[0].Start, timeRecords[0].Stop)
+	}
+
+	if timeRecords[0].Description != timeRecord.Description || fmt.Sprintf("%v", timeRecords[0].Tags) != "[design review]" {
+		t.Fail()
+		t.Logf("The time record should have the description %q and the tags [design review] but was %#v", timeRecord.Description, timeRecords[0])
+	}
+}
+
+func Test_xlsxTimeRecordWriter_TimeZoneGiven_DatesAreWrittenAsLocalExcelDates(t *testing.T) {
+	// arrange
+	location, _ := time.LoadLocation("Europe/Berlin")
+
+	var output bytes.Buffer
+	writer := newXLSXTimeRecordWriter(&output, NewCSVTimeRecordMapper(date.NewISO8601Formatter()), location)
+
+	// act
+	writer.Write(getJSONTestTimeRecord("Record 1"))
+	writer.Close()
+
+	// assert
+	archive, _ := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+
+	var sheet string
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			reader, _ := file.Open()
+			content, _ := ioutil.ReadAll(reader)
+			sheet = string(content)
+		}
+	}
+
+	// 2016-08-01 11:00 in Berlin (09:00 UTC)
+	expectedStartCell := `<c r="A2" s="1"><v>42583.458333333336</v></c>`
+	if !strings.Contains(sheet, expectedStartCell) {
+		t.Fail()
+		t.Logf("The worksheet should contain the date cell %s but was:\n%s", expectedStartCell, sheet)
+	}
+
+	if !strings.Contains(sheet, `state="frozen"`) || !strings.Contains(sheet, `<cols>`) {
+		t.Fail()
+		t.Logf("The worksheet should have a frozen headline and column widths but was:\n%s", sheet)
+	}
+}
+
+func Test_readXLSXRows_SheetNameGiven_RowsOfTheSheetAreReturned(t *testing.T) {
+	// arrange
+	sheets := map[string]string{
+		"Summary": `<row r="1"><c r="A1" t="inlineStr"><is><t>Total</t></is></c></row>`,
+		"Records": `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+			`<row r="2"><c r="A2"><v>42583.375</v></c><c r="B2"><v>42583.4375</v></c><c r="C2" t="inlineStr"><is><t>Work</t></is></c></row>`,
+	}
+
+	workbook := getXLSXTestWorkbook(sheets, []string{"Summary", "Records"}, []string{"Start", "Workspace Name"})
+
+	// act
+	rows, _, err := readXLSXRows(bytes.NewReader(workbook), "Records", time.UTC, nil)
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("readXLSXRows should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	expected := `[[Start  Workspace Name] [2016-08-01T09:00:00+00:00 42583.4375 Work]]`
+	if fmt.Sprintf("%v", rows) != expected {
+		t.Fail()
+		t.Logf("readXLSXRows should have returned %s but returned %v", expected, rows)
+	}
+}
+
+func Test_readXLSXRows_EmptyTrailingCells_RowsArePaddedToTheHeadline(t *testing.T) {
+	// arrange
+	headline := []string{"Start", "Stop", "Workspace Name", "Project Name", "Client Name", "Tag(s)", "Description"}
+
+	var headlineCells string
+	for index := range headline {
+		headlineCells += fmt.Sprintf(`<c r="%s1" t="s"><v>%d</v></c>`, getXLSXColumnName(index), index)
+	}
+
+	sheets := map[string]string{
+		"Sheet1": `<row r="1">` + headlineCells + `</row>` +
+			`<row r="2"><c r="A2"><v>42583.375</v></c><c r="B2"><v>42583.416666666664</v></c><c r="C2" t="inlineStr"><is><t>W</t></is></c><c r="D2" t="inlineStr"><is><t>P</t></is></c></row>`,
+	}
+
+	workbook := getXLSXTestWorkbook(sheets, []string{"Sheet1"}, headline)
+	csvMapper := NewCSVTimeRecordMapper(date.NewISO8601Formatter())
+
+	// act
+	rows, _, err := readXLSXRows(bytes.NewReader(workbook), "", time.UTC, csvMapper.GetColumnNames())
+
+	// assert
+	if err != nil {
+		t.Fail()
+		t.Logf("readXLSXRows should not return an error but returned: %s", err.Error())
+		return
+	}
+
+	if len(rows) != 2 || len(rows[1]) != len(headline) {
+		t.Fail()
+		t.Logf("readXLSXRows should have padded the row to %d values but returned %q", len(headline), rows)
+		return
+	}
+
+	timeRecords, mapError := csvMapper.GetTimeRecords(rows, nil)
+	if mapError != nil || len(timeRecords) != 1 || timeRecords[0].ProjectName != "P" || timeRecords[0].ClientName != "" {
+		t.Fail()
+		t.Logf("The padded row should be a valid time record without client but returned %#v (error: %v)", timeRecords, mapError)
+	}
+}
+
+func Test_readXLSXRows_SheetDoesNotExist_ErrorListsTheAvailableSheets(t *testing.T) {
+	// arrange
+	workbook := getXLSXTestWorkbook(map[string]string{"Sheet1": ""}, []string{"Sheet1"}, nil)
+
+	// act
+	_, _, err := readXLSXRows(bytes.NewReader(workbook), "Records", time.UTC, nil)
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "Available worksheets: Sheet1") {
+		t.Fail()
+		t.Logf("readXLSXRows should have returned an error listing the available worksheets but returned: %v", err)
+	}
+}
+
+func Test_readXLSXRows_InputIsNoWorkbook_ErrorIsReturned(t *testing.T) {
+	// arrange
+	input := strings.NewReader("Start,Stop\n")
+
+	// act
+	_, _, err := readXLSXRows(input, "", time.UTC, nil)
+
+	// assert
+	if err == nil {
+		t.Fail()
+		t.Logf("readXLSXRows should return an error if the input is not an Excel workbook")
+	}
+}
+
+func Test_getXLSXColumnName_ColumnNamesAreReturned(t *testing.T) {
+	inputs := map[int]string{
+		0:   "A",
+		25:  "Z",
+		26:  "AA",
+		701: "ZZ",
+		702: "AAA",
+	}
+
+	for index, expected := range inputs {
+		// act
+		name := getXLSXColumnName(index)
+
+		// assert
+		if name != expected {
+			t.Fail()
+			t.Logf("getXLSXColumnName(%d) should return %q but returned %q", index, expected, name)
+		}
+
+		if getXLSXColumnIndex(name+"12") != index {
+			t.Fail()
+			t.Logf("getXLSXColumnIndex(%q) should return %d but returned %d", name+"12", index, getXLSXColumnIndex(name+"12"))
+		}
+	}
+}